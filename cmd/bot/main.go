@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
@@ -10,6 +13,7 @@ import (
 	"bot-produtos/internal/bot"
 	"bot-produtos/internal/database"
 	"bot-produtos/internal/monitor"
+	"bot-produtos/internal/notify"
 	"bot-produtos/internal/scraper"
 
 	"github.com/joho/godotenv"
@@ -19,6 +23,10 @@ import (
 var Version = "dev"
 
 func main() {
+	// Contexto cancelado ao receber SIGINT/SIGTERM (ex: restart do serviço pelo pull.sh)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Carregar variáveis de ambiente
 	if err := godotenv.Load(); err != nil {
 		log.Println("Arquivo .env não encontrado, usando variáveis de ambiente do sistema")
@@ -37,6 +45,20 @@ func main() {
 	}
 	defer db.Close()
 
+	// Atribuir produtos cadastrados antes do suporte a múltiplos chats ao chat configurado
+	if cfg.TelegramChatID != 0 {
+		if assigned, err := db.AssignLegacySubscriptions(cfg.TelegramChatID); err != nil {
+			log.Printf("Erro ao atribuir produtos antigos ao chat %d: %v", cfg.TelegramChatID, err)
+		} else if assigned > 0 {
+			log.Printf("%d produto(s) antigo(s) atribuído(s) ao chat %d", assigned, cfg.TelegramChatID)
+		}
+	} else if legacy, err := db.CountLegacySubscriptions(); err != nil {
+		log.Printf("Erro ao contar produtos antigos sem chat: %v", err)
+	} else if legacy > 0 {
+		// Inscrições sem chat não são verificadas: sem este aviso, os produtos antigos deixariam de ser monitorados em silêncio
+		log.Printf("ATENÇÃO: %d produto(s) cadastrado(s) antes do suporte a múltiplos chats NÃO estão sendo monitorados. Defina TELEGRAM_CHAT_ID com o chat que deve recebê-los e reinicie o bot.", legacy)
+	}
+
 	// Inicializar bot do Telegram
 	telegramBot, err := bot.Init(cfg.TelegramBotToken)
 	if err != nil {
@@ -44,22 +66,103 @@ func main() {
 	}
 
 	// Inicializar scrapers
-	scraperRegistry := scraper.NewRegistry()
+	scraperRegistry := scraper.NewRegistry(scraper.Options{
+		MercadoLivreAPIURL: cfg.MercadoLivreAPIURL,
+		HostRatePerMinute:  cfg.HostRequestsPerMinute,
+		HostBurst:          cfg.HostBurst,
+	})
+	if count, err := scraperRegistry.LoadConfig(cfg.ScrapersConfigPath); err == nil {
+		log.Printf("%d loja(s) carregada(s) de %s", count, cfg.ScrapersConfigPath)
+	} else if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Arquivo %s não encontrado, usando apenas os scrapers embutidos", cfg.ScrapersConfigPath)
+	} else {
+		log.Printf("Erro ao carregar %s: %v", cfg.ScrapersConfigPath, err)
+	}
+
+	// Calcular a chave canônica dos produtos antigos e juntar os cadastrados mais de uma vez
+	if merged, err := db.MergeDuplicateProducts(scraperRegistry.ProductKey); err != nil {
+		log.Printf("Erro ao juntar produtos duplicados: %v", err)
+	} else if merged > 0 {
+		log.Printf("%d produto(s) duplicado(s) juntado(s)", merged)
+	}
+
+	// Configurar notificadores
+	notifiers := []notify.Notifier{
+		notify.NewTelegramNotifier(telegramBot),
+	}
+	if len(cfg.WebhookURLs) > 0 {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(notify.WebhookConfig{
+			URLs:       cfg.WebhookURLs,
+			Secret:     cfg.WebhookSecret,
+			MaxRetries: cfg.WebhookMaxRetries,
+			Backoff:    cfg.WebhookRetryBackoff,
+		}, db))
+		log.Printf("Webhooks configurados: %d", len(cfg.WebhookURLs))
+	}
+	if cfg.SMTPHost != "" && len(cfg.EmailTo) > 0 {
+		emailNotifier := notify.NewEmailNotifier(notify.EmailConfig{
+			Host:       cfg.SMTPHost,
+			Port:       cfg.SMTPPort,
+			Username:   cfg.SMTPUsername,
+			Password:   cfg.SMTPPassword,
+			From:       cfg.EmailFrom,
+			To:         cfg.EmailTo,
+			StartTLS:   cfg.SMTPStartTLS,
+			Mode:       cfg.EmailMode,
+			DigestHour: cfg.EmailDigestHour,
+		})
+		go emailNotifier.Run(ctx)
+		notifiers = append(notifiers, emailNotifier)
+		log.Printf("Notificações por e-mail configuradas (%s) para %d destinatário(s)", cfg.EmailMode, len(cfg.EmailTo))
+	}
 
 	// Criar gerenciador de monitoramento
-	monitorInstance := monitor.New(db, telegramBot, scraperRegistry, cfg.CheckInterval)
+	monitorInstance := monitor.New(db, notifiers, scraperRegistry, monitor.Options{
+		Interval:    cfg.CheckInterval,
+		Concurrency: cfg.MonitorConcurrency,
+	})
 
 	// Iniciar monitoramento em background
-	go monitorInstance.Start()
+	go monitorInstance.Start(ctx)
 
 	// Configurar comandos do bot
-	bot.SetupCommands(telegramBot, db, monitorInstance, scraperRegistry, Version)
+	commandsDone := make(chan struct{})
+	go func() {
+		defer close(commandsDone)
+		bot.SetupCommands(ctx, telegramBot, db, monitorInstance, scraperRegistry, Version)
+	}()
 
 	// Aguardar sinal de interrupção
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
-
+	<-ctx.Done()
+	stop()
 	log.Println("Encerrando bot...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Parar de receber comandos e aguardar o comando em execução
+	select {
+	case <-commandsDone:
+	case <-shutdownCtx.Done():
+		log.Println("Prazo de encerramento expirado aguardando comandos do Telegram")
+	}
+
+	// Aguardar as verificações em andamento (e suas gravações no banco)
+	if err := monitorInstance.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Verificações interrompidas no encerramento: %v", err)
+	}
+
+	// Enviar notificações pendentes (ex: resumo de e-mail)
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelFlush()
+	for _, notifier := range notifiers {
+		if flusher, ok := notifier.(notify.Flusher); ok {
+			if err := flusher.Flush(flushCtx); err != nil {
+				log.Printf("Erro ao enviar notificações pendentes via %T: %v", notifier, err)
+			}
+		}
+	}
+
+	log.Println("Bot encerrado")
 }
 
@@ -4,16 +4,42 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config contém as configurações da aplicação
 type Config struct {
-	TelegramBotToken    string
-	TelegramChatID      int64
+	TelegramBotToken     string
+	TelegramChatID       int64
 	CheckIntervalMinutes int
-	CheckInterval       time.Duration
-	DatabasePath        string
+	CheckInterval        time.Duration
+	DatabasePath         string
+	ScrapersConfigPath   string
+	MercadoLivreAPIURL   string // Vazio desativa a API e usa apenas o HTML das páginas
+	ShutdownTimeout      time.Duration
+
+	// Ritmo das verificações
+	MonitorConcurrency    int
+	HostRequestsPerMinute float64
+	HostBurst             int
+
+	// Webhooks
+	WebhookURLs         []string
+	WebhookSecret       string
+	WebhookMaxRetries   int
+	WebhookRetryBackoff time.Duration
+
+	// E-mail
+	SMTPHost        string
+	SMTPPort        int
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPStartTLS    bool
+	EmailFrom       string
+	EmailTo         []string
+	EmailMode       string
+	EmailDigestHour int
 }
 
 // Load carrega as configurações das variáveis de ambiente
@@ -24,9 +50,21 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
-		TelegramBotToken:    token,
-		CheckIntervalMinutes: 30,
-		DatabasePath:        "./products.db",
+		TelegramBotToken:      token,
+		CheckIntervalMinutes:  30,
+		DatabasePath:          "./products.db",
+		ScrapersConfigPath:    "./scrapers.json",
+		MercadoLivreAPIURL:    "https://api.mercadolibre.com",
+		ShutdownTimeout:       30 * time.Second,
+		MonitorConcurrency:    4,
+		HostRequestsPerMinute: 30,
+		HostBurst:             1,
+		WebhookMaxRetries:     3,
+		WebhookRetryBackoff:   2 * time.Second,
+		SMTPPort:              587,
+		SMTPStartTLS:          true,
+		EmailMode:             "immediate",
+		EmailDigestHour:       8,
 	}
 
 	// Chat ID é opcional (pode ser usado para restrições, mas não obrigatório)
@@ -44,6 +82,91 @@ func Load() (*Config, error) {
 	}
 	cfg.CheckInterval = time.Duration(cfg.CheckIntervalMinutes) * time.Minute
 
+	// Arquivo com as lojas configuráveis (scrapers declarativos)
+	if envScrapers := os.Getenv("SCRAPERS_CONFIG"); envScrapers != "" {
+		cfg.ScrapersConfigPath = envScrapers
+	}
+
+	// API pública do Mercado Livre ("off" lê apenas o HTML das páginas)
+	if envAPI := os.Getenv("MERCADOLIVRE_API_URL"); envAPI == "off" {
+		cfg.MercadoLivreAPIURL = ""
+	} else if envAPI != "" {
+		cfg.MercadoLivreAPIURL = envAPI
+	}
+
+	// Prazo para concluir as verificações em andamento ao encerrar
+	if envTimeout := os.Getenv("SHUTDOWN_TIMEOUT_SECONDS"); envTimeout != "" {
+		if parsed, err := strconv.Atoi(envTimeout); err == nil && parsed > 0 {
+			cfg.ShutdownTimeout = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// Concorrência e limite de requisições por loja
+	if envConcurrency := os.Getenv("MONITOR_CONCURRENCY"); envConcurrency != "" {
+		if parsed, err := strconv.Atoi(envConcurrency); err == nil && parsed > 0 {
+			cfg.MonitorConcurrency = parsed
+		}
+	}
+	if envRate := os.Getenv("HOST_REQUESTS_PER_MINUTE"); envRate != "" {
+		if parsed, err := strconv.ParseFloat(envRate, 64); err == nil && parsed >= 0 {
+			cfg.HostRequestsPerMinute = parsed
+		}
+	}
+	if envBurst := os.Getenv("HOST_BURST"); envBurst != "" {
+		if parsed, err := strconv.Atoi(envBurst); err == nil && parsed > 0 {
+			cfg.HostBurst = parsed
+		}
+	}
+
+	// Webhooks (opcionais)
+	for _, url := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.WebhookURLs = append(cfg.WebhookURLs, url)
+		}
+	}
+	cfg.WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	if envRetries := os.Getenv("WEBHOOK_MAX_RETRIES"); envRetries != "" {
+		if parsed, err := strconv.Atoi(envRetries); err == nil && parsed >= 0 {
+			cfg.WebhookMaxRetries = parsed
+		}
+	}
+	if envBackoff := os.Getenv("WEBHOOK_RETRY_BACKOFF_SECONDS"); envBackoff != "" {
+		if parsed, err := strconv.Atoi(envBackoff); err == nil && parsed > 0 {
+			cfg.WebhookRetryBackoff = time.Duration(parsed) * time.Second
+		}
+	}
+
+	// E-mail (opcional)
+	cfg.SMTPHost = os.Getenv("SMTP_HOST")
+	if envPort := os.Getenv("SMTP_PORT"); envPort != "" {
+		if parsed, err := strconv.Atoi(envPort); err == nil && parsed > 0 {
+			cfg.SMTPPort = parsed
+		}
+	}
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	if envStartTLS := os.Getenv("SMTP_STARTTLS"); envStartTLS != "" {
+		if parsed, err := strconv.ParseBool(envStartTLS); err == nil {
+			cfg.SMTPStartTLS = parsed
+		}
+	}
+	cfg.EmailFrom = os.Getenv("EMAIL_FROM")
+	for _, to := range strings.Split(os.Getenv("EMAIL_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.EmailTo = append(cfg.EmailTo, to)
+		}
+	}
+	if envMode := os.Getenv("EMAIL_MODE"); envMode != "" {
+		if envMode != "immediate" && envMode != "digest" {
+			return nil, fmt.Errorf("EMAIL_MODE inválido: %s (use immediate ou digest)", envMode)
+		}
+		cfg.EmailMode = envMode
+	}
+	if envHour := os.Getenv("EMAIL_DIGEST_HOUR"); envHour != "" {
+		if parsed, err := strconv.Atoi(envHour); err == nil && parsed >= 0 && parsed <= 23 {
+			cfg.EmailDigestHour = parsed
+		}
+	}
+
 	return cfg, nil
 }
-
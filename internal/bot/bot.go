@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -28,18 +29,34 @@ func Init(token string) (*tgbotapi.BotAPI, error) {
 	return bot, nil
 }
 
-// GetAuthorizedChatID retorna o Chat ID autorizado (se configurado)
-func GetAuthorizedChatID() (int64, bool) {
-	chatIDStr := os.Getenv("TELEGRAM_CHAT_ID")
-	if chatIDStr == "" {
-		return 0, false
-	}
-
-	chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+// GetAdminChatID retorna o chat administrador (TELEGRAM_CHAT_ID), usado em comandos de manutenção
+func GetAdminChatID() (int64, bool) {
+	chatID, err := strconv.ParseInt(strings.TrimSpace(os.Getenv("TELEGRAM_CHAT_ID")), 10, 64)
 	if err != nil {
 		return 0, false
 	}
-
 	return chatID, true
 }
 
+// GetAuthorizedChatIDs retorna os Chat IDs autorizados a usar o bot (se configurados)
+// TELEGRAM_CHAT_ID é sempre autorizado; TELEGRAM_ALLOWED_CHAT_IDS adiciona outros usuários ou grupos
+func GetAuthorizedChatIDs() (map[int64]bool, bool) {
+	chatIDs := make(map[int64]bool)
+	values := []string{os.Getenv("TELEGRAM_CHAT_ID")}
+	values = append(values, strings.Split(os.Getenv("TELEGRAM_ALLOWED_CHAT_IDS"), ",")...)
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		chatID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Printf("Chat ID inválido ignorado: %s", value)
+			continue
+		}
+		chatIDs[chatID] = true
+	}
+
+	return chatIDs, len(chatIDs) > 0
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/chart"
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chartPeriods mapeia os períodos aceitos pelo /chart para a quantidade de dias
+var chartPeriods = map[string]int{
+	"30d": 30,
+	"90d": 90,
+	"1y":  365,
+}
+
+func handleChart(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /chart <id> [30d|90d|1y]\n\nExemplo: /chart 1 90d")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	period := "30d"
+	if len(parts) >= 3 {
+		period = strings.ToLower(parts[2])
+	}
+	days, ok := chartPeriods[period]
+	if !ok {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Período inválido. Use 30d, 90d ou 1y.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	history, err := db.GetPriceHistory(id, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao buscar histórico: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	var points []chart.Point
+	for _, h := range history {
+		if h.Status == models.ScrapeStatusOK && h.CurrentPrice.IsPositive() {
+			points = append(points, chart.Point{Time: h.CheckedAt, Price: h.CurrentPrice.Float64()})
+		}
+	}
+	if len(points) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("📈 Nenhum preço registrado para este produto em %s.", period))
+		bot.Send(msg)
+		return
+	}
+
+	opts := chart.Options{
+		Title:       sub.Product.Name,
+		TargetPrice: sub.TargetPrice.Float64(),
+	}
+	lowest, err := db.GetLowestPrice(id)
+	if err != nil {
+		log.Printf("Erro ao buscar menor preço do produto %d: %v", id, err)
+	} else if lowest != nil {
+		opts.AllTimeLow = &chart.Point{Time: lowest.CheckedAt, Price: lowest.CurrentPrice.Float64()}
+	}
+
+	data, err := chart.RenderPNG(points, opts)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao gerar gráfico: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("produto-%d-%s.png", id, period),
+		Bytes: data,
+	})
+	photo.Caption = fmt.Sprintf("📈 %s (%s)", sub.Product.Name, period)
+	if lowest != nil {
+		photo.Caption += fmt.Sprintf("\nMenor preço: %s em %s", lowest.CurrentPrice, lowest.CheckedAt.Local().Format("02/01/2006"))
+	}
+	if _, err := bot.Send(photo); err != nil {
+		log.Printf("Erro ao enviar gráfico: %v", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Erro ao enviar gráfico.")
+		bot.Send(msg)
+	}
+}
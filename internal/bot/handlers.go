@@ -1,12 +1,15 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 
 	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
 	"bot-produtos/internal/monitor"
 	"bot-produtos/internal/scraper"
 
@@ -21,15 +24,58 @@ func escapeHTML(text string) string {
 	return text
 }
 
-// SetupCommands configura os handlers de comandos do bot
-func SetupCommands(bot *tgbotapi.BotAPI, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry, version string) {
-	authorizedChatID, hasAuth := GetAuthorizedChatID()
+// confidenceLabel descreve a confiança da extração do scraper genérico; vazio para scrapers dedicados
+func confidenceLabel(confidence scraper.Confidence) string {
+	switch confidence {
+	case scraper.ConfidenceHigh:
+		return "alta (dados estruturados do produto)"
+	case scraper.ConfidenceMedium:
+		return "média (meta tags da página)"
+	case scraper.ConfidenceLow:
+		return "baixa (texto da página, confira o valor)"
+	}
+	return ""
+}
+
+// SetupCommands configura os handlers de comandos do bot e processa as mensagens até o contexto ser cancelado
+// O comando em execução no momento do cancelamento é concluído antes de retornar
+func SetupCommands(ctx context.Context, bot *tgbotapi.BotAPI, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry, version string) {
+	authorizedChatIDs, hasAuth := GetAuthorizedChatIDs()
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates := bot.GetUpdatesChan(u)
+	defer bot.StopReceivingUpdates()
+
+	// /add aguardando a escolha da variante no teclado inline
+	prompts := newVariantPrompts()
+
+	for {
+		var update tgbotapi.Update
+		select {
+		case <-ctx.Done():
+			log.Println("Parando de receber comandos do Telegram")
+			return
+		case received, ok := <-updates:
+			if !ok {
+				return
+			}
+			update = received
+		}
+
+		// Botões do teclado de variantes do /add
+		if query := update.CallbackQuery; query != nil {
+			if query.Message == nil || !strings.HasPrefix(query.Data, variantCallbackPrefix) {
+				continue
+			}
+			if hasAuth && !authorizedChatIDs[query.Message.Chat.ID] {
+				bot.Request(tgbotapi.NewCallback(query.ID, "Você não está autorizado a usar este bot."))
+				continue
+			}
+			handleVariantCallback(ctx, bot, query, db, monitor, registry, prompts)
+			continue
+		}
 
-	for update := range updates {
 		if update.Message == nil {
 			continue
 		}
@@ -55,7 +101,7 @@ func SetupCommands(bot *tgbotapi.BotAPI, db *database.DB, monitor *monitor.Monit
 		isPublicCommand := command == "/start" || command == "/help" || command == "/version"
 
 		// Verificar autorização se configurado (exceto para comandos públicos)
-		if !isPublicCommand && hasAuth && update.Message.Chat.ID != authorizedChatID {
+		if !isPublicCommand && hasAuth && !authorizedChatIDs[update.Message.Chat.ID] {
 			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Você não está autorizado a usar este bot.")
 			bot.Send(msg)
 			continue
@@ -67,13 +113,35 @@ func SetupCommands(bot *tgbotapi.BotAPI, db *database.DB, monitor *monitor.Monit
 		case "/version":
 			handleVersion(bot, update.Message.Chat.ID, version)
 		case "/add":
-			handleAddProduct(bot, update.Message, db, registry)
+			handleAddProduct(ctx, bot, update.Message, db, monitor, registry, prompts, true)
 		case "/list":
 			handleListProducts(bot, update.Message.Chat.ID, db)
 		case "/remove":
 			handleRemoveProduct(bot, update.Message, db)
 		case "/check":
-			handleCheckProduct(bot, update.Message, db, monitor, registry)
+			handleCheckProduct(ctx, bot, update.Message, db, monitor, registry)
+		case "/history":
+			handleHistory(bot, update.Message, db)
+		case "/chart":
+			handleChart(bot, update.Message, db)
+		case "/interval":
+			handleInterval(bot, update.Message, db, monitor)
+		case "/stock":
+			handleStockAlert(bot, update.Message, db)
+		case "/cep":
+			handleCEP(bot, update.Message, db)
+		case "/shipping":
+			handleShipping(bot, update.Message, db)
+		case "/basis":
+			handlePriceBasis(bot, update.Message, db)
+		case "/installments":
+			handleInstallments(bot, update.Message, db)
+		case "/coupon":
+			handleCoupon(bot, update.Message, db)
+		case "/seller":
+			handleSellerFilter(bot, update.Message, db)
+		case "/reloadscrapers":
+			handleReloadScrapers(bot, update.Message.Chat.ID, registry)
 		default:
 			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Comando não reconhecido. Use /help para ver os comandos disponíveis.")
 			bot.Send(msg)
@@ -81,6 +149,19 @@ func SetupCommands(bot *tgbotapi.BotAPI, db *database.DB, monitor *monitor.Monit
 	}
 }
 
+// getOwnSubscription retorna a inscrição ativa do chat no produto
+// Produtos de outros chats são tratados como inexistentes
+func getOwnSubscription(db *database.DB, chatID, productID int64) (*models.Subscription, error) {
+	sub, err := db.GetSubscription(chatID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Active {
+		return nil, fmt.Errorf("inscrição inativa")
+	}
+	return sub, nil
+}
+
 func handleHelp(bot *tgbotapi.BotAPI, chatID int64) {
 	helpText := `🤖 <b>Bot de Monitoramento de Preços</b>
 
@@ -90,8 +171,14 @@ func handleHelp(bot *tgbotapi.BotAPI, chatID int64) {
 Uso: /add &lt;URL&gt; &lt;preço_alvo&gt; OU /add &lt;URL&gt; &lt;desconto%&gt;
 Exemplo: /add https://mercadolivre.com.br/produto 3000
 Exemplo: /add https://mercadolivre.com.br/produto 15% (para 15% de desconto)
+Exemplo: /add https://mercadolivre.com.br/produto stock (avisar quando voltar ao estoque)
+Exemplo: /add https://mercadolivre.com.br/produto 3000 frete (alvo com o frete incluído)
+Exemplo: /add https://mercadolivre.com.br/produto 200 parcela (alvo no valor da parcela)
+Exemplo: /add https://mercadolivre.com.br/produto 12x (avisar quando houver 12x sem juros)
+Exemplo: /add https://mercadolivre.com.br/produto 3000 cupom (alvo com o preço depois do cupom)
+Exemplo: /add https://mercadolivre.com.br/produto 3000 oficial (apenas ofertas de lojas oficiais)
 
-<b>/list</b> - Listar todos os produtos monitorados
+<b>/list</b> - Listar os produtos monitorados neste chat
 
 <b>/remove &lt;id&gt;</b> - Remover produto do monitoramento
 Exemplo: /remove 1
@@ -99,6 +186,38 @@ Exemplo: /remove 1
 <b>/check &lt;id&gt;</b> - Verificar preço de um produto agora
 Exemplo: /check 1
 
+<b>/history &lt;id&gt; [dias]</b> - Mostrar mínimo, máximo, média e mudanças de preço
+Exemplo: /history 1 30
+
+<b>/chart &lt;id&gt; [30d|90d|1y]</b> - Enviar gráfico do preço ao longo do tempo
+Exemplo: /chart 1 90d
+
+<b>/interval &lt;id&gt; &lt;duração&gt;</b> - Definir de quanto em quanto tempo o produto é verificado
+Exemplo: /interval 1 5m (use "padrao" para voltar ao intervalo global)
+
+<b>/stock &lt;id&gt; [off]</b> - Avisar quando um produto esgotado voltar ao estoque
+Exemplo: /stock 1
+
+<b>/cep [cep|off]</b> - Definir o CEP usado para cotar o frete
+Exemplo: /cep 01001-000
+
+<b>/shipping &lt;id&gt; [off]</b> - Comparar o alvo com o preço mais o frete
+Exemplo: /shipping 1
+
+<b>/basis &lt;id&gt; &lt;menor|pix|cartao|parcela&gt;</b> - Escolher a qual preço o alvo se aplica
+Exemplo: /basis 1 pix
+
+<b>/installments &lt;id&gt; &lt;parcelas&gt; [valor_máximo]</b> - Avisar quando houver parcelamento sem juros (use off para remover)
+Exemplo: /installments 1 12 200
+
+<b>/coupon &lt;id&gt; [off]</b> - Comparar o alvo com o preço depois do cupom
+Exemplo: /coupon 1
+
+<b>/seller &lt;id&gt; [oficial] [reputação|off]</b> - Escolher os vendedores considerados em páginas com várias ofertas
+Exemplo: /seller 1 oficial verde
+
+<b>/reloadscrapers</b> - Recarregar as lojas do arquivo de scrapers (apenas administrador)
+
 <b>/version</b> - Mostrar versão do bot
 
 <b>/help</b> - Mostrar esta mensagem de ajuda
@@ -126,10 +245,12 @@ func handleVersion(bot *tgbotapi.BotAPI, chatID int64, version string) {
 	}
 }
 
-func handleAddProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB, registry *scraper.Registry) {
+// handleAddProduct trata o /add; com chooseVariant, produtos com variantes de preço diferente
+// são salvos só depois da escolha da variante no teclado (ver askVariant)
+func handleAddProduct(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry, prompts *variantPrompts, chooseVariant bool) {
 	parts := strings.Fields(message.Text)
 	if len(parts) < 3 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /add <URL> <preço_alvo> OU /add <URL> <desconto%>\n\nExemplo: /add https://mercadolivre.com.br/produto 3000\nExemplo: /add https://mercadolivre.com.br/produto 15%")
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /add <URL> <preço_alvo> OU /add <URL> <desconto%> OU /add <URL> <parcelas>x OU /add <URL> stock\nOpções depois do alvo: stock (avisar quando voltar ao estoque), frete (alvo com frete), cupom (alvo com cupom), oficial (apenas lojas oficiais), pix/cartao/parcela (preço ao qual o alvo se aplica)\n\nExemplo: /add https://mercadolivre.com.br/produto 3000\nExemplo: /add https://mercadolivre.com.br/produto 15%\nExemplo: /add https://mercadolivre.com.br/produto 3000 stock frete")
 		bot.Send(msg)
 		return
 	}
@@ -138,8 +259,39 @@ func handleAddProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *datab
 	targetStr := parts[2]
 
 	// Verificar se é percentual ou preço
-	var targetPrice, targetDiscount float64
-	if strings.HasSuffix(targetStr, "%") {
+	var targetPrice money.Money
+	var targetDiscount float64
+	var notifyInStock, withShipping, withCoupon bool
+	var sellerFilter models.SellerFilter
+	var priceBasis models.PriceBasis
+	var targetInstallments int
+	// Opções depois do alvo (ex: /add <url> 3000 stock frete); "stock" também pode substituir o alvo
+	for _, option := range parts[3:] {
+		if basis, ok := parsePriceBasis(option); ok {
+			priceBasis = basis
+			continue
+		}
+		switch {
+		case isStockKeyword(option):
+			notifyInStock = true
+		case isShippingKeyword(option):
+			withShipping = true
+		case isCouponKeyword(option):
+			withCoupon = true
+		case isOfficialStoreKeyword(option):
+			sellerFilter.OfficialStoreOnly = true
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Opção desconhecida: %s. Use stock, frete, cupom, oficial, pix, cartao ou parcela.", option))
+			bot.Send(msg)
+			return
+		}
+	}
+	if isStockKeyword(targetStr) {
+		notifyInStock = true
+	} else if count, ok := parseInstallmentsTarget(targetStr); ok {
+		// Ex: /add <url> 12x avisa quando houver 12 parcelas sem juros ou mais
+		targetInstallments = count
+	} else if strings.HasSuffix(targetStr, "%") {
 		discountStr := strings.TrimSuffix(targetStr, "%")
 		discount, err := strconv.ParseFloat(discountStr, 64)
 		if err != nil || discount < 0 || discount > 100 {
@@ -149,112 +301,230 @@ func handleAddProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *datab
 		}
 		targetDiscount = discount
 	} else {
-		price, err := strconv.ParseFloat(targetStr, 64)
-		if err != nil || price <= 0 {
-			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Preço inválido. Use um valor numérico positivo.")
+		// Aceita o formato brasileiro (1.299,90) e também 1299.90
+		price, err := money.Parse(targetStr, money.PtBR, money.DefaultCurrency)
+		if err != nil || !price.IsPositive() {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Preço inválido. Use um valor positivo, como 1299,90 ou 1.299,90.")
 			bot.Send(msg)
 			return
 		}
 		targetPrice = price
 	}
 
-	// Encontrar scraper apropriado
+	// Seguir links encurtados e de afiliados até a página do produto e remover parâmetros de rastreamento
+	resolvedURL, err := registry.ResolveURL(ctx, url)
+	if err != nil {
+		log.Printf("Erro ao resolver o link %s: %v", url, err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Não consegui abrir o link: %v\n\nEnvie o link completo do produto, começando com http:// ou https://.", err))
+		bot.Send(msg)
+		return
+	}
+	url = resolvedURL
+
+	// Encontrar scraper apropriado (lojas sem scraper dedicado usam o genérico)
 	scraper := registry.FindScraper(url)
 	if scraper == nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ URL não suportada. Atualmente suportamos apenas Mercado Livre.")
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ URL inválida. Envie o link completo do produto, começando com http:// ou https://.")
 		bot.Send(msg)
 		return
 	}
 
-	// Buscar nome do produto
-	name, err := scraper.GetName(url)
-	if err != nil {
-		log.Printf("Erro ao buscar nome do produto: %v", err)
+	// Buscar nome, preço atual, original e desconto em uma única requisição
+	snapshot, scrapeErr := scraper.Scrape(ctx, url)
+	name := snapshot.Name
+	if scrapeErr != nil && snapshot.Extraction.Scraper == "generic" {
+		// Sem scraper dedicado, uma página sem preço legível não tem como ser monitorada
+		log.Printf("Scraper genérico não encontrou preço em %s: %v", url, scrapeErr)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Não encontrei o preço nesta página. Esta loja ainda não tem suporte dedicado e a página não publica dados estruturados do produto.")
+		bot.Send(msg)
+		return
+	}
+	if scrapeErr != nil {
+		log.Printf("Erro ao buscar dados do produto: %v", scrapeErr)
 		name = "Produto sem nome"
+	} else if snapshot.URL != "" {
+		// Salvar a URL canônica (ex: link amzn.to resolvido, sem parâmetros de rastreamento)
+		url = snapshot.URL
+	}
+
+	// Produtos com variantes (ex: 127V/220V) têm preços diferentes: perguntar qual monitorar
+	if chooseVariant && scrapeErr == nil && len(snapshot.Variants) > 1 {
+		askVariant(bot, message.Chat.ID, name, snapshot, parts[2:], prompts)
+		return
+	}
+
+	subscription := models.Subscription{
+		ChatID:         message.Chat.ID,
+		TargetPrice:    targetPrice,
+		TargetDiscount: targetDiscount,
+		NotifyInStock:  notifyInStock,
+		WithShipping:   withShipping,
+		WithCoupon:     withCoupon,
+		PriceBasis:     priceBasis,
+		SellerFilter:   sellerFilter,
+
+		TargetInstallments: targetInstallments,
 	}
 
-	// Adicionar ao banco
-	err = db.AddProduct(url, name, targetPrice, targetDiscount)
+	// Adicionar ao banco (o produto é compartilhado entre chats que monitoram o mesmo produto,
+	// identificado pela chave canônica mesmo quando os links são diferentes)
+	// Se o chat já monitora o produto, os alvos informados substituem os anteriores
+	productID, updated, err := db.AddProductSubscription(url, registry.ProductKey(url), name, snapshot.Variant.Label, subscription)
 	if err != nil {
-		var msg tgbotapi.MessageConfig
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			msg = tgbotapi.NewMessage(message.Chat.ID, "❌ Este produto já está sendo monitorado.")
-		} else {
-			msg = tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao adicionar produto: %v", err))
-		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao adicionar produto: %v", err))
 		bot.Send(msg)
 		return
 	}
+	// Agendar o produto novo (ou o intervalo da inscrição) sem esperar a próxima sincronização do monitor
+	monitor.Wake()
+
+	currentPrice := snapshot.CurrentPrice
+	originalPrice := snapshot.OriginalPrice
+	discountPercent := snapshot.Discount
+
+	resolvedInfo := ""
+	if url != parts[1] {
+		resolvedInfo = fmt.Sprintf("\n🔗 Link resolvido a partir de %s", parts[1])
+	}
 
-	// Buscar preço atual, original e desconto
-	currentPrice, err := scraper.GetPrice(url)
-	originalPrice, _ := scraper.GetOriginalPrice(url)
-	discountPercent, _ := scraper.GetDiscount(url)
-	
 	priceInfo := ""
 	discountInfo := ""
-	if err == nil {
-		priceInfo = fmt.Sprintf("\nPreço atual: R$ %.2f", currentPrice)
-		
-		// Atualizar preços no banco
-		products, _ := db.GetActiveProducts()
-		if len(products) > 0 {
-			if discountPercent > 0 || originalPrice > 0 {
-				db.UpdateProductPricesWithDiscount(products[len(products)-1].ID, currentPrice, originalPrice, discountPercent)
-			} else {
-				db.UpdateProductPrice(products[len(products)-1].ID, currentPrice)
-			}
+	if scrapeErr == nil {
+		if currentPrice.IsPositive() {
+			priceInfo = fmt.Sprintf("\nPreço atual: %s", currentPrice)
 		}
-		
+		if info := paymentInfo(snapshot.CashPrice, snapshot.CardPrice, snapshot.Installments); info != "" {
+			priceInfo += "\n" + info
+		}
+		if info := availabilityInfo(string(snapshot.Availability), snapshot.StockQuantity); info != "" {
+			priceInfo += "\n" + info
+		}
+		if snapshot.Variant.Label != "" {
+			priceInfo += fmt.Sprintf("\n🔀 Variante: %s", snapshot.Variant.Label)
+		}
+		if snapshot.Seller.Known() {
+			priceInfo += fmt.Sprintf("\n🏪 Vendedor: %s", snapshot.Seller)
+		}
+		if info := offersInfo(snapshot.Offers, sellerFilter); info != "" {
+			priceInfo += "\n" + info
+		}
+		if info := shippingInfo(snapshot.Shipping, currentPrice); info != "" {
+			priceInfo += "\n" + info
+		}
+		if info := promotionInfo(snapshot.Coupons, snapshot.Deal, currentPrice); info != "" {
+			priceInfo += "\n" + info
+		}
+		if label := confidenceLabel(snapshot.Extraction.Confidence); label != "" {
+			priceInfo += fmt.Sprintf("\n🔎 Loja sem suporte dedicado. Confiança na leitura do preço: %s", label)
+		}
+
+		// Atualizar preços no banco e registrar a primeira observação no histórico
+		db.UpdateProductAvailability(productID, string(snapshot.Availability), snapshot.StockQuantity)
+		db.UpdateProductPaymentOptions(productID, snapshot.CashPrice, snapshot.CardPrice, snapshot.Installments)
+		coupon, _ := models.BestCoupon(snapshot.Coupons, currentPrice)
+		db.UpdateProductPromotions(productID, coupon, snapshot.Deal)
+		if len(snapshot.Offers) > 0 {
+			db.ReplaceProductOffers(productID, snapshot.Offers)
+		}
+		if !currentPrice.IsPositive() {
+			// Produto esgotado sem preço na página: só a disponibilidade é registrada
+		} else if discountPercent > 0 || originalPrice.IsPositive() {
+			db.UpdateProductPricesWithDiscount(productID, currentPrice, originalPrice, discountPercent)
+		} else {
+			db.UpdateProductPrice(productID, currentPrice)
+		}
+		db.AddPriceHistory(models.PriceHistory{
+			ProductID:     productID,
+			CheckedAt:     snapshot.FetchedAt,
+			CurrentPrice:  currentPrice,
+			OriginalPrice: originalPrice,
+			Discount:      discountPercent,
+			Availability:  string(snapshot.Availability),
+			StockQuantity: snapshot.StockQuantity,
+			Status:        models.ScrapeStatusOK,
+		})
+
 		// Mostrar desconto do site se disponível
 		if discountPercent > 0 {
-			if originalPrice > 0 {
-				discountInfo = fmt.Sprintf("\n🎉 Produto está em promoção! %.1f%% OFF (de R$ %.2f)", discountPercent, originalPrice)
+			if originalPrice.IsPositive() {
+				discountInfo = fmt.Sprintf("\n🎉 Produto está em promoção! %.1f%% OFF (de %s)", discountPercent, originalPrice)
 			} else {
 				discountInfo = fmt.Sprintf("\n🎉 Produto está em promoção! %.1f%% OFF", discountPercent)
 			}
-		} else if originalPrice > 0 && originalPrice > currentPrice {
+		} else if originalPrice.IsPositive() && currentPrice.Less(originalPrice) {
 			// Calcular desconto se não encontrou no site mas tem preço original
-			discount := ((originalPrice - currentPrice) / originalPrice) * 100
-			discountInfo = fmt.Sprintf("\n🎉 Produto está em promoção! %.1f%% OFF (de R$ %.2f)", discount, originalPrice)
-		} else if targetPrice > 0 && currentPrice < targetPrice {
+			discount := currentPrice.DiscountFrom(originalPrice)
+			discountInfo = fmt.Sprintf("\n🎉 Produto está em promoção! %.1f%% OFF (de %s)", discount, originalPrice)
+		} else if targetPrice.IsPositive() && currentPrice.IsPositive() && currentPrice.Less(targetPrice) {
 			// Se não tem preço original mas está abaixo do alvo
-			discount := ((targetPrice - currentPrice) / targetPrice) * 100
+			discount := currentPrice.DiscountFrom(targetPrice)
 			discountInfo = fmt.Sprintf("\n🎉 Produto já está abaixo do preço alvo! Desconto: %.1f%%", discount)
-		} else if targetPrice > 0 && currentPrice > targetPrice {
+		} else if targetPrice.IsPositive() && currentPrice.IsPositive() && targetPrice.Less(currentPrice) {
 			// Se o preço atual está acima do alvo, mostrar quanto falta
-			diff := currentPrice - targetPrice
-			discountInfo = fmt.Sprintf("\n💡 Faltam R$ %.2f para atingir o preço alvo", diff)
+			diff := currentPrice.Sub(targetPrice)
+			discountInfo = fmt.Sprintf("\n💡 Faltam %s para atingir o preço alvo", diff)
 		}
 	}
 
+	header := "✅ Produto adicionado com sucesso!"
+	if updated {
+		header = "✅ Este produto já estava sendo monitorado. Os alvos e opções foram substituídos pelos informados agora."
+	}
 	response := fmt.Sprintf(
-		"✅ Produto adicionado com sucesso!\n\n"+
+		"%s\n\n"+
+			"ID: %d\n"+
 			"Nome: %s\n"+
-			"URL: %s%s%s",
-		name, url, priceInfo, discountInfo,
+			"URL: %s%s%s%s",
+		header, productID, name, url, resolvedInfo, priceInfo, discountInfo,
 	)
 
-	if targetPrice > 0 {
-		response += fmt.Sprintf("\nPreço alvo: R$ %.2f", targetPrice)
+	if targetPrice.IsPositive() {
+		response += fmt.Sprintf("\nPreço alvo: %s", targetPrice)
+		if priceBasis != models.PriceBasisLowest {
+			response += fmt.Sprintf(" (preço %s)", priceBasis.Label())
+		}
 	}
 	if targetDiscount > 0 {
 		response += fmt.Sprintf("\nDesconto alvo: %.1f%%", targetDiscount)
 	}
+	if targetInstallments > 0 {
+		response += fmt.Sprintf("\nParcelamento alvo: %s", subscription.InstallmentsTarget())
+		if subscription.InstallmentsMet(snapshot.Installments) {
+			response += " (já disponível; o aviso vale para quando deixar de atender e voltar)"
+		}
+	}
+	if withCoupon {
+		response += "\n🎟️ O preço alvo considera o melhor cupom ativo"
+	}
+	if sellerFilter.Active() {
+		response += fmt.Sprintf("\n🏪 Os alvos consideram apenas ofertas de vendedores com: %s", sellerFilter)
+	}
+	if withShipping {
+		response += "\n🚚 O preço alvo considera o frete"
+		if cep, err := db.GetChatCEP(message.Chat.ID); err == nil && cep == "" {
+			response += " (configure seu CEP com /cep para cotar o frete até você)"
+		}
+	}
+	if notifyInStock {
+		response += "\n🔔 Você será avisado quando o produto voltar ao estoque"
+		if snapshot.Availability.Purchasable() {
+			response += " (ele está disponível agora; o aviso vale para quando esgotar e voltar)"
+		}
+	}
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, response)
 	bot.Send(msg)
 }
 
 func handleListProducts(bot *tgbotapi.BotAPI, chatID int64, db *database.DB) {
-	products, err := db.GetActiveProducts()
+	subscriptions, err := db.GetChatSubscriptions(chatID)
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Erro ao listar produtos: %v", err))
 		bot.Send(msg)
 		return
 	}
 
-	if len(products) == 0 {
+	if len(subscriptions) == 0 {
 		msg := tgbotapi.NewMessage(chatID, "📋 Nenhum produto sendo monitorado no momento.")
 		bot.Send(msg)
 		return
@@ -263,48 +533,93 @@ func handleListProducts(bot *tgbotapi.BotAPI, chatID int64, db *database.DB) {
 	var response strings.Builder
 	response.WriteString("📋 <b>Produtos em Monitoramento:</b>\n\n")
 
-	for _, p := range products {
+	for _, sub := range subscriptions {
+		p := sub.Product
+
 		// Escapar HTML no nome do produto
 		productName := escapeHTML(p.Name)
 		
 		response.WriteString(fmt.Sprintf("🆔 <b>ID: %d</b>\n", p.ID))
 		response.WriteString(fmt.Sprintf("📦 %s\n", productName))
+		if p.Variant != "" {
+			response.WriteString(fmt.Sprintf("🔀 Variante: %s\n", escapeHTML(p.Variant)))
+		}
 
-		if p.CurrentPrice > 0 {
-			response.WriteString(fmt.Sprintf("💰 <b>Preço atual: R$ %.2f</b>\n", p.CurrentPrice))
+		if p.CurrentPrice.IsPositive() {
+			response.WriteString(fmt.Sprintf("💰 <b>Preço atual: %s</b>\n", p.CurrentPrice))
 			
 			// Mostrar desconto do banco se disponível
 			if p.Discount > 0 {
-				if p.OriginalPrice > 0 {
-					response.WriteString(fmt.Sprintf("🎉 <b>%.1f%% OFF</b> (de R$ %.2f)\n", p.Discount, p.OriginalPrice))
+				if p.OriginalPrice.IsPositive() {
+					response.WriteString(fmt.Sprintf("🎉 <b>%.1f%% OFF</b> (de %s)\n", p.Discount, p.OriginalPrice))
 				} else {
 					response.WriteString(fmt.Sprintf("🎉 <b>%.1f%% OFF</b>\n", p.Discount))
 				}
-			} else if p.OriginalPrice > 0 && p.OriginalPrice > p.CurrentPrice {
+			} else if p.OriginalPrice.IsPositive() && p.CurrentPrice.Less(p.OriginalPrice) {
 				// Calcular desconto se não tem no banco mas tem preço original
-				discount := ((p.OriginalPrice - p.CurrentPrice) / p.OriginalPrice) * 100
-				response.WriteString(fmt.Sprintf("🎉 <b>%.1f%% OFF</b> (de R$ %.2f)\n", discount, p.OriginalPrice))
+				discount := p.CurrentPrice.DiscountFrom(p.OriginalPrice)
+				response.WriteString(fmt.Sprintf("🎉 <b>%.1f%% OFF</b> (de %s)\n", discount, p.OriginalPrice))
 			}
 		} else {
 			response.WriteString("💰 <b>Preço atual: Não verificado ainda</b>\n")
 		}
 
-		if p.TargetPrice > 0 {
-			diff := p.CurrentPrice - p.TargetPrice
-			if p.CurrentPrice > 0 && diff > 0 {
+		if info := paymentInfo(p.CashPrice, p.CardPrice, p.Installments); info != "" {
+			response.WriteString(info + "\n")
+		}
+		if info := promotionInfo(productCoupons(p), p.Deal, p.CurrentPrice); info != "" {
+			response.WriteString(escapeHTML(info) + "\n")
+		}
+
+		if sub.TargetPrice.IsPositive() {
+			// O alvo é comparado com o preço da base escolhida (à vista, cartão, parcela...)
+			basisPrice := sub.ComparedPrice(p.PriceFor(sub.PriceBasis), p.Coupon, models.Shipping{})
+			target := sub.TargetPrice.String()
+			if sub.PriceBasis != models.PriceBasisLowest {
+				target += fmt.Sprintf(" (preço %s)", sub.PriceBasis.Label())
+			}
+			if sub.WithCoupon {
+				target += " com cupom"
+			}
+			diff := basisPrice.Sub(sub.TargetPrice)
+			if basisPrice.IsPositive() && diff.IsPositive() {
 				// Calcular desconto em relação ao preço alvo
-				discount := (diff / p.TargetPrice) * 100
-				response.WriteString(fmt.Sprintf("🎯 Preço alvo: R$ %.2f (faltam R$ %.2f - %.1f%% acima)\n", p.TargetPrice, diff, discount))
-			} else if p.CurrentPrice > 0 && diff <= 0 {
+				discount := float64(diff.Cents) / float64(sub.TargetPrice.Cents) * 100
+				response.WriteString(fmt.Sprintf("🎯 Preço alvo: %s (faltam %s - %.1f%% acima)\n", target, diff, discount))
+			} else if basisPrice.IsPositive() {
 				// Produto está em promoção! Meta atingida
-				response.WriteString(fmt.Sprintf("🎯 Preço alvo: R$ %.2f ✅ <b>META ATINGIDA!</b>\n", p.TargetPrice))
+				response.WriteString(fmt.Sprintf("🎯 Preço alvo: %s ✅ <b>META ATINGIDA!</b>\n", target))
 			} else {
-				response.WriteString(fmt.Sprintf("🎯 Preço alvo: R$ %.2f\n", p.TargetPrice))
+				response.WriteString(fmt.Sprintf("🎯 Preço alvo: %s\n", target))
 			}
 		}
 
-		if p.TargetDiscount > 0 {
-			response.WriteString(fmt.Sprintf("🎯 Desconto alvo: %.1f%%\n", p.TargetDiscount))
+		if sub.TargetDiscount > 0 {
+			response.WriteString(fmt.Sprintf("🎯 Desconto alvo: %.1f%%\n", sub.TargetDiscount))
+		}
+		if target := sub.InstallmentsTarget(); target != "" {
+			if sub.InstallmentsMet(p.Installments) {
+				response.WriteString(fmt.Sprintf("🎯 Parcelamento alvo: %s ✅ <b>META ATINGIDA!</b>\n", target))
+			} else {
+				response.WriteString(fmt.Sprintf("🎯 Parcelamento alvo: %s\n", target))
+			}
+		}
+
+		if info := availabilityInfo(p.Availability, p.StockQuantity); info != "" {
+			response.WriteString(info + "\n")
+		}
+		if sub.NotifyInStock {
+			response.WriteString("🔔 Aviso de volta ao estoque ativado\n")
+		}
+		if sub.WithShipping {
+			response.WriteString("🚚 Alvo considera o frete\n")
+		}
+		if sub.SellerFilter.Active() {
+			response.WriteString(fmt.Sprintf("🏪 Vendedores: %s\n", sub.SellerFilter))
+		}
+
+		if sub.CheckInterval > 0 {
+			response.WriteString(fmt.Sprintf("⏱️ Verificado a cada %s\n", formatInterval(sub.CheckInterval)))
 		}
 
 		if !p.LastChecked.IsZero() {
@@ -343,25 +658,25 @@ func handleRemoveProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *da
 		return
 	}
 
-	product, err := db.GetProductByID(id)
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
 	if err != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
 		bot.Send(msg)
 		return
 	}
 
-	err = db.DeactivateProduct(id)
+	err = db.RemoveSubscription(message.Chat.ID, id)
 	if err != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao remover produto: %v", err))
 		bot.Send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ Produto removido: %s", product.Name))
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ Produto removido: %s", sub.Product.Name))
 	bot.Send(msg)
 }
 
-func handleCheckProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry) {
+func handleCheckProduct(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry) {
 	parts := strings.Fields(message.Text)
 	if len(parts) < 2 {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /check <id>\n\nExemplo: /check 1")
@@ -376,12 +691,13 @@ func handleCheckProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *dat
 		return
 	}
 
-	product, err := db.GetProductByID(id)
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
 	if err != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
 		bot.Send(msg)
 		return
 	}
+	product := &sub.Product
 
 	// Enviar mensagem de "verificando"
 	waitMsg := tgbotapi.NewMessage(message.Chat.ID, "⏳ Verificando preço...")
@@ -392,7 +708,7 @@ func handleCheckProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *dat
 	}
 
 	// Verificar produto (isso atualiza o preço no banco)
-	newPrice, err := monitor.CheckProduct(*product)
+	snapshot, err := monitor.CheckProduct(ctx, *product)
 	if err != nil {
 		errorText := fmt.Sprintf("❌ Erro ao verificar preço: %v", err)
 		if sentMessageID != 0 {
@@ -420,47 +736,80 @@ func handleCheckProduct(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *dat
 	}
 	
 	// Usar o preço retornado se o banco ainda não foi atualizado
-	if updatedProduct.CurrentPrice == 0 && newPrice > 0 {
-		updatedProduct.CurrentPrice = newPrice
+	if updatedProduct.CurrentPrice.IsZero() && snapshot.CurrentPrice.IsPositive() {
+		updatedProduct.CurrentPrice = snapshot.CurrentPrice
 	}
 
 	// Montar resposta (usar HTML)
 	productName := escapeHTML(updatedProduct.Name)
 	response := fmt.Sprintf(
 		"📊 <b>Produto: %s</b>\n\n"+
-			"Preço atual: R$ %.2f\n"+
-			"Preço anterior: R$ %.2f\n"+
+			"Preço atual: %s\n"+
+			"Preço anterior: %s\n"+
 			"Link: %s",
 		productName,
 		updatedProduct.CurrentPrice,
 		product.CurrentPrice,
 		updatedProduct.URL,
 	)
+	if info := availabilityInfo(string(snapshot.Availability), snapshot.StockQuantity); info != "" {
+		response += "\n" + info
+	}
+	if snapshot.Variant.Label != "" {
+		response += fmt.Sprintf("\n🔀 Variante: %s", escapeHTML(snapshot.Variant.Label))
+	}
+	if snapshot.Seller.Known() {
+		response += fmt.Sprintf("\n🏪 Vendedor: %s", escapeHTML(snapshot.Seller.String()))
+	}
+	if info := offersInfo(snapshot.Offers, sub.SellerFilter); info != "" {
+		response += "\n" + escapeHTML(info)
+	}
+
+	// Cotar o frete para o CEP do chat; sem CEP, vale o frete exibido na página
+	shipping := snapshot.Shipping
+	if cep, err := db.GetChatCEP(message.Chat.ID); err != nil {
+		log.Printf("Erro ao buscar CEP do chat %d: %v", message.Chat.ID, err)
+	} else if cep != "" {
+		shipping = monitor.QuoteShipping(ctx, *updatedProduct, cep, snapshot.Shipping)
+	}
+	if info := shippingInfo(shipping, updatedProduct.CurrentPrice); info != "" {
+		response += "\n" + info
+	}
+	if info := paymentInfo(snapshot.CashPrice, snapshot.CardPrice, snapshot.Installments); info != "" {
+		response += "\n\n" + info
+	}
+	if info := promotionInfo(snapshot.Coupons, snapshot.Deal, updatedProduct.CurrentPrice); info != "" {
+		response += "\n\n" + escapeHTML(info)
+	}
 
 	// Mostrar desconto do banco se disponível
 	if updatedProduct.Discount > 0 {
-		if updatedProduct.OriginalPrice > 0 {
-			response += fmt.Sprintf("\n\n🎉 <b>%.1f%% OFF</b> (de R$ %.2f)", updatedProduct.Discount, updatedProduct.OriginalPrice)
+		if updatedProduct.OriginalPrice.IsPositive() {
+			response += fmt.Sprintf("\n\n🎉 <b>%.1f%% OFF</b> (de %s)", updatedProduct.Discount, updatedProduct.OriginalPrice)
 		} else {
 			response += fmt.Sprintf("\n\n🎉 <b>%.1f%% OFF</b>", updatedProduct.Discount)
 		}
-	} else if updatedProduct.OriginalPrice > 0 && updatedProduct.OriginalPrice > updatedProduct.CurrentPrice {
+	} else if updatedProduct.OriginalPrice.IsPositive() && updatedProduct.CurrentPrice.Less(updatedProduct.OriginalPrice) {
 		// Calcular desconto se não tem no banco mas tem preço original
-		discount := ((updatedProduct.OriginalPrice - updatedProduct.CurrentPrice) / updatedProduct.OriginalPrice) * 100
-		response += fmt.Sprintf("\n\n🎉 <b>%.1f%% OFF</b> (de R$ %.2f)", discount, updatedProduct.OriginalPrice)
-	} else if updatedProduct.CurrentPrice < product.CurrentPrice && product.CurrentPrice > 0 {
+		discount := updatedProduct.CurrentPrice.DiscountFrom(updatedProduct.OriginalPrice)
+		response += fmt.Sprintf("\n\n🎉 <b>%.1f%% OFF</b> (de %s)", discount, updatedProduct.OriginalPrice)
+	} else if updatedProduct.CurrentPrice.Less(product.CurrentPrice) && product.CurrentPrice.IsPositive() {
 		// Se não tem preço original mas o preço diminuiu
-		discount := ((product.CurrentPrice - updatedProduct.CurrentPrice) / product.CurrentPrice) * 100
+		discount := updatedProduct.CurrentPrice.DiscountFrom(product.CurrentPrice)
 		response += fmt.Sprintf("\n\n🎉 Desconto de %.1f%%!", discount)
 	}
 	
 	// Mostrar desconto em relação ao preço alvo se estiver em promoção
-	if updatedProduct.TargetPrice > 0 && updatedProduct.CurrentPrice > 0 {
-		if updatedProduct.CurrentPrice <= updatedProduct.TargetPrice {
-			discount := ((updatedProduct.TargetPrice - updatedProduct.CurrentPrice) / updatedProduct.TargetPrice) * 100
+	if sub.TargetPrice.IsPositive() && updatedProduct.CurrentPrice.IsPositive() {
+		effectivePrice := sub.ComparedPrice(updatedProduct.PriceFor(sub.PriceBasis), updatedProduct.Coupon, shipping)
+		if !sub.TargetPrice.Less(effectivePrice) {
+			discount := effectivePrice.DiscountFrom(sub.TargetPrice)
 			response += fmt.Sprintf("\n\n✅ Produto está abaixo do preço alvo! %.1f%% OFF", discount)
 		}
 	}
+	if sub.InstallmentsMet(snapshot.Installments) {
+		response += fmt.Sprintf("\n\n✅ Parcelamento alvo atingido: %s", snapshot.Installments)
+	}
 
 	// Tentar editar a mensagem de "verificando" se foi enviada
 	if sentMessageID != 0 {
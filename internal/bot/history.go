@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultHistoryDays = 30
+	maxHistoryChanges  = 10
+)
+
+// historySummary resume as observações bem-sucedidas de um período
+type historySummary struct {
+	Count   int
+	Min     models.PriceHistory
+	Max     models.PriceHistory
+	Average money.Money
+	Changes []models.PriceHistory // Observações em que o preço mudou em relação à anterior
+}
+
+// summarizeHistory calcula mínimo, máximo, média e mudanças de preço ignorando verificações com erro
+func summarizeHistory(history []models.PriceHistory) historySummary {
+	var summary historySummary
+	var total int64
+	var last int64
+
+	for _, h := range history {
+		if h.Status != models.ScrapeStatusOK || !h.CurrentPrice.IsPositive() {
+			continue
+		}
+		if summary.Count == 0 || h.CurrentPrice.Less(summary.Min.CurrentPrice) {
+			summary.Min = h
+		}
+		if summary.Count == 0 || summary.Max.CurrentPrice.Less(h.CurrentPrice) {
+			summary.Max = h
+		}
+		if summary.Count == 0 || h.CurrentPrice.Cents != last {
+			summary.Changes = append(summary.Changes, h)
+		}
+		last = h.CurrentPrice.Cents
+		total += h.CurrentPrice.Cents
+		summary.Count++
+	}
+
+	if summary.Count > 0 {
+		// Média arredondada para o centavo mais próximo
+		average := (total + int64(summary.Count)/2) / int64(summary.Count)
+		summary.Average = money.New(average, summary.Min.CurrentPrice.Currency)
+	}
+	return summary
+}
+
+func handleHistory(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /history <id> [dias]\n\nExemplo: /history 1 30")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	days := defaultHistoryDays
+	if len(parts) >= 3 {
+		days, err = strconv.Atoi(parts[2])
+		if err != nil || days <= 0 {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Número de dias inválido. Use um valor inteiro positivo.")
+			bot.Send(msg)
+			return
+		}
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	history, err := db.GetPriceHistory(id, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao buscar histórico: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	summary := summarizeHistory(history)
+	if summary.Count == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("📉 Nenhum preço registrado para este produto nos últimos %d dias.", days))
+		bot.Send(msg)
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📉 <b>Histórico: %s</b>\n", escapeHTML(sub.Product.Name)))
+	response.WriteString(fmt.Sprintf("Últimos %d dias (%d verificações)\n\n", days, summary.Count))
+	response.WriteString(fmt.Sprintf("⬇️ Mínimo: <b>%s</b> (%s)\n", summary.Min.CurrentPrice, summary.Min.CheckedAt.Local().Format("02/01/2006")))
+	response.WriteString(fmt.Sprintf("⬆️ Máximo: %s (%s)\n", summary.Max.CurrentPrice, summary.Max.CheckedAt.Local().Format("02/01/2006")))
+	response.WriteString(fmt.Sprintf("➗ Média: %s\n", summary.Average))
+	if sub.Product.CurrentPrice.IsPositive() {
+		response.WriteString(fmt.Sprintf("💰 Atual: %s\n", sub.Product.CurrentPrice))
+	}
+
+	// Mostrar as mudanças mais recentes primeiro
+	changes := summary.Changes
+	if len(changes) > maxHistoryChanges {
+		changes = changes[len(changes)-maxHistoryChanges:]
+	}
+	response.WriteString("\n<b>Mudanças recentes:</b>\n")
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		indicator := "•"
+		if i > 0 {
+			if change.CurrentPrice.Less(changes[i-1].CurrentPrice) {
+				indicator = "🔻"
+			} else {
+				indicator = "🔺"
+			}
+		}
+		response.WriteString(fmt.Sprintf("%s %s - %s\n", indicator, change.CheckedAt.Local().Format("02/01/2006 15:04"), change.CurrentPrice))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, response.String())
+	msg.ParseMode = "HTML"
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("Erro ao enviar histórico com HTML: %v", err)
+		msg.ParseMode = ""
+		bot.Send(msg)
+	}
+}
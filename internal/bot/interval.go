@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/monitor"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	minCheckInterval = time.Minute
+	maxCheckInterval = 7 * 24 * time.Hour
+)
+
+// formatInterval formata uma duração sem as unidades zeradas (ex: "6h", "1h30m", "5m")
+func formatInterval(d time.Duration) string {
+	text := d.Round(time.Second).String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+func handleInterval(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB, monitor *monitor.Monitor) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 3 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /interval <id> <duração>\n\nExemplo: /interval 1 5m\nExemplo: /interval 1 6h\nUse /interval <id> padrao para voltar ao intervalo global.")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	var interval time.Duration
+	switch strings.ToLower(parts[2]) {
+	case "padrao", "padrão", "default":
+		interval = 0
+	default:
+		interval, err = time.ParseDuration(strings.ToLower(parts[2]))
+		if err != nil || interval < minCheckInterval || interval > maxCheckInterval {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Intervalo inválido. Use uma duração entre %s e %s (ex: 5m, 1h30m, 6h).", formatInterval(minCheckInterval), formatInterval(maxCheckInterval)))
+			bot.Send(msg)
+			return
+		}
+	}
+
+	if err := db.SetSubscriptionInterval(message.Chat.ID, id, interval); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar intervalo: %v", err))
+		bot.Send(msg)
+		return
+	}
+	monitor.Wake()
+
+	text := fmt.Sprintf("✅ %s será verificado a cada %s.", sub.Product.Name, formatInterval(interval))
+	if interval == 0 {
+		text = fmt.Sprintf("✅ %s voltou a usar o intervalo global.", sub.Product.Name)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
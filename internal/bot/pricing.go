@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// parsePriceBasis interpreta a base de preço do alvo (ex: /add <url> 3000 pix ou /basis 1 cartao)
+func parsePriceBasis(arg string) (models.PriceBasis, bool) {
+	switch strings.ToLower(arg) {
+	case "menor", "lowest":
+		return models.PriceBasisLowest, true
+	case "pix", "avista", "boleto", "cash":
+		return models.PriceBasisCash, true
+	case "cartao", "cartão", "card":
+		return models.PriceBasisCard, true
+	case "parcela", "installment":
+		return models.PriceBasisInstallment, true
+	}
+	return "", false
+}
+
+// parseInstallmentsTarget interpreta o alvo de parcelamento "12x" (12 parcelas sem juros ou mais)
+func parseInstallmentsTarget(arg string) (int, bool) {
+	countStr, ok := strings.CutSuffix(strings.ToLower(arg), "x")
+	if !ok {
+		return 0, false
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 2 || count > 48 {
+		return 0, false
+	}
+	return count, true
+}
+
+// paymentInfo descreve os preços à vista e no cartão e o parcelamento (vazio se a loja não diferenciar)
+func paymentInfo(cash, card money.Money, installments models.Installments) string {
+	var lines []string
+	if cash.IsPositive() && card.IsPositive() && cash != card {
+		lines = append(lines, fmt.Sprintf("💵 À vista (Pix/boleto): %s", cash))
+		lines = append(lines, fmt.Sprintf("💳 No cartão: %s", card))
+	}
+	if installments.Known() {
+		lines = append(lines, fmt.Sprintf("💳 Parcelado: %s (total %s)", installments, installments.Total()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handlePriceBasis(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 3 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /basis <id> <menor|pix|cartao|parcela>\n\nExemplo: /basis 1 pix (alvo comparado com o preço à vista)\nExemplo: /basis 1 parcela (alvo comparado com o valor da parcela)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	basis, ok := parsePriceBasis(parts[2])
+	if !ok {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Base de preço inválida. Use menor, pix, cartao ou parcela.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	if err := db.SetSubscriptionPriceBasis(message.Chat.ID, id, basis); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar a base de preço: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ O alvo de %s passa a ser comparado com o %s.", sub.Product.Name, basis.Label())
+	if basis != models.PriceBasisLowest {
+		text = fmt.Sprintf("✅ O alvo de %s passa a ser comparado com o preço %s.", sub.Product.Name, basis.Label())
+		if price := sub.Product.PriceFor(basis); price.IsPositive() {
+			text += fmt.Sprintf("\nPreço %s na última verificação: %s", basis.Label(), price)
+		}
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
+
+func handleInstallments(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 3 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /installments <id> <parcelas> [valor_máximo] OU /installments <id> off\n\nExemplo: /installments 1 12 (avisar quando houver 12x sem juros ou mais)\nExemplo: /installments 1 12x 200 (12x sem juros com parcela de até R$ 200,00)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	var count int
+	var maxValue money.Money
+	if strings.ToLower(parts[2]) != "off" {
+		// Aceita "12" e "12x"
+		arg := strings.ToLower(parts[2])
+		if !strings.HasSuffix(arg, "x") {
+			arg += "x"
+		}
+		var ok bool
+		if count, ok = parseInstallmentsTarget(arg); !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Número de parcelas inválido. Use um valor entre 2 e 48.")
+			bot.Send(msg)
+			return
+		}
+		if len(parts) >= 4 {
+			maxValue, err = money.Parse(parts[3], money.PtBR, money.DefaultCurrency)
+			if err != nil || !maxValue.IsPositive() {
+				msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Valor máximo da parcela inválido. Use um valor positivo, como 199,90.")
+				bot.Send(msg)
+				return
+			}
+		}
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	if err := db.SetSubscriptionInstallments(message.Chat.ID, id, count, maxValue); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar o parcelamento alvo: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Parcelamento alvo removido de %s.", sub.Product.Name)
+	if count > 0 {
+		sub.TargetInstallments = count
+		sub.MaxInstallmentValue = maxValue
+		text = fmt.Sprintf("✅ Você será avisado quando %s puder ser pago em %s.", sub.Product.Name, sub.InstallmentsTarget())
+		if sub.InstallmentsMet(sub.Product.Installments) {
+			text += fmt.Sprintf("\nO parcelamento atual (%s) já atende o alvo; o aviso vale para quando ele deixar de atender e voltar.", sub.Product.Installments)
+		}
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
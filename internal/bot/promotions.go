@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isCouponKeyword indica se o argumento pede que o alvo considere o preço com cupom (ex: /add <url> 3000 cupom)
+func isCouponKeyword(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "cupom", "coupon":
+		return true
+	}
+	return false
+}
+
+// promotionInfo descreve os cupons e o selo promocional para as mensagens do bot (vazio se não houver)
+func promotionInfo(coupons []models.Coupon, deal models.Deal, price money.Money) string {
+	var lines []string
+	if deal.Active() {
+		lines = append(lines, fmt.Sprintf("⚡ %s", deal))
+	}
+	for _, coupon := range coupons {
+		if price.IsPositive() {
+			lines = append(lines, fmt.Sprintf("🎟️ Cupom %s (preço com cupom: %s)", coupon, coupon.Apply(price)))
+		} else {
+			lines = append(lines, fmt.Sprintf("🎟️ Cupom %s", coupon))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// productCoupons retorna o cupom salvo do produto como lista, para uso em promotionInfo
+func productCoupons(p models.Product) []models.Coupon {
+	if !p.Coupon.Known() {
+		return nil
+	}
+	return []models.Coupon{p.Coupon}
+}
+
+func handleCoupon(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /coupon <id> [off]\n\nExemplo: /coupon 1 (comparar o alvo com o preço depois do cupom)\nExemplo: /coupon 1 off (ignorar cupons)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	enabled := true
+	if len(parts) >= 3 {
+		switch strings.ToLower(parts[2]) {
+		case "off", "desligar", "nao", "não":
+			enabled = false
+		case "on", "ligar", "sim":
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Opção inválida. Use /coupon <id> para ativar ou /coupon <id> off para desativar.")
+			bot.Send(msg)
+			return
+		}
+	}
+
+	if err := db.SetSubscriptionCoupon(message.Chat.ID, id, enabled); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar o cupom do alvo: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ O alvo de %s volta a ignorar cupons.", sub.Product.Name)
+	if enabled {
+		text = fmt.Sprintf("✅ O alvo de %s passa a considerar o preço depois do melhor cupom ativo.", sub.Product.Name)
+		if sub.Product.Coupon.Known() && sub.Product.CurrentPrice.IsPositive() {
+			text += fmt.Sprintf("\nCupom atual: %s (preço com cupom: %s)", sub.Product.Coupon, sub.Product.Coupon.Apply(sub.Product.CurrentPrice))
+		}
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+
+	"bot-produtos/internal/scraper"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleReloadScrapers relê o arquivo de scrapers configuráveis sem reiniciar o bot
+// Restrito ao chat administrador (TELEGRAM_CHAT_ID)
+func handleReloadScrapers(bot *tgbotapi.BotAPI, chatID int64, registry *scraper.Registry) {
+	adminChatID, ok := GetAdminChatID()
+	if !ok || chatID != adminChatID {
+		msg := tgbotapi.NewMessage(chatID, "❌ Apenas o administrador pode recarregar os scrapers.")
+		bot.Send(msg)
+		return
+	}
+
+	count, err := registry.Reload()
+	if err != nil {
+		log.Printf("Erro ao recarregar scrapers: %v", err)
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Erro ao recarregar scrapers: %v\n\nAs lojas carregadas anteriormente continuam ativas.", err))
+		bot.Send(msg)
+		return
+	}
+
+	log.Printf("Scrapers recarregados: %d loja(s)", count)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Scrapers recarregados: %d loja(s) configurada(s).", count))
+	bot.Send(msg)
+}
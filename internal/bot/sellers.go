@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isOfficialStoreKeyword indica se o argumento pede apenas ofertas de lojas oficiais (ex: /add <url> 3000 oficial)
+func isOfficialStoreKeyword(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "oficial", "official", "lojaoficial":
+		return true
+	}
+	return false
+}
+
+// parseSellerFilter lê o filtro de vendedor do /seller (ex: "oficial", "verde", "oficial 4")
+func parseSellerFilter(args []string) (models.SellerFilter, error) {
+	var filter models.SellerFilter
+	for _, arg := range args {
+		if isOfficialStoreKeyword(arg) {
+			filter.OfficialStoreOnly = true
+			continue
+		}
+		reputation, ok := models.ParseReputation(arg)
+		if !ok {
+			return filter, fmt.Errorf("opção inválida: %s", arg)
+		}
+		filter.MinReputation = reputation
+	}
+	return filter, nil
+}
+
+// offersInfo descreve as ofertas dos vendedores para as mensagens do bot (vazio se a página não tiver várias ofertas)
+// Com o filtro ativo, mostra também a oferta mais barata entre os vendedores aceitos
+func offersInfo(offers []models.Offer, filter models.SellerFilter) string {
+	if len(offers) < 2 {
+		return ""
+	}
+	var lines []string
+	if best, ok := models.BestOffer(offers, models.SellerFilter{}); ok {
+		lines = append(lines, fmt.Sprintf("🏷️ %d ofertas de vendedores; a mais barata: %s (%s)", len(offers), best.Price, best.Seller))
+	}
+	if filter.Active() {
+		if best, ok := models.BestOffer(offers, filter); ok {
+			lines = append(lines, fmt.Sprintf("🏪 Melhor oferta com o seu filtro (%s): %s (%s)", filter, best.Price, best.Seller))
+		} else {
+			lines = append(lines, fmt.Sprintf("🏪 Nenhuma oferta atende ao seu filtro (%s)", filter))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handleSellerFilter(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /seller <id> [oficial] [reputação] OU /seller <id> off\nReputação: 1 a 5 ou vermelha, laranja, amarela, verde-clara, verde\n\nExemplo: /seller 1 oficial (apenas lojas oficiais)\nExemplo: /seller 1 verde (apenas vendedores com reputação verde)\nExemplo: /seller 1 off (todos os vendedores)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	offers, err := db.GetProductOffers(id)
+	if err != nil {
+		log.Printf("Erro ao buscar ofertas do produto %d: %v", id, err)
+	}
+
+	// Sem opções, mostrar o filtro atual e as ofertas da última verificação
+	if len(parts) == 2 {
+		text := fmt.Sprintf("🏪 %s acompanha a oferta mais barata entre todos os vendedores.", sub.Product.Name)
+		if sub.SellerFilter.Active() {
+			text = fmt.Sprintf("🏪 %s acompanha a oferta mais barata entre os vendedores com: %s.", sub.Product.Name, sub.SellerFilter)
+		}
+		if info := offersInfo(offers, sub.SellerFilter); info != "" {
+			text += "\n\n" + info
+		} else {
+			text += "\n\nA página do produto não tem ofertas de vários vendedores."
+		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		bot.Send(msg)
+		return
+	}
+
+	var filter models.SellerFilter
+	switch strings.ToLower(parts[2]) {
+	case "off", "todos", "desligar":
+	default:
+		filter, err = parseSellerFilter(parts[2:])
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ %s. Use oficial, uma reputação de 1 a 5 (ou vermelha, laranja, amarela, verde-clara, verde) ou off.", err))
+			bot.Send(msg)
+			return
+		}
+	}
+
+	if err := db.SetSubscriptionSellerFilter(message.Chat.ID, id, filter); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar o filtro de vendedor: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Os alvos de %s voltam a considerar todos os vendedores.", sub.Product.Name)
+	if filter.Active() {
+		text = fmt.Sprintf("✅ Os alvos de %s passam a considerar apenas ofertas de vendedores com: %s.", sub.Product.Name, filter)
+	}
+	if info := offersInfo(offers, filter); info != "" {
+		text += "\n\n" + info
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isShippingKeyword indica se o argumento pede que o alvo considere o frete (ex: /add <url> 3000 frete)
+func isShippingKeyword(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "frete", "shipping":
+		return true
+	}
+	return false
+}
+
+// normalizeCEP aceita "01001-000" ou "01001000" e retorna apenas os 8 dígitos
+func normalizeCEP(text string) (string, error) {
+	cep := strings.NewReplacer("-", "", ".", "", " ", "").Replace(text)
+	if len(cep) != 8 {
+		return "", fmt.Errorf("CEP deve ter 8 dígitos")
+	}
+	for _, r := range cep {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("CEP deve conter apenas números")
+		}
+	}
+	return cep, nil
+}
+
+// shippingInfo descreve o frete e o total com frete para as mensagens do bot (vazio se desconhecido)
+func shippingInfo(shipping models.Shipping, price money.Money) string {
+	if !shipping.Known() {
+		return ""
+	}
+
+	destination := ""
+	if shipping.CEP != "" {
+		destination = " para " + models.FormatCEP(shipping.CEP)
+	}
+	if shipping.Free {
+		return fmt.Sprintf("🚚 Frete grátis%s", destination)
+	}
+
+	info := fmt.Sprintf("🚚 Frete%s: %s", destination, shipping.Cost)
+	if price.IsPositive() {
+		info += fmt.Sprintf("\n💵 Total com frete: %s", shipping.Total(price))
+	}
+	return info
+}
+
+func handleCEP(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		cep, err := db.GetChatCEP(message.Chat.ID)
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao buscar CEP: %v", err))
+			bot.Send(msg)
+			return
+		}
+		text := "📍 Nenhum CEP configurado.\n\nUso: /cep <cep>\n\nExemplo: /cep 01001-000"
+		if cep != "" {
+			text = fmt.Sprintf("📍 CEP configurado: %s\n\nUse /cep off para removê-lo.", models.FormatCEP(cep))
+		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		bot.Send(msg)
+		return
+	}
+
+	cep := ""
+	if strings.ToLower(parts[1]) != "off" {
+		normalized, err := normalizeCEP(strings.Join(parts[1:], ""))
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ CEP inválido: %v\n\nExemplo: /cep 01001-000", err))
+			bot.Send(msg)
+			return
+		}
+		cep = normalized
+	}
+
+	if err := db.SetChatCEP(message.Chat.ID, cep); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao salvar CEP: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := "✅ CEP removido. O frete passa a ser o exibido na página do produto."
+	if cep != "" {
+		text = fmt.Sprintf("✅ CEP %s configurado. O frete será cotado para este CEP quando a loja permitir.", models.FormatCEP(cep))
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
+
+func handleShipping(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /shipping <id> [off]\n\nExemplo: /shipping 1 (comparar o alvo com o preço mais o frete)\nExemplo: /shipping 1 off (comparar só o preço)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	enabled := true
+	if len(parts) >= 3 {
+		switch strings.ToLower(parts[2]) {
+		case "off", "desligar", "nao", "não":
+			enabled = false
+		case "on", "ligar", "sim":
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Opção inválida. Use /shipping <id> para ativar ou /shipping <id> off para desativar.")
+			bot.Send(msg)
+			return
+		}
+	}
+
+	if err := db.SetSubscriptionShipping(message.Chat.ID, id, enabled); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar o frete do alvo: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ O alvo de %s volta a considerar só o preço do produto.", sub.Product.Name)
+	if enabled {
+		text = fmt.Sprintf("✅ O alvo de %s passa a considerar o preço mais o frete.", sub.Product.Name)
+		if cep, err := db.GetChatCEP(message.Chat.ID); err == nil && cep == "" {
+			text += "\nConfigure seu CEP com /cep para cotar o frete até você; sem CEP, vale o frete exibido na página."
+		}
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/scraper"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isStockKeyword indica se o argumento pede o aviso de volta ao estoque (ex: /add <url> stock)
+func isStockKeyword(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "stock", "estoque":
+		return true
+	}
+	return false
+}
+
+// availabilityInfo descreve a disponibilidade para as mensagens do bot (vazio se desconhecida)
+func availabilityInfo(availability string, quantity int) string {
+	switch scraper.Availability(availability) {
+	case scraper.AvailabilityOutOfStock:
+		return "📦 Esgotado no momento"
+	case scraper.AvailabilityLimited:
+		if quantity > 0 {
+			return fmt.Sprintf("📦 Últimas unidades (%d disponíveis)", quantity)
+		}
+		return "📦 Últimas unidades"
+	case scraper.AvailabilityInStock:
+		if quantity > 0 {
+			return fmt.Sprintf("📦 Em estoque (%d disponíveis)", quantity)
+		}
+		return "📦 Em estoque"
+	}
+	return ""
+}
+
+func handleStockAlert(bot *tgbotapi.BotAPI, message *tgbotapi.Message, db *database.DB) {
+	parts := strings.Fields(message.Text)
+	if len(parts) < 2 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Formato incorreto.\n\nUso: /stock <id> [off]\n\nExemplo: /stock 1 (avisar quando voltar ao estoque)\nExemplo: /stock 1 off (desativar o aviso)")
+		bot.Send(msg)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ ID inválido.")
+		bot.Send(msg)
+		return
+	}
+
+	sub, err := getOwnSubscription(db, message.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Produto não encontrado.")
+		bot.Send(msg)
+		return
+	}
+
+	enabled := true
+	if len(parts) >= 3 {
+		switch strings.ToLower(parts[2]) {
+		case "off", "desligar", "nao", "não":
+			enabled = false
+		case "on", "ligar", "sim":
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Opção inválida. Use /stock <id> para ativar ou /stock <id> off para desativar.")
+			bot.Send(msg)
+			return
+		}
+	}
+
+	if err := db.SetSubscriptionStockAlert(message.Chat.ID, id, enabled); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Erro ao alterar aviso de estoque: %v", err))
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Aviso de volta ao estoque desativado para %s.", sub.Product.Name)
+	if enabled {
+		text = fmt.Sprintf("✅ Você será avisado quando %s voltar ao estoque.", sub.Product.Name)
+		if scraper.Availability(sub.Product.Availability).Purchasable() {
+			text += "\nO produto está disponível agora; o aviso vale para quando ele esgotar e voltar."
+		}
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/monitor"
+	"bot-produtos/internal/scraper"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// variantPromptTTL é por quanto tempo um /add aguarda a escolha da variante
+const variantPromptTTL = time.Hour
+
+// maxVariantButtons limita o número de variantes oferecidas no teclado
+const maxVariantButtons = 30
+
+// variantCallbackPrefix identifica os callbacks do teclado de variantes (variant:<token>:<índice>)
+const variantCallbackPrefix = "variant:"
+
+// variantPrompt é um /add aguardando a escolha da variante no teclado inline
+type variantPrompt struct {
+	chatID   int64
+	args     []string // Argumentos do /add depois da URL (alvo e opções)
+	variants []models.Variant
+	created  time.Time
+}
+
+// variantPrompts guarda os /add pendentes, indexados pelo token enviado nos botões
+type variantPrompts struct {
+	mu      sync.Mutex
+	next    int64
+	prompts map[string]variantPrompt
+}
+
+func newVariantPrompts() *variantPrompts {
+	return &variantPrompts{prompts: make(map[string]variantPrompt)}
+}
+
+// add guarda o /add pendente e retorna o token usado nos botões
+func (p *variantPrompts) add(prompt variantPrompt) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for token, pending := range p.prompts {
+		if time.Since(pending.created) > variantPromptTTL {
+			delete(p.prompts, token)
+		}
+	}
+	p.next++
+	token := strconv.FormatInt(p.next, 36)
+	p.prompts[token] = prompt
+	return token
+}
+
+// take remove e retorna o /add pendente do chat, se ainda não tiver expirado
+func (p *variantPrompts) take(token string, chatID int64) (variantPrompt, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prompt, ok := p.prompts[token]
+	if !ok || prompt.chatID != chatID {
+		return variantPrompt{}, false
+	}
+	delete(p.prompts, token)
+	return prompt, time.Since(prompt.created) <= variantPromptTTL
+}
+
+// variantButtonLabel descreve a variante no botão (ex: "Voltagem: 220V - R$ 1.999,00 ✓")
+func variantButtonLabel(variant, current models.Variant) string {
+	label := variant.Label
+	if label == "" {
+		label = variant.ID
+	}
+	if variant.Price.IsPositive() {
+		label += " - " + variant.Price.String()
+	}
+	if variant.ID == current.ID {
+		label += " ✓"
+	}
+	return label
+}
+
+// askVariant envia o teclado com as variantes do produto e guarda o /add até a escolha
+func askVariant(bot *tgbotapi.BotAPI, chatID int64, name string, snapshot scraper.ProductSnapshot, args []string, prompts *variantPrompts) {
+	variants := snapshot.Variants
+	if len(variants) > maxVariantButtons {
+		variants = variants[:maxVariantButtons]
+	}
+	token := prompts.add(variantPrompt{chatID: chatID, args: args, variants: variants, created: time.Now()})
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, variant := range variants {
+		data := fmt.Sprintf("%s%s:%d", variantCallbackPrefix, token, i)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(variantButtonLabel(variant, snapshot.Variant), data)))
+	}
+
+	text := fmt.Sprintf("🔀 %s tem variantes, e o preço pode mudar entre elas. Qual delas você quer monitorar?", name)
+	if snapshot.Variant.Label != "" {
+		text += fmt.Sprintf("\n\nA página abriu em: %s (✓)", snapshot.Variant.Label)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// handleVariantCallback conclui o /add com a variante escolhida no teclado
+func handleVariantCallback(ctx context.Context, bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, db *database.DB, monitor *monitor.Monitor, registry *scraper.Registry, prompts *variantPrompts) {
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+
+	token, indexStr, _ := strings.Cut(strings.TrimPrefix(query.Data, variantCallbackPrefix), ":")
+	index, err := strconv.Atoi(indexStr)
+	prompt, ok := prompts.take(token, chatID)
+	if err != nil || !ok || index < 0 || index >= len(prompt.variants) {
+		bot.Request(tgbotapi.NewCallback(query.ID, "Esta escolha expirou. Envie o /add novamente."))
+		return
+	}
+	variant := prompt.variants[index]
+	bot.Request(tgbotapi.NewCallback(query.ID, "Variante escolhida"))
+
+	// Trocar o teclado pela escolha, para que a mesma mensagem não seja usada de novo
+	edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, fmt.Sprintf("🔀 Variante escolhida: %s", variantButtonLabel(variant, models.Variant{})))
+	bot.Send(edit)
+
+	// Repetir o /add com a URL da variante, sem perguntar de novo
+	message := &tgbotapi.Message{
+		Chat: query.Message.Chat,
+		Text: strings.Join(append([]string{"/add", variant.URL}, prompt.args...), " "),
+	}
+	handleAddProduct(ctx, bot, message, db, monitor, registry, prompts, false)
+}
@@ -0,0 +1,288 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 40
+	gridLines    = 5
+	timeTicks    = 4
+)
+
+var (
+	colorBackground = color.RGBA{255, 255, 255, 255}
+	colorAxis       = color.RGBA{90, 90, 90, 255}
+	colorGrid       = color.RGBA{225, 225, 225, 255}
+	colorText       = color.RGBA{40, 40, 40, 255}
+	colorPrice      = color.RGBA{52, 131, 250, 255}
+	colorTarget     = color.RGBA{0, 166, 80, 255}
+	colorLow        = color.RGBA{220, 53, 69, 255}
+)
+
+// Point representa um preço observado em um instante
+type Point struct {
+	Time  time.Time
+	Price float64
+}
+
+// Options configura a renderização do gráfico
+type Options struct {
+	Title       string
+	Width       int     // Largura em pixels (padrão: 800)
+	Height      int     // Altura em pixels (padrão: 400)
+	TargetPrice float64 // Preço alvo desenhado como linha tracejada (0 para omitir)
+	AllTimeLow  *Point  // Menor preço já registrado (nil para omitir)
+}
+
+// RenderPNG desenha um gráfico de linha dos preços e retorna a imagem codificada em PNG
+func RenderPNG(points []Point, opts Options) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("nenhum ponto para desenhar")
+	}
+	if opts.Width <= 0 {
+		opts.Width = 800
+	}
+	if opts.Height <= 0 {
+		opts.Height = 400
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, opts.Width-marginRight, opts.Height-marginBottom)
+	minTime, maxTime, minPrice, maxPrice := bounds(points, opts)
+
+	toX := func(t time.Time) int {
+		ratio := float64(t.Sub(minTime)) / float64(maxTime.Sub(minTime))
+		return plot.Min.X + int(math.Round(ratio*float64(plot.Dx())))
+	}
+	toY := func(price float64) int {
+		ratio := (price - minPrice) / (maxPrice - minPrice)
+		return plot.Max.Y - int(math.Round(ratio*float64(plot.Dy())))
+	}
+
+	// Grade horizontal com rótulos de preço
+	for i := 0; i <= gridLines; i++ {
+		price := minPrice + (maxPrice-minPrice)*float64(i)/gridLines
+		y := toY(price)
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, colorGrid, 1, 0)
+		label := formatPrice(price)
+		drawText(img, plot.Min.X-8-textWidth(label), y+4, label, colorText)
+	}
+
+	// Rótulos de data no eixo X
+	for i := 0; i <= timeTicks; i++ {
+		t := minTime.Add(time.Duration(float64(maxTime.Sub(minTime)) * float64(i) / timeTicks))
+		x := toX(t)
+		drawLine(img, x, plot.Max.Y, x, plot.Max.Y+4, colorAxis, 1, 0)
+		label := t.Local().Format("02/01")
+		drawText(img, x-textWidth(label)/2, plot.Max.Y+18, label, colorText)
+	}
+
+	// Eixos
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, colorAxis, 1, 0)
+	drawLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, colorAxis, 1, 0)
+
+	// Linha do preço alvo
+	if opts.TargetPrice > 0 {
+		y := toY(opts.TargetPrice)
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, colorTarget, 1, 6)
+		label := "Alvo " + formatPrice(opts.TargetPrice)
+		drawText(img, plot.Max.X-textWidth(label)-4, y-4, label, colorTarget)
+	}
+
+	// Linha de preços
+	for i := 1; i < len(points); i++ {
+		drawLine(img, toX(points[i-1].Time), toY(points[i-1].Price), toX(points[i].Time), toY(points[i].Price), colorPrice, 2, 0)
+	}
+	if len(points) == 1 {
+		drawCircle(img, toX(points[0].Time), toY(points[0].Price), 3, colorPrice)
+	}
+
+	// Menor preço de todos os tempos
+	if opts.AllTimeLow != nil {
+		low := *opts.AllTimeLow
+		y := toY(low.Price)
+		label := fmt.Sprintf("Minimo %s (%s)", formatPrice(low.Price), low.Time.Local().Format("02/01/06"))
+		if low.Time.Before(minTime) || low.Time.After(maxTime) {
+			// Fora do período exibido: apenas a referência horizontal
+			drawLine(img, plot.Min.X, y, plot.Max.X, y, colorLow, 1, 3)
+			drawText(img, plot.Min.X+4, y+14, label, colorLow)
+		} else {
+			x := toX(low.Time)
+			drawCircle(img, x, y, 4, colorLow)
+			labelX := x + 8
+			if labelX+textWidth(label) > plot.Max.X {
+				labelX = x - 8 - textWidth(label)
+			}
+			drawText(img, labelX, y+14, label, colorLow)
+		}
+	}
+
+	if opts.Title != "" {
+		drawText(img, marginLeft, marginTop-16, opts.Title, colorText)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bounds calcula os limites dos eixos considerando os pontos, o alvo e o menor preço
+func bounds(points []Point, opts Options) (time.Time, time.Time, float64, float64) {
+	minTime, maxTime := points[0].Time, points[0].Time
+	minPrice, maxPrice := points[0].Price, points[0].Price
+	for _, p := range points {
+		if p.Time.Before(minTime) {
+			minTime = p.Time
+		}
+		if p.Time.After(maxTime) {
+			maxTime = p.Time
+		}
+		minPrice = math.Min(minPrice, p.Price)
+		maxPrice = math.Max(maxPrice, p.Price)
+	}
+	if opts.TargetPrice > 0 {
+		minPrice = math.Min(minPrice, opts.TargetPrice)
+		maxPrice = math.Max(maxPrice, opts.TargetPrice)
+	}
+	if opts.AllTimeLow != nil {
+		minPrice = math.Min(minPrice, opts.AllTimeLow.Price)
+	}
+
+	if !maxTime.After(minTime) {
+		minTime = minTime.Add(-time.Hour)
+		maxTime = maxTime.Add(time.Hour)
+	}
+
+	// Margem de 5% para a linha não encostar nas bordas
+	padding := (maxPrice - minPrice) * 0.05
+	if padding == 0 {
+		padding = math.Max(maxPrice*0.05, 1)
+	}
+	return minTime, maxTime, math.Max(minPrice-padding, 0), maxPrice + padding
+}
+
+// drawLine desenha uma linha com a espessura informada; dash > 0 desenha tracejado
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, thickness, dash int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+
+	for step := 0; ; step++ {
+		if dash == 0 || (step/dash)%2 == 0 {
+			for ox := 0; ox < thickness; ox++ {
+				for oy := 0; oy < thickness; oy++ {
+					img.Set(x0+ox-thickness/2, y0+oy-thickness/2, c)
+				}
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawCircle desenha um círculo preenchido
+func drawCircle(img *image.RGBA, cx, cy, r int, c color.Color) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.Set(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// drawText escreve um texto com a linha de base em (x, y)
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(foldASCII(text))
+}
+
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, foldASCII(text)).Round()
+}
+
+// asciiReplacer troca letras acentuadas e símbolos comuns pelos equivalentes em ASCII
+var asciiReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+	"º", "o", "ª", "a", "°", "o",
+	"\u00a0", " ", "\u202f", " ", "–", "-", "—", "-", "…", "...",
+	"“", "\"", "”", "\"", "‘", "'", "’", "'",
+)
+
+// foldASCII prepara o texto para a fonte basicfont.Face7x13, que só desenha caracteres ASCII:
+// acentos são removidos (ex: "Máquina" vira "Maquina") e os demais caracteres viram "?"
+func foldASCII(text string) string {
+	text = asciiReplacer.Replace(text)
+	return strings.Map(func(r rune) rune {
+		if r > '~' {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+func formatPrice(price float64) string {
+	return money.FromFloat(price, money.DefaultCurrency).String()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// initChatSettings cria a tabela com as preferências de cada chat
+func (db *DB) initChatSettings() error {
+	createChatSettingsSQL := `
+	CREATE TABLE IF NOT EXISTS chat_settings (
+		chat_id INTEGER PRIMARY KEY,
+		cep TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	_, err := db.conn.Exec(createChatSettingsSQL)
+	return err
+}
+
+// SetChatCEP define o CEP usado nas cotações de frete do chat (vazio remove o CEP)
+func (db *DB) SetChatCEP(chatID int64, cep string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO chat_settings (chat_id, cep) VALUES (?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET cep = excluded.cep, updated_at = CURRENT_TIMESTAMP`,
+		chatID, cep,
+	)
+	return err
+}
+
+// GetChatCEP retorna o CEP configurado pelo chat, ou vazio se não houver
+func (db *DB) GetChatCEP(chatID int64) (string, error) {
+	var cep sql.NullString
+	err := db.conn.QueryRow("SELECT cep FROM chat_settings WHERE chat_id = ?", chatID).Scan(&cep)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cep.String, nil
+}
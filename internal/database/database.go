@@ -2,9 +2,13 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -20,6 +24,10 @@ func New(dbPath string) (*DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Cada conexão com ":memory:" abre um banco vazio; com uma única conexão todas as consultas usam o mesmo banco
+	if dbPath == ":memory:" {
+		conn.SetMaxOpenConns(1)
+	}
 
 	db := &DB{conn: conn}
 
@@ -42,7 +50,7 @@ func (db *DB) init() error {
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS products (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		url TEXT NOT NULL UNIQUE,
+		url TEXT NOT NULL,
 		name TEXT,
 		current_price REAL,
 		original_price REAL,
@@ -58,27 +66,425 @@ func (db *DB) init() error {
 	if _, err := db.conn.Exec(createTableSQL); err != nil {
 		return err
 	}
+
+	createHistorySQL := `
+	CREATE TABLE IF NOT EXISTS price_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL REFERENCES products(id),
+		checked_at DATETIME NOT NULL,
+		current_price REAL,
+		original_price REAL,
+		discount REAL,
+		status TEXT NOT NULL,
+		error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history (product_id, checked_at);
+	`
+
+	if _, err := db.conn.Exec(createHistorySQL); err != nil {
+		return err
+	}
+
+	if err := db.initSubscriptions(); err != nil {
+		return err
+	}
+
+	if err := db.initWebhookDeliveries(); err != nil {
+		return err
+	}
+
+	if err := db.initChatSettings(); err != nil {
+		return err
+	}
+
+	if err := db.initProductOffers(); err != nil {
+		return err
+	}
 	
 	// Tentar adicionar colunas se não existirem (migração)
 	// SQLite não suporta IF NOT EXISTS em ALTER TABLE, então ignoramos o erro
 	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN original_price REAL")
 	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN discount REAL")
-	
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN check_interval_seconds INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN availability TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN stock_quantity INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE price_history ADD COLUMN availability TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE price_history ADD COLUMN stock_quantity INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN notify_in_stock BOOLEAN DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN with_shipping BOOLEAN DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN price_basis TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN target_installments INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN max_installment_value_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN cash_price_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN card_price_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN installment_count INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN installment_value_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN installment_interest_free BOOLEAN DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN coupon_amount_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN coupon_percent REAL DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN coupon_max_discount_cents INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN deal_badge TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN deal_ends_at DATETIME")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN with_coupon BOOLEAN DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN product_key TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN variant TEXT DEFAULT ''")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN min_seller_reputation INTEGER DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN official_store_only BOOLEAN DEFAULT 0")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN check_interval_seconds INTEGER DEFAULT 0")
+
+	if err := db.migrateSubscriptionIntervals(); err != nil {
+		return err
+	}
+
+	if err := db.migrateMoneyColumns(); err != nil {
+		return err
+	}
+
+	// Depois das outras migrações, para que a tabela recriada tenha todas as colunas
+	if err := db.dropProductURLUnique(); err != nil {
+		return fmt.Errorf("erro ao remover a unicidade da URL dos produtos: %v", err)
+	}
+
+	// A unicidade passa a ser pela chave canônica; produtos antigos ficam sem chave até MergeDuplicateProducts
+	_, err := db.conn.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_products_key ON products (product_key) WHERE product_key != ''")
+	return err
+}
+
+// productsTableColumns são as colunas da tabela products depois de todas as migrações
+// dropProductURLUnique recria a tabela com elas, então uma coluna nova também deve entrar aqui
+var productsTableColumns = []struct{ name, definition string }{
+	{"id", "INTEGER PRIMARY KEY AUTOINCREMENT"},
+	{"url", "TEXT NOT NULL"},
+	{"name", "TEXT"},
+	{"current_price", "REAL"},
+	{"original_price", "REAL"},
+	{"discount", "REAL"},
+	{"target_price", "REAL"},
+	{"target_discount", "REAL"},
+	{"last_checked", "DATETIME"},
+	{"active", "BOOLEAN DEFAULT 1"},
+	{"created_at", "DATETIME DEFAULT CURRENT_TIMESTAMP"},
+	{"check_interval_seconds", "INTEGER DEFAULT 0"},
+	{"availability", "TEXT DEFAULT ''"},
+	{"stock_quantity", "INTEGER DEFAULT 0"},
+	{"cash_price_cents", "INTEGER DEFAULT 0"},
+	{"card_price_cents", "INTEGER DEFAULT 0"},
+	{"installment_count", "INTEGER DEFAULT 0"},
+	{"installment_value_cents", "INTEGER DEFAULT 0"},
+	{"installment_interest_free", "BOOLEAN DEFAULT 0"},
+	{"coupon_amount_cents", "INTEGER DEFAULT 0"},
+	{"coupon_percent", "REAL DEFAULT 0"},
+	{"coupon_max_discount_cents", "INTEGER DEFAULT 0"},
+	{"deal_badge", "TEXT DEFAULT ''"},
+	{"deal_ends_at", "DATETIME"},
+	{"product_key", "TEXT DEFAULT ''"},
+	{"variant", "TEXT DEFAULT ''"},
+	{"current_price_cents", "INTEGER"},
+	{"original_price_cents", "INTEGER"},
+	{"currency", "TEXT DEFAULT 'BRL'"},
+}
+
+// dropProductURLUnique remove a restrição UNIQUE da coluna url em bancos antigos
+// O produto é identificado pela chave canônica, e variantes de um mesmo anúncio (ex: 127V e 220V) têm a mesma URL.
+// SQLite não remove restrições com ALTER TABLE, então a tabela é recriada com as colunas de productsTableColumns
+func (db *DB) dropProductURLUnique() error {
+	unique, err := db.productURLUnique()
+	if err != nil || !unique {
+		return err
+	}
+
+	definitions := make([]string, len(productsTableColumns))
+	names := make([]string, len(productsTableColumns))
+	for i, column := range productsTableColumns {
+		definitions[i] = column.name + " " + column.definition
+		names[i] = column.name
+	}
+	columns := strings.Join(names, ", ")
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Manter a sequência do AUTOINCREMENT para que IDs de produtos removidos não sejam reaproveitados
+	var sequence sql.NullInt64
+	if err := tx.QueryRow("SELECT seq FROM sqlite_sequence WHERE name = 'products'").Scan(&sequence); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	statements := []string{
+		"CREATE TABLE products_new (" + strings.Join(definitions, ", ") + ")",
+		"INSERT INTO products_new (" + columns + ") SELECT " + columns + " FROM products",
+		"DROP TABLE products",
+		"ALTER TABLE products_new RENAME TO products",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if sequence.Valid {
+		if _, err := tx.Exec("UPDATE sqlite_sequence SET seq = MAX(seq, ?) WHERE name = 'products'", sequence.Int64); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println("Restrição de URL única removida da tabela de produtos")
 	return nil
 }
 
-// AddProduct adiciona um novo produto ao banco de dados
-func (db *DB) AddProduct(url, name string, targetPrice, targetDiscount float64) error {
-	_, err := db.conn.Exec(
-		"INSERT INTO products (url, name, current_price, original_price, target_price, target_discount, active) VALUES (?, ?, 0, 0, ?, ?, 1)",
-		url, name, targetPrice, targetDiscount,
-	)
+// productURLUnique informa se a tabela products ainda tem um índice UNIQUE só na coluna url
+// A restrição UNIQUE declarada na coluna cria um índice automático, listado por PRAGMA index_list
+func (db *DB) productURLUnique() (bool, error) {
+	rows, err := db.conn.Query("PRAGMA index_list(products)")
+	if err != nil {
+		return false, err
+	}
+	var uniqueIndexes []string
+	for rows.Next() {
+		var (
+			seq, unique, partial int
+			name, origin         string
+		)
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return false, err
+		}
+		if unique == 1 {
+			uniqueIndexes = append(uniqueIndexes, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, index := range uniqueIndexes {
+		var columns []string
+		rows, err := db.conn.Query("SELECT name FROM pragma_index_info(?)", index)
+		if err != nil {
+			return false, err
+		}
+		for rows.Next() {
+			var column sql.NullString
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return false, err
+			}
+			columns = append(columns, column.String)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return false, err
+		}
+		if len(columns) == 1 && columns[0] == "url" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// migrateMoneyColumns cria as colunas de preço em centavos e as preenche a partir das antigas colunas REAL
+// As colunas REAL continuam na tabela, mas deixam de ser lidas e atualizadas
+func (db *DB) migrateMoneyColumns() error {
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN current_price_cents INTEGER")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN original_price_cents INTEGER")
+	_, _ = db.conn.Exec("ALTER TABLE products ADD COLUMN currency TEXT DEFAULT 'BRL'")
+	_, _ = db.conn.Exec("ALTER TABLE price_history ADD COLUMN current_price_cents INTEGER")
+	_, _ = db.conn.Exec("ALTER TABLE price_history ADD COLUMN original_price_cents INTEGER")
+	_, _ = db.conn.Exec("ALTER TABLE subscriptions ADD COLUMN target_price_cents INTEGER")
+
+	// Linhas novas sempre gravam os centavos; só linhas antigas têm NULL
+	backfillSQL := `
+	UPDATE products SET
+		current_price_cents = CAST(ROUND(COALESCE(current_price, 0) * 100) AS INTEGER),
+		original_price_cents = CAST(ROUND(COALESCE(original_price, 0) * 100) AS INTEGER)
+	WHERE current_price_cents IS NULL;
+	UPDATE price_history SET
+		current_price_cents = CAST(ROUND(COALESCE(current_price, 0) * 100) AS INTEGER),
+		original_price_cents = CAST(ROUND(COALESCE(original_price, 0) * 100) AS INTEGER)
+	WHERE current_price_cents IS NULL;
+	UPDATE subscriptions SET target_price_cents = CAST(ROUND(COALESCE(target_price, 0) * 100) AS INTEGER)
+	WHERE target_price_cents IS NULL;
+	`
+	_, err := db.conn.Exec(backfillSQL)
 	return err
 }
 
-// GetActiveProducts retorna todos os produtos ativos
-func (db *DB) GetActiveProducts() ([]models.Product, error) {
-	rows, err := db.conn.Query("SELECT id, url, name, current_price, original_price, discount, target_price, target_discount, last_checked, active, created_at FROM products WHERE active = 1")
+// MergeDuplicateProducts calcula a chave canônica dos produtos cadastrados sem chave e junta
+// os que apontam para o mesmo produto, retornando quantos produtos duplicados foram removidos
+// O produto mais antigo de cada chave é mantido e recebe o histórico, as entregas de webhooks
+// e as inscrições dos duplicados; quando um chat monitorava os dois, vale a inscrição ativa
+func (db *DB) MergeDuplicateProducts(productKey func(url string) string) (int, error) {
+	type pending struct {
+		id  int64
+		url string
+	}
+
+	rows, err := db.conn.Query("SELECT id, url FROM products WHERE product_key IS NULL OR product_key = '' ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	var products []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.url); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(products) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	merged := 0
+	for _, p := range products {
+		key := productKey(p.url)
+
+		var keeperID int64
+		err := tx.QueryRow("SELECT id FROM products WHERE product_key = ?", key).Scan(&keeperID)
+		if err == sql.ErrNoRows {
+			if _, err := tx.Exec("UPDATE products SET product_key = ? WHERE id = ?", key, p.id); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if err := mergeProduct(tx, keeperID, p.id); err != nil {
+			return 0, fmt.Errorf("erro ao juntar o produto %d ao produto %d: %v", p.id, keeperID, err)
+		}
+		log.Printf("Produto %d (%s) juntado ao produto %d (%s)", p.id, p.url, keeperID, key)
+		merged++
+	}
+
+	return merged, tx.Commit()
+}
+
+// mergeProduct move os dados do produto duplicado para o produto mantido e remove o duplicado
+func mergeProduct(tx *sql.Tx, keeperID, duplicateID int64) error {
+	statements := []string{
+		"UPDATE price_history SET product_id = ?1 WHERE product_id = ?2",
+		"UPDATE webhook_deliveries SET product_id = ?1 WHERE product_id = ?2",
+		// As ofertas do duplicado são lidas de novo na próxima verificação do produto mantido
+		"DELETE FROM product_offers WHERE product_id = ?2",
+		// Uma inscrição ativa no duplicado substitui a inscrição inativa do mesmo chat no produto mantido
+		`DELETE FROM subscriptions WHERE product_id = ?1 AND active = 0
+			AND chat_id IN (SELECT chat_id FROM subscriptions WHERE product_id = ?2 AND active = 1)`,
+		"UPDATE OR IGNORE subscriptions SET product_id = ?1 WHERE product_id = ?2",
+		"DELETE FROM subscriptions WHERE product_id = ?2",
+		"UPDATE products SET active = 1 WHERE id = ?1 AND EXISTS (SELECT 1 FROM products WHERE id = ?2 AND active = 1)",
+		"DELETE FROM products WHERE id = ?2",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, keeperID, duplicateID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// productColumns lista as colunas lidas por scanProduct, na mesma ordem
+const productColumns = "id, url, product_key, variant, name, current_price_cents, cash_price_cents, card_price_cents, installment_count, installment_value_cents, installment_interest_free, coupon_amount_cents, coupon_percent, coupon_max_discount_cents, deal_badge, deal_ends_at, original_price_cents, currency, discount, availability, stock_quantity, last_checked, active, created_at"
+
+// rowScanner é satisfeito por *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct lê um produto a partir de uma linha com as colunas de productColumns
+func scanProduct(row rowScanner) (models.Product, error) {
+	var p models.Product
+	var key, variant sql.NullString
+	var lastChecked sql.NullTime
+	var currentCents, originalCents sql.NullInt64
+	var payment paymentColumns
+	var promotion promotionColumns
+	var currency sql.NullString
+	var discount sql.NullFloat64
+	var availability sql.NullString
+	var stockQuantity sql.NullInt64
+	err := row.Scan(&p.ID, &p.URL, &key, &variant, &p.Name, &currentCents, &payment.cashCents, &payment.cardCents, &payment.installmentCount, &payment.installmentCents, &payment.interestFree,
+		&promotion.couponCents, &promotion.couponPercent, &promotion.couponMaxCents, &promotion.dealBadge, &promotion.dealEndsAt,
+		&originalCents, &currency, &discount, &availability, &stockQuantity, &lastChecked, &p.Active, &p.CreatedAt)
+	if err != nil {
+		return p, err
+	}
+	p.Key = key.String
+	p.Variant = variant.String
+	payment.apply(&p, currency.String)
+	promotion.apply(&p, currency.String)
+	p.Availability = availability.String
+	p.StockQuantity = int(stockQuantity.Int64)
+	p.CurrentPrice = money.New(currentCents.Int64, currency.String)
+	p.OriginalPrice = money.New(originalCents.Int64, currency.String)
+	if lastChecked.Valid {
+		p.LastChecked = lastChecked.Time
+	}
+	if discount.Valid {
+		p.Discount = discount.Float64
+	}
+	return p, nil
+}
+
+// paymentColumns guarda os preços à vista, no cartão e o parcelamento lidos de uma linha de products
+type paymentColumns struct {
+	cashCents, cardCents, installmentCount, installmentCents sql.NullInt64
+	interestFree                                             sql.NullBool
+}
+
+// apply preenche os preços por forma de pagamento do produto
+func (c paymentColumns) apply(p *models.Product, currency string) {
+	p.CashPrice = money.New(c.cashCents.Int64, currency)
+	p.CardPrice = money.New(c.cardCents.Int64, currency)
+	p.Installments = models.Installments{
+		Count:        int(c.installmentCount.Int64),
+		Value:        money.New(c.installmentCents.Int64, currency),
+		InterestFree: c.interestFree.Bool,
+	}
+}
+
+// promotionColumns guarda o cupom e o selo promocional lidos de uma linha de products
+type promotionColumns struct {
+	couponCents, couponMaxCents sql.NullInt64
+	couponPercent               sql.NullFloat64
+	dealBadge                   sql.NullString
+	dealEndsAt                  sql.NullTime
+}
+
+// apply preenche o cupom e o selo promocional do produto
+func (c promotionColumns) apply(p *models.Product, currency string) {
+	p.Coupon = models.Coupon{
+		Amount:      money.New(c.couponCents.Int64, currency),
+		Percent:     c.couponPercent.Float64,
+		MaxDiscount: money.New(c.couponMaxCents.Int64, currency),
+	}
+	p.Deal = models.Deal{Badge: c.dealBadge.String}
+	if c.dealEndsAt.Valid {
+		p.Deal.EndsAt = c.dealEndsAt.Time
+	}
+}
+
+// queryProducts executa uma consulta que retorna as colunas de productColumns
+func (db *DB) queryProducts(query string, args ...interface{}) ([]models.Product, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -86,51 +492,112 @@ func (db *DB) GetActiveProducts() ([]models.Product, error) {
 
 	var products []models.Product
 	for rows.Next() {
-		var p models.Product
-		var lastChecked sql.NullTime
-		var originalPrice sql.NullFloat64
-		var discount sql.NullFloat64
-		err := rows.Scan(&p.ID, &p.URL, &p.Name, &p.CurrentPrice, &originalPrice, &discount, &p.TargetPrice, &p.TargetDiscount, &lastChecked, &p.Active, &p.CreatedAt)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		if lastChecked.Valid {
-			p.LastChecked = lastChecked.Time
-		}
-		if originalPrice.Valid {
-			p.OriginalPrice = originalPrice.Float64
-		}
-		if discount.Valid {
-			p.Discount = discount.Float64
-		}
 		products = append(products, p)
 	}
 	return products, rows.Err()
 }
 
+// addProduct adiciona um produto ao banco de dados dentro da transação e retorna seu ID
+// key é a chave canônica do produto (ver scraper.Registry.ProductKey); se ela já estiver cadastrada,
+// mesmo com outra URL, o produto existente é reativado e seu ID é retornado
+// variant, quando informado, são os atributos da variante monitorada (ex: "Voltagem: 127V")
+func addProduct(tx *sql.Tx, url, key, name, variant string) (int64, error) {
+	_, err := tx.Exec(
+		"INSERT INTO products (url, product_key, name, current_price_cents, original_price_cents, active) VALUES (?, ?, ?, 0, 0, 1) ON CONFLICT(product_key) WHERE product_key != '' DO UPDATE SET active = 1",
+		url, key, name,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	if err := tx.QueryRow("SELECT id FROM products WHERE product_key = ?", key).Scan(&id); err != nil {
+		return 0, err
+	}
+	if variant != "" {
+		if _, err := tx.Exec("UPDATE products SET variant = ? WHERE id = ?", variant, id); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// GetActiveProducts retorna todos os produtos ativos com ao menos uma inscrição ativa de um chat,
+// com os intervalos de verificação pedidos pelas inscrições
+func (db *DB) GetActiveProducts() ([]models.Product, error) {
+	products, err := db.queryProducts("SELECT "+productColumns+" FROM products WHERE active = 1 AND EXISTS (SELECT 1 FROM subscriptions s WHERE s.product_id = products.id AND s.active = 1 AND s.chat_id != ?)", legacyChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals, err := db.activeSubscriptionIntervals()
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].CheckIntervals = intervals[products[i].ID]
+	}
+	return products, nil
+}
+
 // UpdateProductPrice atualiza o preço atual de um produto
-func (db *DB) UpdateProductPrice(id int64, price float64) error {
+func (db *DB) UpdateProductPrice(id int64, price money.Money) error {
 	_, err := db.conn.Exec(
-		"UPDATE products SET current_price = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
-		price, id,
+		"UPDATE products SET current_price_cents = ?, currency = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
+		price.Cents, price.CurrencyCode(), id,
 	)
 	return err
 }
 
 // UpdateProductPrices atualiza o preço atual e original de um produto
-func (db *DB) UpdateProductPrices(id int64, currentPrice, originalPrice float64) error {
+func (db *DB) UpdateProductPrices(id int64, currentPrice, originalPrice money.Money) error {
+	_, err := db.conn.Exec(
+		"UPDATE products SET current_price_cents = ?, original_price_cents = ?, currency = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
+		currentPrice.Cents, originalPrice.Cents, currentPrice.CurrencyCode(), id,
+	)
+	return err
+}
+
+// UpdateProductAvailability atualiza a disponibilidade e a quantidade em estoque de um produto
+func (db *DB) UpdateProductAvailability(id int64, availability string, stockQuantity int) error {
+	_, err := db.conn.Exec(
+		"UPDATE products SET availability = ?, stock_quantity = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
+		availability, stockQuantity, id,
+	)
+	return err
+}
+
+// UpdateProductPaymentOptions atualiza os preços à vista e no cartão e o parcelamento de um produto
+func (db *DB) UpdateProductPaymentOptions(id int64, cashPrice, cardPrice money.Money, installments models.Installments) error {
 	_, err := db.conn.Exec(
-		"UPDATE products SET current_price = ?, original_price = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
-		currentPrice, originalPrice, id,
+		"UPDATE products SET cash_price_cents = ?, card_price_cents = ?, installment_count = ?, installment_value_cents = ?, installment_interest_free = ? WHERE id = ?",
+		cashPrice.Cents, cardPrice.Cents, installments.Count, installments.Value.Cents, installments.InterestFree, id,
+	)
+	return err
+}
+
+// UpdateProductPromotions atualiza o melhor cupom ativo e o selo promocional de um produto
+func (db *DB) UpdateProductPromotions(id int64, coupon models.Coupon, deal models.Deal) error {
+	var endsAt sql.NullTime
+	if !deal.EndsAt.IsZero() {
+		endsAt = sql.NullTime{Time: deal.EndsAt, Valid: true}
+	}
+	_, err := db.conn.Exec(
+		"UPDATE products SET coupon_amount_cents = ?, coupon_percent = ?, coupon_max_discount_cents = ?, deal_badge = ?, deal_ends_at = ? WHERE id = ?",
+		coupon.Amount.Cents, coupon.Percent, coupon.MaxDiscount.Cents, deal.Badge, endsAt, id,
 	)
 	return err
 }
 
 // UpdateProductPricesWithDiscount atualiza o preço atual, original e desconto de um produto
-func (db *DB) UpdateProductPricesWithDiscount(id int64, currentPrice, originalPrice, discount float64) error {
+func (db *DB) UpdateProductPricesWithDiscount(id int64, currentPrice, originalPrice money.Money, discount float64) error {
 	_, err := db.conn.Exec(
-		"UPDATE products SET current_price = ?, original_price = ?, discount = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
-		currentPrice, originalPrice, discount, id,
+		"UPDATE products SET current_price_cents = ?, original_price_cents = ?, currency = ?, discount = ?, last_checked = CURRENT_TIMESTAMP WHERE id = ?",
+		currentPrice.Cents, originalPrice.Cents, currentPrice.CurrencyCode(), discount, id,
 	)
 	return err
 }
@@ -143,58 +610,82 @@ func (db *DB) DeactivateProduct(id int64) error {
 
 // GetProductByID retorna um produto pelo ID
 func (db *DB) GetProductByID(id int64) (*models.Product, error) {
-	var p models.Product
-	var lastChecked sql.NullTime
-	var originalPrice sql.NullFloat64
-	var discount sql.NullFloat64
-	err := db.conn.QueryRow(
-		"SELECT id, url, name, current_price, original_price, discount, target_price, target_discount, last_checked, active, created_at FROM products WHERE id = ?",
-		id,
-	).Scan(&p.ID, &p.URL, &p.Name, &p.CurrentPrice, &originalPrice, &discount, &p.TargetPrice, &p.TargetDiscount, &lastChecked, &p.Active, &p.CreatedAt)
+	p, err := scanProduct(db.conn.QueryRow("SELECT "+productColumns+" FROM products WHERE id = ?", id))
 	if err != nil {
 		return nil, err
 	}
-	if lastChecked.Valid {
-		p.LastChecked = lastChecked.Time
-	}
-	if originalPrice.Valid {
-		p.OriginalPrice = originalPrice.Float64
-	}
-	if discount.Valid {
-		p.Discount = discount.Float64
-	}
 	return &p, nil
 }
 
 // ListProducts retorna todos os produtos (ativos e inativos)
 func (db *DB) ListProducts() ([]models.Product, error) {
-	rows, err := db.conn.Query("SELECT id, url, name, current_price, original_price, discount, target_price, target_discount, last_checked, active, created_at FROM products ORDER BY created_at DESC")
+	return db.queryProducts("SELECT " + productColumns + " FROM products ORDER BY created_at DESC")
+}
+
+// AddPriceHistory registra uma observação de preço no histórico
+func (db *DB) AddPriceHistory(entry models.PriceHistory) error {
+	if entry.CheckedAt.IsZero() {
+		entry.CheckedAt = time.Now()
+	}
+	_, err := db.conn.Exec(
+		"INSERT INTO price_history (product_id, checked_at, current_price_cents, original_price_cents, discount, availability, stock_quantity, status, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.ProductID, entry.CheckedAt.UTC(), entry.CurrentPrice.Cents, entry.OriginalPrice.Cents, entry.Discount, entry.Availability, entry.StockQuantity, entry.Status, entry.Error,
+	)
+	return err
+}
+
+// GetPriceHistory retorna as observações de um produto desde a data informada, em ordem cronológica
+func (db *DB) GetPriceHistory(productID int64, since time.Time) ([]models.PriceHistory, error) {
+	rows, err := db.conn.Query(
+		"SELECT h.id, h.product_id, h.checked_at, h.current_price_cents, h.original_price_cents, p.currency, h.discount, h.availability, h.stock_quantity, h.status, h.error FROM price_history h JOIN products p ON p.id = h.product_id WHERE h.product_id = ? AND h.checked_at >= ? ORDER BY h.checked_at ASC",
+		productID, since.UTC(),
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var products []models.Product
+	var history []models.PriceHistory
 	for rows.Next() {
-		var p models.Product
-		var lastChecked sql.NullTime
-		var originalPrice sql.NullFloat64
+		var h models.PriceHistory
+		var currentCents, originalCents sql.NullInt64
+		var currency, availability, errorText sql.NullString
 		var discount sql.NullFloat64
-		err := rows.Scan(&p.ID, &p.URL, &p.Name, &p.CurrentPrice, &originalPrice, &discount, &p.TargetPrice, &p.TargetDiscount, &lastChecked, &p.Active, &p.CreatedAt)
+		var stockQuantity sql.NullInt64
+		err := rows.Scan(&h.ID, &h.ProductID, &h.CheckedAt, &currentCents, &originalCents, &currency, &discount, &availability, &stockQuantity, &h.Status, &errorText)
 		if err != nil {
 			return nil, err
 		}
-		if lastChecked.Valid {
-			p.LastChecked = lastChecked.Time
-		}
-		if originalPrice.Valid {
-			p.OriginalPrice = originalPrice.Float64
-		}
-		if discount.Valid {
-			p.Discount = discount.Float64
-		}
-		products = append(products, p)
+		h.Availability = availability.String
+		h.StockQuantity = int(stockQuantity.Int64)
+		h.CurrentPrice = money.New(currentCents.Int64, currency.String)
+		h.OriginalPrice = money.New(originalCents.Int64, currency.String)
+		h.Discount = discount.Float64
+		h.Error = errorText.String
+		history = append(history, h)
 	}
-	return products, rows.Err()
+	return history, rows.Err()
 }
 
+// GetLowestPrice retorna a observação com o menor preço já registrado para um produto
+// Retorna nil se o produto ainda não tiver observações bem-sucedidas
+func (db *DB) GetLowestPrice(productID int64) (*models.PriceHistory, error) {
+	var h models.PriceHistory
+	var currentCents, originalCents sql.NullInt64
+	var currency sql.NullString
+	var discount sql.NullFloat64
+	err := db.conn.QueryRow(
+		"SELECT h.id, h.product_id, h.checked_at, h.current_price_cents, h.original_price_cents, p.currency, h.discount, h.status FROM price_history h JOIN products p ON p.id = h.product_id WHERE h.product_id = ? AND h.status = ? AND h.current_price_cents > 0 ORDER BY h.current_price_cents ASC, h.checked_at ASC LIMIT 1",
+		productID, models.ScrapeStatusOK,
+	).Scan(&h.ID, &h.ProductID, &h.CheckedAt, &currentCents, &originalCents, &currency, &discount, &h.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.CurrentPrice = money.New(currentCents.Int64, currency.String)
+	h.OriginalPrice = money.New(originalCents.Int64, currency.String)
+	h.Discount = discount.Float64
+	return &h, nil
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// baselineSchema é a tabela products da primeira versão do bot, com a URL única
+const baselineSchema = `
+CREATE TABLE products (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL UNIQUE,
+	name TEXT,
+	current_price REAL,
+	original_price REAL,
+	discount REAL,
+	target_price REAL,
+	target_discount REAL,
+	last_checked DATETIME,
+	active BOOLEAN DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// baselineProduct é uma linha da tabela products no banco da primeira versão
+type baselineProduct struct {
+	url         string
+	name        string
+	price       float64
+	targetPrice float64
+}
+
+// newBaselineDB cria um arquivo SQLite com o esquema da primeira versão e os produtos informados
+// e retorna o caminho do arquivo, ainda sem as migrações
+func newBaselineDB(t *testing.T, products ...baselineProduct) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "baseline.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range products {
+		_, err := conn.Exec("INSERT INTO products (url, name, current_price, original_price, target_price, target_discount) VALUES (?, ?, ?, ?, ?, 0)",
+			p.url, p.name, p.price, p.price, p.targetPrice)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func openDB(t *testing.T, path string) *DB {
+	t.Helper()
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrationDropsProductURLUnique(t *testing.T) {
+	path := newBaselineDB(t,
+		baselineProduct{url: "https://www.kabum.com.br/produto/1", name: "Fone", price: 199.9, targetPrice: 150},
+		baselineProduct{url: "https://www.kabum.com.br/produto/2", name: "Mouse", price: 89.9},
+		baselineProduct{url: "https://www.kabum.com.br/produto/3", name: "Teclado", price: 249},
+	)
+
+	// O ID removido não pode ser reaproveitado depois que a tabela é recriada
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("DELETE FROM products WHERE id = 3"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	db := openDB(t, path)
+
+	unique, err := db.productURLUnique()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unique {
+		t.Fatal("a coluna url ainda é única depois da migração")
+	}
+
+	product, err := db.GetProductByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.URL != "https://www.kabum.com.br/produto/1" || product.Name != "Fone" || product.CurrentPrice.Cents != 19990 {
+		t.Errorf("produto 1 = %q %q %d, esperado os dados do banco antigo", product.URL, product.Name, product.CurrentPrice.Cents)
+	}
+
+	// Os alvos antigos viram inscrições do chat legado
+	if _, err := db.AssignLegacySubscriptions(10); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := db.GetProductSubscriptions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].TargetPrice.Cents != 15000 {
+		t.Errorf("inscrições do produto 1 = %+v, esperado uma com alvo de 15000 centavos", subs)
+	}
+
+	// Variantes do mesmo anúncio têm a mesma URL e chaves diferentes
+	first, _, err := db.AddProductSubscription("https://www.kabum.com.br/produto/2", "kabum-2-127v", "Mouse 127V", "Voltagem: 127V", models.Subscription{ChatID: 10})
+	if err != nil {
+		t.Fatalf("AddProductSubscription com URL repetida: %v", err)
+	}
+	second, _, err := db.AddProductSubscription("https://www.kabum.com.br/produto/2", "kabum-2-220v", "Mouse 220V", "Voltagem: 220V", models.Subscription{ChatID: 10})
+	if err != nil {
+		t.Fatalf("AddProductSubscription com URL repetida: %v", err)
+	}
+	if first != 4 || second != 5 {
+		t.Errorf("IDs dos produtos novos = %d e %d, esperado 4 e 5", first, second)
+	}
+
+	// Abrir de novo não recria a tabela nem perde os produtos
+	db.Close()
+	db = openDB(t, path)
+	products, err := db.ListProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 4 {
+		t.Errorf("ListProducts retornou %d produtos, esperado 4", len(products))
+	}
+}
+
+func TestMigrationKeepsNewSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.db")
+	db := openDB(t, path)
+
+	unique, err := db.productURLUnique()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unique {
+		t.Error("banco novo criado com a URL única")
+	}
+	if _, _, err := db.AddProductSubscription("https://www.kabum.com.br/produto/1", "kabum-1", "Fone", "", models.Subscription{ChatID: 10}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeDuplicateProducts(t *testing.T) {
+	path := newBaselineDB(t,
+		baselineProduct{url: "https://www.mercadolivre.com.br/p/MLB50097091", name: "Catálogo", price: 100, targetPrice: 90},
+		baselineProduct{url: "https://produto.mercadolivre.com.br/MLB-50097091-fone", name: "Anúncio", price: 100, targetPrice: 80},
+		baselineProduct{url: "https://www.kabum.com.br/produto/1", name: "Fone", price: 50},
+		baselineProduct{url: "https://www.mercadolivre.com.br/p/MLB50097091?tracking_id=abc", name: "Rastreado", price: 100},
+	)
+	db := openDB(t, path)
+
+	keys := map[string]string{
+		"https://www.mercadolivre.com.br/p/MLB50097091":                 "ML:MLB50097091",
+		"https://produto.mercadolivre.com.br/MLB-50097091-fone":         "ML:MLB50097091",
+		"https://www.kabum.com.br/produto/1":                            "https://www.kabum.com.br/produto/1",
+		"https://www.mercadolivre.com.br/p/MLB50097091?tracking_id=abc": "ML:MLB50097091",
+	}
+	productKey := func(url string) string { return keys[url] }
+
+	// As inscrições migradas são do chat legado; o chat 20 segue só o anúncio, o chat 30 segue o catálogo e o anúncio
+	if _, err := db.AssignLegacySubscriptions(10); err != nil {
+		t.Fatal(err)
+	}
+	// Os produtos antigos ainda não têm chave, então as inscrições são gravadas diretamente
+	for _, sub := range []models.Subscription{
+		{ProductID: 2, ChatID: 20, TargetPrice: money.BRL(7000)},
+		{ProductID: 1, ChatID: 30, TargetPrice: money.BRL(6000)},
+		{ProductID: 2, ChatID: 30, TargetPrice: money.BRL(6500)},
+	} {
+		tx, err := db.conn.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := addSubscription(tx, sub); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.RemoveSubscription(30, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	checkedAt := time.Now().Add(-time.Hour)
+	for _, id := range []int64{1, 2, 4} {
+		entry := models.PriceHistory{ProductID: id, CheckedAt: checkedAt, CurrentPrice: money.BRL(10000), Status: models.ScrapeStatusOK}
+		if err := db.AddPriceHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged, err := db.MergeDuplicateProducts(productKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 2 {
+		t.Errorf("MergeDuplicateProducts juntou %d produtos, esperado 2", merged)
+	}
+
+	for _, id := range []int64{2, 4} {
+		if p, err := db.GetProductByID(id); err == nil {
+			t.Errorf("produto duplicado %d (%s) não foi removido", id, p.URL)
+		}
+	}
+	kept, err := db.GetProductByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.Key != "ML:MLB50097091" {
+		t.Errorf("chave do produto mantido = %q, esperado ML:MLB50097091", kept.Key)
+	}
+	other, err := db.GetProductByID(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Key != "https://www.kabum.com.br/produto/1" {
+		t.Errorf("chave do produto sem duplicados = %q", other.Key)
+	}
+
+	history, err := db.GetPriceHistory(1, checkedAt.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Errorf("histórico do produto mantido tem %d observações, esperado 3", len(history))
+	}
+
+	subs, err := db.GetProductSubscriptions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := make(map[int64]int64)
+	for _, sub := range subs {
+		targets[sub.ChatID] = sub.TargetPrice.Cents
+	}
+	// O chat 10 tinha inscrições nos três: vale a do produto mantido
+	// O chat 30 tinha removido a inscrição do catálogo: vale a ativa do anúncio
+	want := map[int64]int64{10: 9000, 20: 7000, 30: 6500}
+	if len(targets) != len(want) {
+		t.Errorf("inscrições do produto mantido = %v, esperado %v", targets, want)
+	}
+	for chatID, cents := range want {
+		if targets[chatID] != cents {
+			t.Errorf("alvo do chat %d = %d, esperado %d", chatID, targets[chatID], cents)
+		}
+	}
+
+	// Uma segunda execução não encontra produtos sem chave
+	merged, err = db.MergeDuplicateProducts(productKey)
+	if err != nil || merged != 0 {
+		t.Errorf("segunda execução = %d, %v; esperado 0, nil", merged, err)
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// initProductOffers cria a tabela com as ofertas dos vendedores de cada produto
+func (db *DB) initProductOffers() error {
+	createOffersSQL := `
+	CREATE TABLE IF NOT EXISTS product_offers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL REFERENCES products(id),
+		item_id TEXT NOT NULL,
+		seller_id INTEGER DEFAULT 0,
+		seller_name TEXT DEFAULT '',
+		official_store BOOLEAN DEFAULT 0,
+		reputation INTEGER DEFAULT 0,
+		price_cents INTEGER NOT NULL,
+		original_price_cents INTEGER DEFAULT 0,
+		currency TEXT DEFAULT 'BRL',
+		free_shipping BOOLEAN DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_offers_product ON product_offers (product_id);
+	`
+
+	_, err := db.conn.Exec(createOffersSQL)
+	return err
+}
+
+// ReplaceProductOffers substitui as ofertas salvas do produto pelas da última verificação
+func (db *DB) ReplaceProductOffers(productID int64, offers []models.Offer) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM product_offers WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+	for _, offer := range offers {
+		_, err := tx.Exec(
+			`INSERT INTO product_offers (product_id, item_id, seller_id, seller_name, official_store, reputation, price_cents, original_price_cents, currency, free_shipping)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			productID, offer.ItemID, offer.Seller.ID, offer.Seller.Name, offer.Seller.OfficialStore, offer.Seller.Reputation,
+			offer.Price.Cents, offer.OriginalPrice.Cents, offer.Price.CurrencyCode(), offer.Shipping.Free,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetProductOffers retorna as ofertas salvas do produto, da mais barata para a mais cara
+func (db *DB) GetProductOffers(productID int64) ([]models.Offer, error) {
+	rows, err := db.conn.Query(
+		`SELECT item_id, seller_id, seller_name, official_store, reputation, price_cents, original_price_cents, currency, free_shipping
+		FROM product_offers WHERE product_id = ? ORDER BY price_cents, id`,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []models.Offer
+	for rows.Next() {
+		var offer models.Offer
+		var sellerName, currency sql.NullString
+		var priceCents, originalCents int64
+		err := rows.Scan(
+			&offer.ItemID, &offer.Seller.ID, &sellerName, &offer.Seller.OfficialStore, &offer.Seller.Reputation,
+			&priceCents, &originalCents, &currency, &offer.Shipping.Free,
+		)
+		if err != nil {
+			return nil, err
+		}
+		offer.Seller.Name = sellerName.String
+		offer.Price = money.New(priceCents, currency.String)
+		offer.OriginalPrice = money.New(originalCents, currency.String)
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
@@ -0,0 +1,343 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// legacyChatID marca inscrições migradas de bancos anteriores ao suporte a múltiplos chats,
+// que ainda não foram atribuídas a um chat por AssignLegacySubscriptions
+const legacyChatID = 0
+
+// initSubscriptions cria a tabela de inscrições e migra os alvos antigos da tabela products
+func (db *DB) initSubscriptions() error {
+	createSubscriptionsSQL := `
+	CREATE TABLE IF NOT EXISTS subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL REFERENCES products(id),
+		chat_id INTEGER NOT NULL,
+		target_price REAL,
+		target_discount REAL,
+		active BOOLEAN DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (product_id, chat_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_subscriptions_chat ON subscriptions (chat_id);
+	`
+
+	if _, err := db.conn.Exec(createSubscriptionsSQL); err != nil {
+		return err
+	}
+
+	// Produtos criados antes das inscrições guardavam os alvos na própria tabela products.
+	// Criar uma inscrição para cada um deles, ainda sem chat definido.
+	result, err := db.conn.Exec(`
+		INSERT INTO subscriptions (product_id, chat_id, target_price, target_discount, active, created_at)
+		SELECT id, ?, COALESCE(target_price, 0), COALESCE(target_discount, 0), active, created_at FROM products
+		WHERE NOT EXISTS (SELECT 1 FROM subscriptions s WHERE s.product_id = products.id)`,
+		legacyChatID,
+	)
+	if err != nil {
+		return err
+	}
+	if migrated, _ := result.RowsAffected(); migrated > 0 {
+		log.Printf("%d produto(s) migrado(s) para inscrições", migrated)
+	}
+	return nil
+}
+
+// CountLegacySubscriptions retorna quantas inscrições ativas ainda não foram atribuídas a um chat
+func (db *DB) CountLegacySubscriptions() (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM subscriptions WHERE chat_id = ? AND active = 1", legacyChatID).Scan(&count)
+	return count, err
+}
+
+// AssignLegacySubscriptions atribui ao chat informado as inscrições migradas sem chat definido
+func (db *DB) AssignLegacySubscriptions(chatID int64) (int64, error) {
+	result, err := db.conn.Exec("UPDATE subscriptions SET chat_id = ? WHERE chat_id = ?", chatID, legacyChatID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// migrateSubscriptionIntervals copia o intervalo que era de todo o produto para as suas inscrições ativas
+// O intervalo passou a ser de cada chat; a coluna de products é zerada para não ser copiada de novo e deixa de ser lida
+func (db *DB) migrateSubscriptionIntervals() error {
+	migrateSQL := `
+	UPDATE subscriptions SET check_interval_seconds = (SELECT p.check_interval_seconds FROM products p WHERE p.id = subscriptions.product_id)
+	WHERE active = 1 AND COALESCE(check_interval_seconds, 0) = 0
+		AND product_id IN (SELECT id FROM products WHERE check_interval_seconds > 0);
+	UPDATE products SET check_interval_seconds = 0 WHERE check_interval_seconds > 0;
+	`
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migrateSQL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// activeSubscriptionIntervals retorna, por produto, os intervalos pedidos pelas inscrições ativas dos chats
+func (db *DB) activeSubscriptionIntervals() (map[int64][]time.Duration, error) {
+	rows, err := db.conn.Query("SELECT product_id, check_interval_seconds FROM subscriptions WHERE active = 1 AND chat_id != ?", legacyChatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intervals := make(map[int64][]time.Duration)
+	for rows.Next() {
+		var productID int64
+		var seconds sql.NullInt64
+		if err := rows.Scan(&productID, &seconds); err != nil {
+			return nil, err
+		}
+		intervals[productID] = append(intervals[productID], time.Duration(seconds.Int64)*time.Second)
+	}
+	return intervals, rows.Err()
+}
+
+// subscriptionColumns lista as colunas lidas por scanSubscription, incluindo as do produto
+const subscriptionColumns = "s.id, s.product_id, s.chat_id, s.target_price_cents, s.target_discount, s.notify_in_stock, s.with_shipping, s.with_coupon, s.min_seller_reputation, s.official_store_only, s.price_basis, s.target_installments, s.max_installment_value_cents, s.check_interval_seconds, s.active, s.created_at, " +
+	"p.id, p.url, p.product_key, p.variant, p.name, p.current_price_cents, p.cash_price_cents, p.card_price_cents, p.installment_count, p.installment_value_cents, p.installment_interest_free, " +
+	"p.coupon_amount_cents, p.coupon_percent, p.coupon_max_discount_cents, p.deal_badge, p.deal_ends_at, p.original_price_cents, p.currency, p.discount, p.availability, p.stock_quantity, p.last_checked, p.active, p.created_at"
+
+// scanSubscription lê uma inscrição e o produto associado
+func scanSubscription(row rowScanner) (models.Subscription, error) {
+	var sub models.Subscription
+	var targetCents sql.NullInt64
+	var targetDiscount sql.NullFloat64
+	var notifyInStock, withShipping, withCoupon, officialStoreOnly sql.NullBool
+	var minReputation sql.NullInt64
+	var priceBasis sql.NullString
+	var targetInstallments, maxInstallmentCents, intervalSeconds sql.NullInt64
+	var payment paymentColumns
+	var promotion promotionColumns
+	var lastChecked sql.NullTime
+	var currentCents, originalCents sql.NullInt64
+	var productKey, variant, currency, availability sql.NullString
+	var discount sql.NullFloat64
+	var stockQuantity sql.NullInt64
+	p := &sub.Product
+	err := row.Scan(
+		&sub.ID, &sub.ProductID, &sub.ChatID, &targetCents, &targetDiscount, &notifyInStock, &withShipping, &withCoupon, &minReputation, &officialStoreOnly, &priceBasis, &targetInstallments, &maxInstallmentCents, &intervalSeconds, &sub.Active, &sub.CreatedAt,
+		&p.ID, &p.URL, &productKey, &variant, &p.Name, &currentCents, &payment.cashCents, &payment.cardCents, &payment.installmentCount, &payment.installmentCents, &payment.interestFree,
+		&promotion.couponCents, &promotion.couponPercent, &promotion.couponMaxCents, &promotion.dealBadge, &promotion.dealEndsAt, &originalCents, &currency, &discount, &availability, &stockQuantity, &lastChecked, &p.Active, &p.CreatedAt,
+	)
+	if err != nil {
+		return sub, err
+	}
+	sub.NotifyInStock = notifyInStock.Bool
+	sub.WithShipping = withShipping.Bool
+	sub.WithCoupon = withCoupon.Bool
+	sub.SellerFilter = models.SellerFilter{MinReputation: int(minReputation.Int64), OfficialStoreOnly: officialStoreOnly.Bool}
+	sub.PriceBasis = models.PriceBasis(priceBasis.String)
+	sub.TargetInstallments = int(targetInstallments.Int64)
+	sub.MaxInstallmentValue = money.New(maxInstallmentCents.Int64, currency.String)
+	sub.CheckInterval = time.Duration(intervalSeconds.Int64) * time.Second
+	p.Key = productKey.String
+	p.Variant = variant.String
+	payment.apply(p, currency.String)
+	promotion.apply(p, currency.String)
+	p.Availability = availability.String
+	p.StockQuantity = int(stockQuantity.Int64)
+	sub.TargetPrice = money.New(targetCents.Int64, currency.String)
+	sub.TargetDiscount = targetDiscount.Float64
+	if lastChecked.Valid {
+		p.LastChecked = lastChecked.Time
+	}
+	p.CurrentPrice = money.New(currentCents.Int64, currency.String)
+	p.OriginalPrice = money.New(originalCents.Int64, currency.String)
+	p.Discount = discount.Float64
+	return sub, nil
+}
+
+// querySubscriptions executa uma consulta que retorna as colunas de subscriptionColumns
+func (db *DB) querySubscriptions(query string, args ...interface{}) ([]models.Subscription, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// AddProductSubscription cadastra o produto (ou reativa o que tem a mesma chave) e a inscrição do chat
+// em uma única transação, retornando o ID do produto; sub.ProductID é ignorado
+// Se o chat já tiver uma inscrição ativa no produto, os alvos dela são substituídos e updated é true
+func (db *DB) AddProductSubscription(url, key, name, variant string, sub models.Subscription) (productID int64, updated bool, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	productID, err = addProduct(tx, url, key, name, variant)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = tx.QueryRow("SELECT active FROM subscriptions WHERE chat_id = ? AND product_id = ?", sub.ChatID, productID).Scan(&updated)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	sub.ProductID = productID
+	if err := addSubscription(tx, sub); err != nil {
+		return 0, false, err
+	}
+	return productID, updated, tx.Commit()
+}
+
+// addSubscription inscreve um chat (sub.ChatID) em um produto (sub.ProductID) com seus próprios alvos
+// Uma inscrição removida anteriormente é reativada com os novos alvos
+func addSubscription(tx *sql.Tx, sub models.Subscription) error {
+	_, err := tx.Exec(`
+		INSERT INTO subscriptions (product_id, chat_id, target_price_cents, target_discount, notify_in_stock, with_shipping, with_coupon, price_basis,
+			target_installments, max_installment_value_cents, min_seller_reputation, official_store_only, active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(product_id, chat_id) DO UPDATE SET target_price_cents = excluded.target_price_cents, target_discount = excluded.target_discount,
+			notify_in_stock = excluded.notify_in_stock, with_shipping = excluded.with_shipping, with_coupon = excluded.with_coupon, price_basis = excluded.price_basis,
+			target_installments = excluded.target_installments, max_installment_value_cents = excluded.max_installment_value_cents,
+			min_seller_reputation = excluded.min_seller_reputation, official_store_only = excluded.official_store_only, active = 1`,
+		sub.ProductID, sub.ChatID, sub.TargetPrice.Cents, sub.TargetDiscount, sub.NotifyInStock, sub.WithShipping, sub.WithCoupon, string(sub.PriceBasis),
+		sub.TargetInstallments, sub.MaxInstallmentValue.Cents, sub.SellerFilter.MinReputation, sub.SellerFilter.OfficialStoreOnly,
+	)
+	return err
+}
+
+// SetSubscriptionShipping define se os alvos da inscrição consideram o preço com frete
+func (db *DB) SetSubscriptionShipping(chatID, productID int64, enabled bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET with_shipping = ? WHERE chat_id = ? AND product_id = ?",
+		enabled, chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionPriceBasis define a qual preço (à vista, cartão, parcela...) o alvo da inscrição se aplica
+func (db *DB) SetSubscriptionPriceBasis(chatID, productID int64, basis models.PriceBasis) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET price_basis = ? WHERE chat_id = ? AND product_id = ?",
+		string(basis), chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionInstallments define o alvo de parcelamento da inscrição (count 0 remove o alvo)
+func (db *DB) SetSubscriptionInstallments(chatID, productID int64, count int, maxValue money.Money) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET target_installments = ?, max_installment_value_cents = ? WHERE chat_id = ? AND product_id = ?",
+		count, maxValue.Cents, chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionCoupon define se o alvo de preço da inscrição considera o preço depois do cupom
+func (db *DB) SetSubscriptionCoupon(chatID, productID int64, enabled bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET with_coupon = ? WHERE chat_id = ? AND product_id = ?",
+		enabled, chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionSellerFilter define os vendedores considerados pela inscrição em páginas com várias ofertas
+func (db *DB) SetSubscriptionSellerFilter(chatID, productID int64, filter models.SellerFilter) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET min_seller_reputation = ?, official_store_only = ? WHERE chat_id = ? AND product_id = ?",
+		filter.MinReputation, filter.OfficialStoreOnly, chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionInterval define o intervalo de verificação pedido pelo chat (0 volta ao intervalo global)
+// O produto é verificado no menor intervalo entre as inscrições ativas
+func (db *DB) SetSubscriptionInterval(chatID, productID int64, interval time.Duration) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET check_interval_seconds = ? WHERE chat_id = ? AND product_id = ?",
+		int64(interval.Seconds()), chatID, productID,
+	)
+	return err
+}
+
+// SetSubscriptionStockAlert liga ou desliga o aviso de volta ao estoque de uma inscrição
+func (db *DB) SetSubscriptionStockAlert(chatID, productID int64, enabled bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE subscriptions SET notify_in_stock = ? WHERE chat_id = ? AND product_id = ?",
+		enabled, chatID, productID,
+	)
+	return err
+}
+
+// GetSubscription retorna a inscrição de um chat em um produto
+func (db *DB) GetSubscription(chatID, productID int64) (*models.Subscription, error) {
+	sub, err := scanSubscription(db.conn.QueryRow(
+		"SELECT "+subscriptionColumns+" FROM subscriptions s JOIN products p ON p.id = s.product_id WHERE s.chat_id = ? AND s.product_id = ?",
+		chatID, productID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetChatSubscriptions retorna as inscrições ativas de um chat, das mais recentes para as mais antigas
+func (db *DB) GetChatSubscriptions(chatID int64) ([]models.Subscription, error) {
+	return db.querySubscriptions(
+		"SELECT "+subscriptionColumns+" FROM subscriptions s JOIN products p ON p.id = s.product_id WHERE s.chat_id = ? AND s.active = 1 ORDER BY s.created_at DESC",
+		chatID,
+	)
+}
+
+// GetProductSubscriptions retorna as inscrições ativas de um produto
+func (db *DB) GetProductSubscriptions(productID int64) ([]models.Subscription, error) {
+	return db.querySubscriptions(
+		"SELECT "+subscriptionColumns+" FROM subscriptions s JOIN products p ON p.id = s.product_id WHERE s.product_id = ? AND s.active = 1 AND s.chat_id != ?",
+		productID, legacyChatID,
+	)
+}
+
+// RemoveSubscription desativa a inscrição de um chat em um produto
+// O produto é desativado quando não restam inscrições ativas
+func (db *DB) RemoveSubscription(chatID, productID int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE subscriptions SET active = 0 WHERE chat_id = ? AND product_id = ?", chatID, productID); err != nil {
+		return err
+	}
+
+	var remaining int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM subscriptions WHERE product_id = ? AND active = 1", productID).Scan(&remaining); err != nil {
+		return err
+	}
+	if remaining == 0 {
+		if _, err := tx.Exec("UPDATE products SET active = 0 WHERE id = ?", productID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+func TestSetSubscriptionIntervalPerChat(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "bot.db"))
+
+	const url = "https://www.kabum.com.br/produto/1"
+	productID, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.SetSubscriptionInterval(10, productID, 5*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for chatID, want := range map[int64]time.Duration{10: 5 * time.Minute, 20: 0} {
+		sub, err := db.GetSubscription(chatID, productID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.CheckInterval != want {
+			t.Errorf("intervalo do chat %d = %v, esperado %v", chatID, sub.CheckInterval, want)
+		}
+	}
+
+	products, err := db.GetActiveProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("GetActiveProducts retornou %d produtos, esperado 1", len(products))
+	}
+	intervals := products[0].CheckIntervals
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	if len(intervals) != 2 || intervals[0] != 0 || intervals[1] != 5*time.Minute {
+		t.Errorf("CheckIntervals = %v, esperado [0 5m]", intervals)
+	}
+
+	// A inscrição removida deixa de contar para o intervalo do produto
+	if err := db.RemoveSubscription(20, productID); err != nil {
+		t.Fatal(err)
+	}
+	products, err = db.GetActiveProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 || len(products[0].CheckIntervals) != 1 || products[0].CheckIntervals[0] != 5*time.Minute {
+		t.Errorf("GetActiveProducts = %+v, esperado um produto com o intervalo de 5m", products)
+	}
+}
+
+func TestMigrateSubscriptionIntervals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	db := openDB(t, path)
+
+	const url = "https://www.kabum.com.br/produto/1"
+	productID, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Versões anteriores guardavam o intervalo no produto
+	if _, err := db.conn.Exec("UPDATE products SET check_interval_seconds = 3600 WHERE id = ?", productID); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db = openDB(t, path)
+	for _, chatID := range []int64{10, 20} {
+		sub, err := db.GetSubscription(chatID, productID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.CheckInterval != time.Hour {
+			t.Errorf("intervalo do chat %d = %v, esperado 1h", chatID, sub.CheckInterval)
+		}
+	}
+
+	var productSeconds int
+	if err := db.conn.QueryRow("SELECT check_interval_seconds FROM products WHERE id = ?", productID).Scan(&productSeconds); err != nil {
+		t.Fatal(err)
+	}
+	if productSeconds != 0 {
+		t.Errorf("intervalo do produto = %d, esperado 0 depois da migração", productSeconds)
+	}
+
+	// Um intervalo alterado depois da migração não é sobrescrito ao reabrir o banco
+	if err := db.SetSubscriptionInterval(20, productID, 0); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	db = openDB(t, path)
+	sub, err := db.GetSubscription(20, productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.CheckInterval != 0 {
+		t.Errorf("intervalo do chat 20 = %v, esperado o intervalo global", sub.CheckInterval)
+	}
+}
+
+func TestAddProductSubscriptionPerChat(t *testing.T) {
+	db := openDB(t, ":memory:")
+
+	const url = "https://www.kabum.com.br/produto/1"
+	productID, updated, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10, TargetPrice: money.BRL(10000)})
+	if err != nil || updated {
+		t.Fatalf("AddProductSubscription do chat 10 = %v, %v; esperado uma inscrição nova", updated, err)
+	}
+	otherID, updated, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 20, TargetPrice: money.BRL(9000)})
+	if err != nil || updated {
+		t.Fatalf("AddProductSubscription do chat 20 = %v, %v; esperado uma inscrição nova", updated, err)
+	}
+	if otherID != productID {
+		t.Errorf("chat 20 recebeu o produto %d, esperado o mesmo produto %d", otherID, productID)
+	}
+
+	// Adicionar de novo atualiza só a inscrição do próprio chat
+	if _, updated, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10, TargetPrice: money.BRL(8000)}); err != nil || !updated {
+		t.Fatalf("AddProductSubscription repetido = %v, %v; esperado a inscrição atualizada", updated, err)
+	}
+	for chatID, cents := range map[int64]int64{10: 8000, 20: 9000} {
+		subs, err := db.GetChatSubscriptions(chatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subs) != 1 || subs[0].ProductID != productID || subs[0].TargetPrice.Cents != cents {
+			t.Errorf("inscrições do chat %d = %+v, esperado uma com alvo de %d centavos", chatID, subs, cents)
+		}
+	}
+	if subs, err := db.GetChatSubscriptions(30); err != nil || len(subs) != 0 {
+		t.Errorf("inscrições de um chat sem produtos = %+v, %v; esperado nenhuma", subs, err)
+	}
+	if subs, err := db.GetProductSubscriptions(productID); err != nil || len(subs) != 2 {
+		t.Errorf("inscrições do produto = %+v, %v; esperado as dos dois chats", subs, err)
+	}
+}
+
+func TestRemoveSubscription(t *testing.T) {
+	db := openDB(t, ":memory:")
+
+	const url = "https://www.kabum.com.br/produto/1"
+	productID, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	activeProducts := func() int {
+		t.Helper()
+		products, err := db.GetActiveProducts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(products)
+	}
+
+	// O produto continua ativo enquanto outro chat o acompanha
+	if err := db.RemoveSubscription(10, productID); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := db.GetChatSubscriptions(10); len(subs) != 0 {
+		t.Errorf("chat 10 ainda tem %d inscrições depois de remover", len(subs))
+	}
+	if subs, _ := db.GetChatSubscriptions(20); len(subs) != 1 {
+		t.Errorf("chat 20 tem %d inscrições, esperado 1 depois de o chat 10 remover a sua", len(subs))
+	}
+	if n := activeProducts(); n != 1 {
+		t.Errorf("%d produtos ativos, esperado 1 enquanto o chat 20 acompanha o produto", n)
+	}
+
+	// Sem inscrições ativas o produto deixa de ser verificado
+	if err := db.RemoveSubscription(20, productID); err != nil {
+		t.Fatal(err)
+	}
+	if n := activeProducts(); n != 0 {
+		t.Errorf("%d produtos ativos, esperado nenhum depois da última inscrição removida", n)
+	}
+	product, err := db.GetProductByID(productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Active {
+		t.Error("produto sem inscrições continua ativo")
+	}
+
+	// Adicionar de novo reativa o produto só para o chat que adicionou
+	if _, updated, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: 10}); err != nil || updated {
+		t.Fatalf("AddProductSubscription depois de remover = %v, %v; esperado uma inscrição nova", updated, err)
+	}
+	if n := activeProducts(); n != 1 {
+		t.Errorf("%d produtos ativos, esperado o produto reativado", n)
+	}
+	if subs, _ := db.GetProductSubscriptions(productID); len(subs) != 1 || subs[0].ChatID != 10 {
+		t.Errorf("inscrições do produto reativado = %+v, esperado só a do chat 10", subs)
+	}
+}
+
+func TestAssignLegacySubscriptions(t *testing.T) {
+	db := openDB(t, ":memory:")
+
+	// Inscrições migradas da versão sem chats ficam com o chat legado
+	const url = "https://www.kabum.com.br/produto/1"
+	productID, _, err := db.AddProductSubscription(url, url, "Fone", "", models.Subscription{ChatID: legacyChatID, TargetPrice: money.BRL(15000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := db.CountLegacySubscriptions(); err != nil || count != 1 {
+		t.Fatalf("CountLegacySubscriptions = %d, %v; esperado 1", count, err)
+	}
+	// O chat legado não recebe alertas
+	if subs, _ := db.GetProductSubscriptions(productID); len(subs) != 0 {
+		t.Errorf("inscrições do produto = %+v, esperado nenhuma antes da atribuição", subs)
+	}
+
+	assigned, err := db.AssignLegacySubscriptions(10)
+	if err != nil || assigned != 1 {
+		t.Fatalf("AssignLegacySubscriptions = %d, %v; esperado 1", assigned, err)
+	}
+	if count, _ := db.CountLegacySubscriptions(); count != 0 {
+		t.Errorf("%d inscrições legadas depois da atribuição, esperado nenhuma", count)
+	}
+	subs, err := db.GetChatSubscriptions(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].ProductID != productID || subs[0].TargetPrice.Cents != 15000 {
+		t.Errorf("inscrições do chat 10 = %+v, esperado a inscrição legada com o alvo mantido", subs)
+	}
+
+	// Uma segunda atribuição não encontra inscrições legadas
+	if assigned, err := db.AssignLegacySubscriptions(20); err != nil || assigned != 0 {
+		t.Errorf("segunda AssignLegacySubscriptions = %d, %v; esperado 0", assigned, err)
+	}
+}
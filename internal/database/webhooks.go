@@ -0,0 +1,36 @@
+package database
+
+import (
+	"bot-produtos/internal/models"
+)
+
+// initWebhookDeliveries cria a tabela com o registro de entregas de webhooks
+func (db *DB) initWebhookDeliveries() error {
+	createDeliveriesSQL := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER,
+		url TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER,
+		success BOOLEAN NOT NULL,
+		error TEXT,
+		payload TEXT,
+		duration_ms INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_product ON webhook_deliveries (product_id, created_at);
+	`
+
+	_, err := db.conn.Exec(createDeliveriesSQL)
+	return err
+}
+
+// LogWebhookDelivery registra uma tentativa de entrega de webhook
+func (db *DB) LogWebhookDelivery(delivery models.WebhookDelivery) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO webhook_deliveries (product_id, url, attempt, status_code, success, error, payload, duration_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.ProductID, delivery.URL, delivery.Attempt, delivery.StatusCode, delivery.Success, delivery.Error, delivery.Payload, delivery.Duration.Milliseconds(),
+	)
+	return err
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"bot-produtos/internal/money"
+)
+
+// Offer é a oferta de um vendedor em uma página de catálogo, onde vários vendedores disputam a venda
+type Offer struct {
+	ItemID        string      // Código do anúncio do vendedor (ex: MLB123456789)
+	Seller        Seller      // Vendedor da oferta
+	Price         money.Money // Preço da oferta
+	OriginalPrice money.Money // Preço original (antes do desconto), zero se não houver
+	Shipping      Shipping    // Frete da oferta, se informado
+}
+
+// Discount retorna o percentual de desconto da oferta (0 se não houver preço original)
+func (o Offer) Discount() float64 {
+	if !o.OriginalPrice.IsPositive() || !o.Price.Less(o.OriginalPrice) {
+		return 0
+	}
+	return o.Price.DiscountFrom(o.OriginalPrice)
+}
+
+// SellerFilter restringe as ofertas consideradas por uma inscrição
+type SellerFilter struct {
+	MinReputation     int  // Reputação mínima do vendedor (1-5), 0 aceita qualquer reputação
+	OfficialStoreOnly bool // Considerar apenas lojas oficiais
+}
+
+// Active indica se o filtro restringe alguma oferta
+func (f SellerFilter) Active() bool {
+	return f.MinReputation > 0 || f.OfficialStoreOnly
+}
+
+// Allows indica se o vendedor atende ao filtro; vendedores sem reputação conhecida não atendem a uma reputação mínima
+func (f SellerFilter) Allows(seller Seller) bool {
+	if f.OfficialStoreOnly && !seller.OfficialStore {
+		return false
+	}
+	return f.MinReputation <= 0 || seller.Reputation >= f.MinReputation
+}
+
+// String descreve o filtro para as mensagens (ex: "loja oficial, reputação amarela ou melhor")
+func (f SellerFilter) String() string {
+	var parts []string
+	if f.OfficialStoreOnly {
+		parts = append(parts, "loja oficial")
+	}
+	if name := ReputationName(f.MinReputation); name != "" {
+		if f.MinReputation == MaxReputation {
+			parts = append(parts, fmt.Sprintf("reputação %s", name))
+		} else {
+			parts = append(parts, fmt.Sprintf("reputação %s ou melhor", name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BestOffer retorna a oferta mais barata entre as que atendem ao filtro; no empate, a com frete grátis
+func BestOffer(offers []Offer, filter SellerFilter) (Offer, bool) {
+	var best Offer
+	found := false
+	for _, offer := range offers {
+		if !offer.Price.IsPositive() || !filter.Allows(offer.Seller) {
+			continue
+		}
+		if !found || offer.Price.Less(best.Price) || (offer.Price == best.Price && offer.Shipping.Free && !best.Shipping.Free) {
+			best = offer
+			found = true
+		}
+	}
+	return best, found
+}
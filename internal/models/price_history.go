@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"bot-produtos/internal/money"
+)
+
+// Status possíveis de uma observação de preço
+const (
+	ScrapeStatusOK    = "ok"
+	ScrapeStatusError = "error"
+)
+
+// PriceHistory representa uma observação de preço registrada em uma verificação
+type PriceHistory struct {
+	ID            int64
+	ProductID     int64
+	CheckedAt     time.Time
+	CurrentPrice  money.Money
+	OriginalPrice money.Money
+	Discount      float64
+	Availability  string // Valores de scraper.Availability; vazio se a página não informar
+	StockQuantity int
+	Status        string // ScrapeStatusOK ou ScrapeStatusError
+	Error         string // Mensagem de erro quando Status é ScrapeStatusError
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+
+	"bot-produtos/internal/money"
+)
+
+// Installments descreve o parcelamento exibido pela loja (ex: "10x de R$ 129,90 sem juros")
+type Installments struct {
+	Count        int         // Número de parcelas, 0 se a loja não informar
+	Value        money.Money // Valor de cada parcela
+	InterestFree bool        // Parcelamento sem juros
+}
+
+// Known indica se o parcelamento foi informado pela loja
+func (i Installments) Known() bool {
+	return i.Count > 0 && i.Value.IsPositive()
+}
+
+// Total retorna o valor total pago no parcelamento
+func (i Installments) Total() money.Money {
+	return money.New(i.Value.Cents*int64(i.Count), i.Value.Currency)
+}
+
+// String formata o parcelamento (ex: "10x de R$ 129,90 sem juros")
+func (i Installments) String() string {
+	if !i.Known() {
+		return ""
+	}
+	text := fmt.Sprintf("%dx de %s", i.Count, i.Value)
+	if i.InterestFree {
+		return text + " sem juros"
+	}
+	return text + " com juros"
+}
+
+// PriceBasis indica a qual preço o alvo de uma inscrição se aplica
+type PriceBasis string
+
+const (
+	PriceBasisLowest      PriceBasis = ""            // Menor preço pago (à vista quando a loja diferencia)
+	PriceBasisCash        PriceBasis = "cash"        // Preço à vista no Pix/boleto
+	PriceBasisCard        PriceBasis = "card"        // Preço no cartão (ou total parcelado)
+	PriceBasisInstallment PriceBasis = "installment" // Valor de cada parcela
+)
+
+// Label descreve a base de preço para as mensagens
+func (b PriceBasis) Label() string {
+	switch b {
+	case PriceBasisCash:
+		return "à vista"
+	case PriceBasisCard:
+		return "no cartão"
+	case PriceBasisInstallment:
+		return "da parcela"
+	}
+	return "menor preço"
+}
+
+// Select retorna o preço da base entre os informados pela loja
+// Se a loja não informar o preço dessa base, usa current (o menor preço pago)
+func (b PriceBasis) Select(current, cash, card money.Money, installments Installments) money.Money {
+	switch b {
+	case PriceBasisCash:
+		if cash.IsPositive() {
+			return cash
+		}
+	case PriceBasisCard:
+		if card.IsPositive() {
+			return card
+		}
+		if installments.Known() {
+			return installments.Total()
+		}
+	case PriceBasisInstallment:
+		if installments.Known() {
+			return installments.Value
+		}
+	}
+	return current
+}
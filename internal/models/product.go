@@ -1,19 +1,37 @@
 package models
 
-import "time"
+import (
+	"time"
 
-// Product representa um produto sendo monitorado
+	"bot-produtos/internal/money"
+)
+
+// Product representa uma página de produto sendo monitorada
+// Os alvos de preço e desconto ficam nas inscrições (Subscription) de cada chat
 type Product struct {
 	ID             int64
 	URL            string
+	Key            string // Chave canônica do produto na loja (ex: ML:MLB50097091), única entre os produtos
+	Variant        string // Atributos da variante monitorada (ex: "Voltagem: 127V"), vazio se o produto não tiver variantes
 	Name           string
-	CurrentPrice   float64
-	OriginalPrice  float64 // Preço original (antes do desconto)
-	Discount       float64 // Percentual de desconto atual do site (0-100)
-	TargetPrice    float64
-	TargetDiscount float64 // Percentual de desconto desejado (0-100)
+	CurrentPrice   money.Money
+	CashPrice      money.Money     // Preço à vista no Pix/boleto, zero se a loja não diferenciar
+	CardPrice      money.Money     // Preço no cartão, zero se a loja não diferenciar
+	Installments   Installments    // Parcelamento exibido pela loja
+	Coupon         Coupon          // Melhor cupom ativo na última verificação
+	Deal           Deal            // Selo promocional da última verificação (ex: "Oferta do dia")
+	OriginalPrice  money.Money     // Preço original (antes do desconto), zero se não houver
+	Discount       float64         // Percentual de desconto atual do site (0-100)
+	Availability   string          // Disponibilidade da última verificação (valores de scraper.Availability)
+	StockQuantity  int             // Unidades disponíveis exibidas pela loja, 0 se não informadas
+	Offers         []Offer         // Ofertas dos vendedores na última verificação (preenchido por GetProductOffers)
+	CheckIntervals []time.Duration // Intervalos pedidos pelas inscrições ativas (0 usa o intervalo global), preenchidos por GetActiveProducts
 	LastChecked    time.Time
 	Active         bool
 	CreatedAt      time.Time
 }
 
+// PriceFor retorna o preço do produto na base informada
+func (p Product) PriceFor(basis PriceBasis) money.Money {
+	return basis.Select(p.CurrentPrice, p.CashPrice, p.CardPrice, p.Installments)
+}
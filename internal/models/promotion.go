@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+)
+
+// Coupon descreve um cupom de desconto ativo na página (ex: "Cupom de R$ 50" ou "Cupom de 10% OFF")
+type Coupon struct {
+	Amount      money.Money // Desconto fixo, zero quando o cupom é percentual
+	Percent     float64     // Desconto percentual (0-100), 0 quando o cupom é de valor fixo
+	MaxDiscount money.Money // Limite do desconto de um cupom percentual, zero se não houver
+}
+
+// Known indica se o cupom tem um valor ou percentual
+func (c Coupon) Known() bool {
+	return c.Amount.IsPositive() || c.Percent > 0
+}
+
+// Apply retorna o preço depois de aplicar o cupom (nunca negativo)
+func (c Coupon) Apply(price money.Money) money.Money {
+	discount := c.Amount
+	if c.Percent > 0 {
+		discount = money.New(int64(math.Round(float64(price.Cents)*c.Percent/100)), price.Currency)
+		if c.MaxDiscount.IsPositive() && c.MaxDiscount.Less(discount) {
+			discount = c.MaxDiscount
+		}
+	}
+	if price.Less(discount) {
+		return money.New(0, price.Currency)
+	}
+	return price.Sub(discount)
+}
+
+// String formata o cupom (ex: "R$ 50,00 OFF" ou "10% OFF (máximo R$ 100,00)")
+func (c Coupon) String() string {
+	if c.Percent > 0 {
+		text := strings.ReplaceAll(strconv.FormatFloat(c.Percent, 'f', -1, 64), ".", ",") + "% OFF"
+		if c.MaxDiscount.IsPositive() {
+			text += fmt.Sprintf(" (máximo %s)", c.MaxDiscount)
+		}
+		return text
+	}
+	if c.Amount.IsPositive() {
+		return fmt.Sprintf("%s OFF", c.Amount)
+	}
+	return ""
+}
+
+// BestCoupon retorna o cupom que deixa o preço mais baixo, se houver
+func BestCoupon(coupons []Coupon, price money.Money) (Coupon, bool) {
+	var best Coupon
+	found := false
+	for _, coupon := range coupons {
+		if !coupon.Known() {
+			continue
+		}
+		if !found || coupon.Apply(price).Less(best.Apply(price)) {
+			best = coupon
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Deal descreve um selo promocional da página (ex: "Oferta do dia", "Oferta relâmpago")
+type Deal struct {
+	Badge  string    // Texto do selo, vazio se não houver
+	EndsAt time.Time // Fim da oferta informado pela contagem regressiva, zero se desconhecido
+}
+
+// Active indica se a página exibe um selo promocional
+func (d Deal) Active() bool {
+	return d.Badge != ""
+}
+
+// String formata o selo com o horário de término (ex: "Oferta relâmpago (termina 17/10 18:30)")
+func (d Deal) String() string {
+	if d.Badge == "" {
+		return ""
+	}
+	if d.EndsAt.IsZero() {
+		return d.Badge
+	}
+	return fmt.Sprintf("%s (termina %s)", d.Badge, d.EndsAt.Local().Format("02/01 15:04"))
+}
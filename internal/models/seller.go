@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Seller descreve o vendedor do anúncio exibido na página do produto
+type Seller struct {
+	ID            int64  // Código do vendedor na loja, 0 se desconhecido
+	Name          string // Nome ou apelido exibido, vazio se desconhecido
+	OfficialStore bool   // Loja oficial da marca
+	Reputation    int    // Nível de reputação de 1 (vermelho) a MaxReputation (verde), 0 se desconhecido
+}
+
+// MaxReputation é o nível mais alto de reputação (verde no termômetro do Mercado Livre)
+const MaxReputation = 5
+
+// reputationNames são as cores do termômetro de reputação, do nível 1 ao 5
+var reputationNames = []string{"vermelha", "laranja", "amarela", "verde-clara", "verde"}
+
+// ReputationName descreve o nível de reputação (ex: "verde"), vazio se desconhecido
+func ReputationName(level int) string {
+	if level < 1 || level > MaxReputation {
+		return ""
+	}
+	return reputationNames[level-1]
+}
+
+// ParseReputation lê um nível de reputação pelo número (1-5) ou pela cor (ex: "verde", "amarela")
+func ParseReputation(text string) (int, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if level, err := strconv.Atoi(text); err == nil {
+		return level, level >= 1 && level <= MaxReputation
+	}
+	for i, name := range reputationNames {
+		if text == name || text == strings.TrimSuffix(name, "a")+"o" || text == strings.ReplaceAll(name, "-", "") {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Known indica se a loja informou o vendedor
+func (s Seller) Known() bool {
+	return s.ID != 0 || s.Name != ""
+}
+
+// String descreve o vendedor para as mensagens (ex: "LOJA_X (loja oficial, reputação verde)")
+func (s Seller) String() string {
+	name := s.Name
+	if name == "" {
+		if !s.Known() {
+			return ""
+		}
+		name = fmt.Sprintf("vendedor %d", s.ID)
+	}
+	var notes []string
+	if s.OfficialStore {
+		notes = append(notes, "loja oficial")
+	}
+	if reputation := ReputationName(s.Reputation); reputation != "" {
+		notes = append(notes, "reputação "+reputation)
+	}
+	if len(notes) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(notes, ", ") + ")"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "bot-produtos/internal/money"
+
+// Shipping descreve o frete de um produto, lido da página ou cotado para um CEP
+type Shipping struct {
+	Cost money.Money // Valor do frete, zero se grátis ou desconhecido
+	Free bool        // Frete grátis
+	CEP  string      // CEP da cotação, vazio quando o valor vem da página sem CEP definido
+}
+
+// Known indica se o frete é conhecido (grátis ou com valor)
+func (s Shipping) Known() bool {
+	return s.Free || s.Cost.IsPositive()
+}
+
+// FormatCEP formata um CEP de 8 dígitos como "01001-000"
+func FormatCEP(cep string) string {
+	if len(cep) != 8 {
+		return cep
+	}
+	return cep[:5] + "-" + cep[5:]
+}
+
+// Total retorna o preço somado ao frete; com frete grátis ou desconhecido, retorna o próprio preço
+func (s Shipping) Total(price money.Money) money.Money {
+	if s.Free {
+		return price
+	}
+	return price.Add(s.Cost)
+}
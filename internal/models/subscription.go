@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"bot-produtos/internal/money"
+)
+
+// Subscription representa o acompanhamento de um produto por um chat
+// Vários chats podem acompanhar o mesmo produto, cada um com seus próprios alvos
+type Subscription struct {
+	ID             int64
+	ProductID      int64
+	ChatID         int64
+	TargetPrice    money.Money   // Zero quando o alvo é um desconto
+	TargetDiscount float64       // Percentual de desconto desejado (0-100)
+	NotifyInStock  bool          // Avisar quando o produto voltar ao estoque
+	WithShipping   bool          // Comparar os alvos com o preço somado ao frete para o CEP do chat
+	PriceBasis     PriceBasis    // Preço ao qual o alvo se aplica (à vista, cartão, parcela...)
+	WithCoupon     bool          // Comparar o alvo de preço com o preço depois do cupom ativo
+	SellerFilter   SellerFilter  // Vendedores considerados em páginas com várias ofertas (reputação, loja oficial)
+	CheckInterval  time.Duration // Intervalo entre verificações pedido pelo chat (0 usa o intervalo global)
+
+	TargetInstallments  int         // Mínimo de parcelas sem juros desejado, 0 se não usado
+	MaxInstallmentValue money.Money // Valor máximo de cada parcela no alvo de parcelamento, zero se não houver
+	Active              bool
+	CreatedAt           time.Time
+	Product             Product // Preenchido pelas consultas que juntam os dados do produto
+}
+
+// ComparedPrice retorna o preço comparado com o alvo de preço: o preço da base escolhida,
+// depois do cupom (WithCoupon) e somado ao frete (WithShipping); o valor da parcela é comparado sem ajustes
+func (s Subscription) ComparedPrice(basisPrice money.Money, coupon Coupon, shipping Shipping) money.Money {
+	if s.PriceBasis == PriceBasisInstallment {
+		return basisPrice
+	}
+	price := basisPrice
+	if s.WithCoupon && coupon.Known() {
+		price = coupon.Apply(price)
+	}
+	if s.WithShipping {
+		price = shipping.Total(price)
+	}
+	return price
+}
+
+// InstallmentsMet indica se o parcelamento atinge o alvo de parcelamento da inscrição
+// (ao menos TargetInstallments parcelas sem juros e, se definido, parcela de até MaxInstallmentValue)
+func (s Subscription) InstallmentsMet(installments Installments) bool {
+	if s.TargetInstallments <= 0 || !installments.Known() || !installments.InterestFree {
+		return false
+	}
+	if installments.Count < s.TargetInstallments {
+		return false
+	}
+	return !s.MaxInstallmentValue.IsPositive() || !s.MaxInstallmentValue.Less(installments.Value)
+}
+
+// InstallmentsTarget descreve o alvo de parcelamento (ex: "12x sem juros, parcela de até R$ 200,00")
+func (s Subscription) InstallmentsTarget() string {
+	if s.TargetInstallments <= 0 {
+		return ""
+	}
+	target := fmt.Sprintf("%dx sem juros", s.TargetInstallments)
+	if s.MaxInstallmentValue.IsPositive() {
+		target += fmt.Sprintf(", parcela de até %s", s.MaxInstallmentValue)
+	}
+	return target
+}
@@ -0,0 +1,11 @@
+package models
+
+import "bot-produtos/internal/money"
+
+// Variant descreve uma variante do produto (ex: voltagem, cor ou tamanho), que pode ter preço próprio
+type Variant struct {
+	ID    string      // Código da variante na loja (produto de catálogo ou variação do anúncio)
+	Label string      // Atributos da variante (ex: "Voltagem: 127V, Cor: Preto")
+	URL   string      // URL que seleciona a variante; é a URL monitorada quando a variante é escolhida
+	Price money.Money // Preço da variante, zero se a loja não informar sem abrir a variante
+}
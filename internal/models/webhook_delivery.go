@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WebhookDelivery registra uma tentativa de entrega de alerta para um webhook
+type WebhookDelivery struct {
+	ID         int64
+	ProductID  int64
+	URL        string
+	Attempt    int // Número da tentativa, começando em 1
+	StatusCode int // Status HTTP da resposta (0 se a requisição falhou)
+	Success    bool
+	Error      string
+	Payload    string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
@@ -0,0 +1,133 @@
+package money
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency é a moeda usada quando nenhuma é informada
+const DefaultCurrency = "BRL"
+
+// Money representa um valor monetário em centavos, sem os erros de arredondamento de float64
+// Operações e comparações assumem que os dois valores estão na mesma moeda
+type Money struct {
+	Cents    int64
+	Currency string // Código ISO 4217 (ex: BRL); vazio é tratado como DefaultCurrency
+}
+
+// New cria um valor a partir de centavos
+func New(cents int64, currency string) Money {
+	return Money{Cents: cents, Currency: normalizeCurrency(currency)}
+}
+
+// BRL cria um valor em reais a partir de centavos
+func BRL(cents int64) Money {
+	return Money{Cents: cents, Currency: DefaultCurrency}
+}
+
+// FromFloat converte um valor decimal (ex: números de APIs JSON) arredondando para o centavo mais próximo
+func FromFloat(value float64, currency string) Money {
+	return New(int64(math.Round(value*100)), currency)
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// CurrencyCode retorna o código da moeda, com DefaultCurrency para valores sem moeda
+func (m Money) CurrencyCode() string {
+	return normalizeCurrency(m.Currency)
+}
+
+// Float64 retorna o valor em unidades da moeda (ex: 1299.9), para gráficos e payloads JSON
+func (m Money) Float64() float64 {
+	return float64(m.Cents) / 100
+}
+
+// IsZero indica se o valor é zero (também usado para "preço desconhecido")
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// IsPositive indica se o valor é maior que zero
+func (m Money) IsPositive() bool {
+	return m.Cents > 0
+}
+
+// Add soma dois valores
+func (m Money) Add(other Money) Money {
+	return Money{Cents: m.Cents + other.Cents, Currency: m.Currency}
+}
+
+// Sub subtrai other do valor
+func (m Money) Sub(other Money) Money {
+	return Money{Cents: m.Cents - other.Cents, Currency: m.Currency}
+}
+
+// Less indica se o valor é menor que other
+func (m Money) Less(other Money) bool {
+	return m.Cents < other.Cents
+}
+
+// DiscountFrom calcula o percentual de desconto do valor em relação a original (ex: 17.5 para 17,5%)
+// Retorna 0 se original não for maior que o valor
+func (m Money) DiscountFrom(original Money) float64 {
+	if original.Cents <= 0 || m.Cents >= original.Cents {
+		return 0
+	}
+	return float64(original.Cents-m.Cents) / float64(original.Cents) * 100
+}
+
+// String formata o valor como nas mensagens do bot (ex: "R$ 1.299,90")
+func (m Money) String() string {
+	return m.Format(PtBR)
+}
+
+// Format formata o valor com o símbolo da moeda e os separadores do locale
+func (m Money) Format(locale Locale) string {
+	return symbolFor(m.CurrencyCode()) + " " + m.FormatAmount(locale)
+}
+
+// FormatAmount formata apenas o número, sem a moeda (ex: "1.299,90")
+func (m Money) FormatAmount(locale Locale) string {
+	cents := m.Cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	whole := strconv.FormatInt(cents/100, 10)
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(locale.Thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	fraction := cents % 100
+	return sign + grouped.String() + locale.Decimal + strconv.FormatInt(fraction/10, 10) + strconv.FormatInt(fraction%10, 10)
+}
+
+// symbolFor retorna o símbolo usado para a moeda
+func symbolFor(currency string) string {
+	switch currency {
+	case "BRL":
+		return "R$"
+	case "USD":
+		return "US$"
+	case "EUR":
+		return "€"
+	case "GBP":
+		return "£"
+	case "ARS":
+		return "AR$"
+	}
+	return currency
+}
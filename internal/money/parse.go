@@ -0,0 +1,182 @@
+package money
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Locale define os separadores de milhar e de decimais de um formato de número
+type Locale struct {
+	Name      string
+	Thousands string
+	Decimal   string
+}
+
+var (
+	PtBR = Locale{Name: "pt-BR", Thousands: ".", Decimal: ","} // 1.299,90
+	EnUS = Locale{Name: "en-US", Thousands: ",", Decimal: "."} // 1,299.90
+	DeDE = Locale{Name: "de-DE", Thousands: ".", Decimal: ","} // 1.299,90
+	EsES = Locale{Name: "es-ES", Thousands: ".", Decimal: ","} // 1.299,90
+	FrFR = Locale{Name: "fr-FR", Thousands: " ", Decimal: ","} // 1 299,90
+	DeCH = Locale{Name: "de-CH", Thousands: "'", Decimal: "."} // 1'299.90
+)
+
+var locales = []Locale{PtBR, EnUS, DeDE, EsES, FrFR, DeCH}
+
+// LookupLocale encontra um locale pelo nome, aceitando variações como "pt_BR", "pt-br" ou "pt"
+func LookupLocale(name string) (Locale, bool) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+	for _, locale := range locales {
+		full := strings.ToLower(locale.Name)
+		if name == full || name == strings.SplitN(full, "-", 2)[0] {
+			return locale, true
+		}
+	}
+	return Locale{}, false
+}
+
+// numberRe encontra o primeiro número do texto; separadores só contam entre dígitos
+var numberRe = regexp.MustCompile(`\d(?:[\d.,' \x{a0}\x{202f}]*\d)?`)
+
+// Parse interpreta um preço escrito no locale informado e retorna o valor na moeda indicada
+// Aceita símbolos e textos ao redor (ex: "R$ 1.299,90", "US$ 1,299.90", "1299.9", "por 1.299")
+func Parse(text string, locale Locale, currency string) (Money, error) {
+	cents, err := ParseCents(text, locale)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(cents, currency), nil
+}
+
+// ParseCents interpreta um preço e retorna o valor em centavos
+//
+// Quando o texto tem os dois separadores, o último é o decimal, independente do locale.
+// Com um só separador, ele é decimal se aparecer uma vez sem ser seguido de exatamente
+// três dígitos ("1299.9", "1.299,90"); é milhar se aparecer mais de uma vez ("1.299.000").
+// O caso ambíguo ("1.299" ou "1,299") é resolvido pelo locale.
+// Um sinal de menos logo antes do número ("-10,00", "R$ -10,00") torna o valor negativo.
+func ParseCents(text string, locale Locale) (int64, error) {
+	number, negative := findNumber(text)
+	if number == "" {
+		return 0, fmt.Errorf("nenhum número encontrado em %q", text)
+	}
+
+	// Espaços só separam milhares em locales que os usam; fora deles, encerram o número
+	if locale.Thousands != " " {
+		number = strings.FieldsFunc(number, isSpace)[0]
+	} else {
+		number = strings.Map(func(r rune) rune {
+			if isSpace(r) {
+				return -1
+			}
+			return r
+		}, number)
+	}
+	number = strings.ReplaceAll(number, "'", "")
+
+	lastDot := strings.LastIndex(number, ".")
+	lastComma := strings.LastIndex(number, ",")
+
+	decimalIndex := -1
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalIndex = max(lastDot, lastComma)
+	case lastDot >= 0 || lastComma >= 0:
+		sep := "."
+		if lastComma >= 0 {
+			sep = ","
+		}
+		index := strings.LastIndex(number, sep)
+		digitsAfter := len(number) - index - 1
+		switch {
+		case strings.Count(number, sep) > 1:
+			decimalIndex = -1
+		case digitsAfter != 3:
+			decimalIndex = index
+		case sep == locale.Decimal:
+			decimalIndex = index
+		}
+	}
+
+	whole, fraction := number, ""
+	if decimalIndex >= 0 {
+		whole, fraction = number[:decimalIndex], number[decimalIndex+1:]
+	}
+	cents, err := combine(whole, fraction)
+	if negative {
+		cents = -cents
+	}
+	return cents, err
+}
+
+// ParseSplit interpreta preços exibidos em partes separadas, como as spans
+// andes-money-amount__fraction ("1.299") e andes-money-amount__cents ("90") do Mercado Livre
+// Todos os separadores da parte inteira são tratados como separadores de milhar.
+func ParseSplit(whole, cents string) (int64, error) {
+	whole = strings.TrimSpace(whole)
+	cents = strings.TrimSpace(cents)
+	if whole == "" {
+		return 0, fmt.Errorf("parte inteira do preço vazia")
+	}
+	if strings.IndexFunc(cents, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return 0, fmt.Errorf("centavos inválidos %q", cents)
+	}
+
+	number, negative := findNumber(whole)
+	if number == "" {
+		return 0, fmt.Errorf("nenhum número encontrado em %q", whole)
+	}
+	value, err := combine(number, cents)
+	if negative {
+		value = -value
+	}
+	return value, err
+}
+
+// findNumber retorna o primeiro número do texto e se ele vem precedido de um sinal de menos
+func findNumber(text string) (string, bool) {
+	loc := numberRe.FindStringIndex(text)
+	if loc == nil {
+		return "", false
+	}
+	before := text[:loc[0]]
+	negative := strings.HasSuffix(before, "-") || strings.HasSuffix(before, "\u2212")
+	return text[loc[0]:loc[1]], negative
+}
+
+// combine junta a parte inteira (separadores de milhar são descartados) e a decimal,
+// arredondando casas além dos centavos (ex: "1299.905" -> 129991)
+func combine(whole, fraction string) (int64, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, whole)
+	if digits == "" {
+		digits = "0"
+	}
+	if len(digits) > 15 {
+		return 0, fmt.Errorf("valor muito grande: %s", whole)
+	}
+
+	var cents int64
+	for _, r := range digits {
+		cents = cents*10 + int64(r-'0')
+	}
+	cents *= 100
+
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	cents += int64(fraction[0]-'0')*10 + int64(fraction[1]-'0')
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		cents++
+	}
+	return cents, nil
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u202f'
+}
@@ -0,0 +1,123 @@
+package money
+
+import "testing"
+
+func TestParseCents(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		locale Locale
+		want   int64
+	}{
+		{"pt-BR com símbolo", "R$ 1.299,90", PtBR, 129990},
+		{"pt-BR sem milhar", "R$ 49,9", PtBR, 4990},
+		{"en-US", "1,299.90", EnUS, 129990},
+		{"de-CH", "1'299.90", DeCH, 129990},
+		{"fr-FR com espaço", "1 299,90 €", FrFR, 129990},
+		{"fr-FR com espaço inseparável", "1\u00a0299,90", FrFR, 129990},
+		{"código da moeda antes", "CHF 1'299.90", DeCH, 129990},
+		{"código USD antes", "USD 1,299.90", EnUS, 129990},
+		{"símbolo US$ em pt-BR", "US$ 1,299.90", PtBR, 129990},
+		{"símbolo do euro", "€ 1.299,90", DeDE, 129990},
+		{"texto ao redor", "por 1.299 à vista", PtBR, 129900},
+		{"ponto decimal em pt-BR", "1299.90", PtBR, 129990},
+		{"uma casa decimal", "1299.9", PtBR, 129990},
+		{"vírgula decimal em en-US", "1299,90", EnUS, 129990},
+		{"sem separador", "1299", PtBR, 129900},
+		{"milhar repetido", "1.299.000", PtBR, 129900000},
+		{"ambíguo com ponto em pt-BR", "1.299", PtBR, 129900},
+		{"ambíguo com ponto em en-US", "1.299", EnUS, 130},
+		{"ambíguo com vírgula em en-US", "1,299", EnUS, 129900},
+		{"ambíguo com vírgula em pt-BR", "1,299", PtBR, 130},
+		{"três casas decimais arredondadas", "1299,905", PtBR, 129991},
+		{"espaço encerra o número fora do fr-FR", "12 x 99,90", PtBR, 1200},
+		{"negativo", "-10,00", PtBR, -1000},
+		{"negativo depois do símbolo", "R$ -1.299,90", PtBR, -129990},
+		{"hífen longe do número", "- R$ 10,00", PtBR, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCents(tt.text, tt.locale)
+			if err != nil {
+				t.Fatalf("ParseCents(%q, %s) retornou erro: %v", tt.text, tt.locale.Name, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCents(%q, %s) = %d, esperado %d", tt.text, tt.locale.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCentsInvalid(t *testing.T) {
+	for _, text := range []string{"", "   ", "R$", "grátis", "1234567890123456"} {
+		if got, err := ParseCents(text, PtBR); err == nil {
+			t.Errorf("ParseCents(%q) = %d, esperado erro", text, got)
+		}
+	}
+}
+
+func TestParseSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		whole string
+		cents string
+		want  int64
+	}{
+		{"milhar com ponto", "1.299", "90", 129990},
+		{"milhar com vírgula", "1,299", "90", 129990},
+		{"sem centavos", "1.299", "", 129900},
+		{"uma casa nos centavos", "49", "9", 4990},
+		{"espaços ao redor", " 1.299 ", " 90 ", 129990},
+		{"com símbolo", "R$ 1.299", "90", 129990},
+		{"milhões", "1.299.000", "00", 129900000},
+		{"negativo", "-1.299", "90", -129990},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSplit(tt.whole, tt.cents)
+			if err != nil {
+				t.Fatalf("ParseSplit(%q, %q) retornou erro: %v", tt.whole, tt.cents, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSplit(%q, %q) = %d, esperado %d", tt.whole, tt.cents, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSplitInvalid(t *testing.T) {
+	tests := []struct {
+		whole string
+		cents string
+	}{
+		{"", "90"},
+		{"  ", ""},
+		{"R$", "90"},
+		{"1.299", "9a"},
+		{"1.299", "-90"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSplit(tt.whole, tt.cents); err == nil {
+			t.Errorf("ParseSplit(%q, %q) = %d, esperado erro", tt.whole, tt.cents, got)
+		}
+	}
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	values := []int64{0, 5, 99, 100, 4990, 129990, 100000, 123456789, -129990}
+	for _, locale := range locales {
+		for _, cents := range values {
+			text := New(cents, DefaultCurrency).Format(locale)
+			got, err := ParseCents(text, locale)
+			if err != nil {
+				t.Errorf("ParseCents(%q, %s) retornou erro: %v", text, locale.Name, err)
+				continue
+			}
+			if got != cents {
+				t.Errorf("ParseCents(%q, %s) = %d, esperado %d", text, locale.Name, got, cents)
+			}
+		}
+	}
+}
@@ -1,214 +1,579 @@
 package monitor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
+	"sync"
 	"time"
 
 	"bot-produtos/internal/database"
 	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+	"bot-produtos/internal/notify"
 	"bot-produtos/internal/scraper"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// syncInterval é o tempo máximo de espera antes de buscar produtos novos ou alterados no banco
+const syncInterval = time.Minute
+
+// Options configura o ritmo das verificações
+type Options struct {
+	Interval    time.Duration // Intervalo padrão entre verificações de um produto
+	Concurrency int           // Número de produtos verificados em paralelo
+}
+
+// scrapers é a parte do scraper.Registry usada pelo monitor
+type scrapers interface {
+	FindScraper(url string) scraper.Scraper
+	QuoteShipping(ctx context.Context, url, cep string) (models.Shipping, error)
+}
+
 // Monitor gerencia o monitoramento periódico de produtos
 type Monitor struct {
-	db       *database.DB
-	bot      *tgbotapi.BotAPI
-	registry *scraper.Registry
-	interval time.Duration
+	db          *database.DB
+	notifiers   []notify.Notifier
+	registry    scrapers
+	interval    time.Duration
+	concurrency int
+	scheduler   *scheduler
+	wake        chan struct{}
+
+	// checkCtx é usado pelas verificações em andamento; só é cancelado quando o prazo do Shutdown expira
+	checkCtx     context.Context
+	cancelChecks context.CancelFunc
+	done         chan struct{}
 }
 
 // New cria uma nova instância do monitor
-func New(db *database.DB, bot *tgbotapi.BotAPI, registry *scraper.Registry, interval time.Duration) *Monitor {
+// Os alertas são enviados para todos os notificadores informados
+func New(db *database.DB, notifiers []notify.Notifier, registry *scraper.Registry, opts Options) *Monitor {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	checkCtx, cancelChecks := context.WithCancel(context.Background())
 	return &Monitor{
-		db:       db,
-		bot:      bot,
-		registry: registry,
-		interval: interval,
+		db:          db,
+		notifiers:   notifiers,
+		registry:    registry,
+		interval:    opts.Interval,
+		concurrency: opts.Concurrency,
+		scheduler:   newScheduler(opts.Interval),
+		wake:        make(chan struct{}, 1),
+
+		checkCtx:     checkCtx,
+		cancelChecks: cancelChecks,
+		done:         make(chan struct{}),
+	}
+}
+
+// Start inicia o monitoramento e bloqueia até o contexto ser cancelado
+// Cada produto é verificado no seu próprio intervalo, na ordem do próximo vencimento
+func (m *Monitor) Start(ctx context.Context) {
+	defer close(m.done)
+	log.Printf("Monitor iniciado. Intervalo padrão de %v com %d verificações simultâneas", m.interval, m.concurrency)
+
+	for {
+		products, err := m.db.GetActiveProducts()
+		if err != nil {
+			log.Printf("Erro ao buscar produtos: %v", err)
+		} else {
+			m.scheduler.Sync(products, time.Now())
+			m.checkDueProducts(ctx, products)
+		}
+
+		// Dormir até o próximo vencimento, uma alteração de produto ou a próxima sincronização
+		wait := syncInterval
+		if next, ok := m.scheduler.Next(); ok {
+			if until := time.Until(next); until < wait {
+				wait = until
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Monitor encerrado")
+			return
+		case <-timer.C:
+		case <-m.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// Shutdown aguarda o fim das verificações em andamento depois que o contexto de Start é cancelado
+// Se o prazo de ctx expirar antes, as requisições pendentes são canceladas
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	defer m.cancelChecks()
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		log.Println("Prazo de encerramento expirado, cancelando verificações em andamento")
+		m.cancelChecks()
+		<-m.done
+		return ctx.Err()
+	}
+}
+
+// Wake faz o monitor reler os produtos e seus intervalos imediatamente
+// Usado após adicionar um produto ou alterar seu intervalo
+func (m *Monitor) Wake() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
 	}
 }
 
-// Start inicia o monitoramento em background
-func (m *Monitor) Start() {
-	log.Printf("Monitor iniciado. Verificando produtos a cada %v", m.interval)
+// checkDueProducts verifica os produtos com verificação vencida e os reagenda
+func (m *Monitor) checkDueProducts(ctx context.Context, products []models.Product) {
+	dueIDs := m.scheduler.PopDue(time.Now())
+	if len(dueIDs) == 0 {
+		return
+	}
+
+	byID := make(map[int64]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
 
-	// Verificar imediatamente na primeira execução
-	m.checkAllProducts()
+	due := make([]models.Product, 0, len(dueIDs))
+	for _, id := range dueIDs {
+		due = append(due, byID[id])
+	}
 
-	ticker := time.NewTicker(m.interval)
-	defer ticker.Stop()
+	checked := m.checkProducts(ctx, due)
 
-	for range ticker.C {
-		m.checkAllProducts()
+	// Produtos não verificados por causa do encerramento continuam vencidos
+	now := time.Now()
+	for _, product := range due[:checked] {
+		m.scheduler.Reschedule(product, now)
 	}
 }
 
 // CheckProduct verifica um produto específico (usado pelo comando /check)
-// Retorna o preço atual encontrado e um erro se houver
-func (m *Monitor) CheckProduct(product models.Product) (float64, error) {
+// Retorna o snapshot extraído da página e um erro se houver
+func (m *Monitor) CheckProduct(ctx context.Context, product models.Product) (scraper.ProductSnapshot, error) {
+	// Não verificar promoções aqui, apenas atualizar o preço
+	return m.refreshProduct(ctx, product)
+}
+
+// refreshProduct baixa a página do produto uma única vez e atualiza os preços no banco
+func (m *Monitor) refreshProduct(ctx context.Context, product models.Product) (scraper.ProductSnapshot, error) {
 	// Encontrar scraper apropriado
-	scraper := m.registry.FindScraper(product.URL)
-	if scraper == nil {
-		return 0, fmt.Errorf("nenhum scraper encontrado para URL: %s", product.URL)
+	s := m.registry.FindScraper(product.URL)
+	if s == nil {
+		return scraper.ProductSnapshot{}, fmt.Errorf("nenhum scraper encontrado para URL: %s", product.URL)
 	}
 
-	// Buscar preço atual, original e desconto
-	currentPrice, err := scraper.GetPrice(product.URL)
+	// O limite de requisições por loja é aplicado pelos clientes HTTP dos scrapers, a cada requisição
+	snapshot, err := s.Scrape(ctx, product.URL)
+	if snapshot.Extraction.Fallback != "" {
+		log.Printf("Produto %d lido da página: %s", product.ID, snapshot.Extraction.Fallback)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("erro ao buscar preço: %v", err)
+		m.recordHistory(product.ID, snapshot, err)
+		return snapshot, fmt.Errorf("erro ao buscar preço: %v", err)
+	}
+	m.recordHistory(product.ID, snapshot, nil)
+
+	if err := m.db.UpdateProductAvailability(product.ID, string(snapshot.Availability), snapshot.StockQuantity); err != nil {
+		return snapshot, fmt.Errorf("erro ao atualizar disponibilidade no banco: %v", err)
+	}
+	// Verificações sem a lista de ofertas (ex: leitura pelo HTML) mantêm as últimas ofertas conhecidas
+	if len(snapshot.Offers) > 0 {
+		if err := m.db.ReplaceProductOffers(product.ID, snapshot.Offers); err != nil {
+			return snapshot, fmt.Errorf("erro ao atualizar ofertas no banco: %v", err)
+		}
 	}
 
-	originalPrice, _ := scraper.GetOriginalPrice(product.URL)
-	discount, _ := scraper.GetDiscount(product.URL)
+	// Produtos esgotados podem vir sem preço; o último preço conhecido é mantido
+	if !snapshot.CurrentPrice.IsPositive() {
+		return snapshot, nil
+	}
 
-	// Atualizar preços no banco
-	if discount > 0 || originalPrice > 0 {
-		if err := m.db.UpdateProductPricesWithDiscount(product.ID, currentPrice, originalPrice, discount); err != nil {
-			return currentPrice, fmt.Errorf("erro ao atualizar preços no banco: %v", err)
+	// Atualizar preços no banco (sempre atualizar, mesmo se o preço não mudou)
+	if err := m.db.UpdateProductPaymentOptions(product.ID, snapshot.CashPrice, snapshot.CardPrice, snapshot.Installments); err != nil {
+		return snapshot, fmt.Errorf("erro ao atualizar formas de pagamento no banco: %v", err)
+	}
+	coupon, _ := models.BestCoupon(snapshot.Coupons, snapshot.CurrentPrice)
+	if err := m.db.UpdateProductPromotions(product.ID, coupon, snapshot.Deal); err != nil {
+		return snapshot, fmt.Errorf("erro ao atualizar cupons e ofertas no banco: %v", err)
+	}
+	if snapshot.Discount > 0 || snapshot.OriginalPrice.IsPositive() {
+		if err := m.db.UpdateProductPricesWithDiscount(product.ID, snapshot.CurrentPrice, snapshot.OriginalPrice, snapshot.Discount); err != nil {
+			return snapshot, fmt.Errorf("erro ao atualizar preços no banco: %v", err)
 		}
 	} else {
-		if err := m.db.UpdateProductPrice(product.ID, currentPrice); err != nil {
-			return currentPrice, fmt.Errorf("erro ao atualizar preço no banco: %v", err)
+		if err := m.db.UpdateProductPrice(product.ID, snapshot.CurrentPrice); err != nil {
+			return snapshot, fmt.Errorf("erro ao atualizar preço no banco: %v", err)
 		}
 	}
 
-	// Não verificar promoções aqui, apenas atualizar o preço
-	return currentPrice, nil
+	return snapshot, nil
+}
+
+// recordHistory registra a observação no histórico de preços, inclusive quando a verificação falhou
+func (m *Monitor) recordHistory(productID int64, snapshot scraper.ProductSnapshot, scrapeErr error) {
+	entry := models.PriceHistory{
+		ProductID:     productID,
+		CheckedAt:     snapshot.FetchedAt,
+		CurrentPrice:  snapshot.CurrentPrice,
+		OriginalPrice: snapshot.OriginalPrice,
+		Discount:      snapshot.Discount,
+		Availability:  string(snapshot.Availability),
+		StockQuantity: snapshot.StockQuantity,
+		Status:        models.ScrapeStatusOK,
+	}
+	if scrapeErr != nil {
+		entry.Status = models.ScrapeStatusError
+		entry.Error = scrapeErr.Error()
+	}
+
+	if err := m.db.AddPriceHistory(entry); err != nil {
+		log.Printf("Erro ao registrar histórico do produto %d: %v", productID, err)
+	}
+}
+
+// checkProducts verifica os produtos informados usando um pool de workers e retorna quantos foram verificados
+// Lojas diferentes são verificadas em paralelo; cada loja respeita seu próprio limite de requisições.
+// Quando ctx é cancelado, nenhum produto novo é iniciado, mas os que estão em andamento terminam.
+func (m *Monitor) checkProducts(ctx context.Context, products []models.Product) int {
+	if len(products) == 0 {
+		return 0
+	}
+
+	start := time.Now()
+	jobs := make(chan models.Product)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed int
+	var checkTime time.Duration
+
+	for i := 0; i < m.concurrency && i < len(products); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for product := range jobs {
+				productStart := time.Now()
+				err := m.checkProduct(m.checkCtx, product)
+
+				mu.Lock()
+				checkTime += time.Since(productStart)
+				if err != nil {
+					failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	sent := 0
+feed:
+	for _, product := range products {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- product:
+			sent++
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if sent == 0 {
+		return 0
+	}
+
+	elapsed := time.Since(start)
+	log.Printf(
+		"Ciclo concluído: %d produtos em %v (%d ok, %d com erro, média de %v por produto, %d workers)",
+		sent, elapsed.Round(time.Millisecond), sent-failed, failed,
+		(checkTime / time.Duration(sent)).Round(time.Millisecond), m.concurrency,
+	)
+	if sent < len(products) {
+		log.Printf("%d produto(s) não verificado(s) por causa do encerramento", len(products)-sent)
+	}
+	return sent
 }
 
-func (m *Monitor) checkAllProducts() {
-	products, err := m.db.GetActiveProducts()
+// checkProduct verifica um produto e envia os alertas das inscrições cujos alvos foram atingidos
+func (m *Monitor) checkProduct(ctx context.Context, product models.Product) error {
+	// Ofertas da verificação anterior, usadas pelas inscrições com filtro de vendedor
+	offers, err := m.db.GetProductOffers(product.ID)
 	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		return
+		log.Printf("Erro ao buscar ofertas do produto %d: %v", product.ID, err)
+	}
+	product.Offers = offers
+
+	// A página é baixada uma única vez, independente de quantos chats acompanham o produto
+	snapshot, err := m.refreshProduct(ctx, product)
+	if err != nil {
+		log.Printf("Erro ao verificar produto %d (%s): %v", product.ID, product.URL, err)
+		return err
+	}
+
+	subscriptions, err := m.db.GetProductSubscriptions(product.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar inscrições do produto %d: %v", product.ID, err)
+		return err
 	}
 
-	if len(products) > 0 {
-		for _, product := range products {
-			m.checkProduct(product)
-			// Pequeno delay entre requisições para não sobrecarregar
-			time.Sleep(2 * time.Second)
+	// Cotações de frete feitas nesta verificação, por CEP, para não repetir a consulta entre chats
+	quotes := make(map[string]models.Shipping)
+	var alerts []notify.Alert
+	for _, sub := range subscriptions {
+		shipping := snapshot.Shipping
+		if sub.WithShipping {
+			shipping = m.subscriptionShipping(ctx, product, sub.ChatID, snapshot.Shipping, quotes)
+		}
+		alert, shouldNotify := evaluateSubscription(product, sub, snapshot, shipping)
+		if !shouldNotify {
+			continue
 		}
+		m.dispatch(ctx, alert, true)
+		alerts = append(alerts, alert)
+	}
+
+	// Webhooks e e-mail têm destino fixo: recebem um único alerta pelo produto, sem os dados dos chats
+	if len(alerts) > 0 {
+		m.dispatch(ctx, productAlert(product, snapshot, alerts), false)
 	}
+	return nil
 }
 
-func (m *Monitor) checkProduct(product models.Product) {
-	// Encontrar scraper apropriado
-	scraper := m.registry.FindScraper(product.URL)
-	if scraper == nil {
-		log.Printf("Nenhum scraper encontrado para URL: %s", product.URL)
-		return
+// productAlert resume os alertas das inscrições em um único alerta para os notificadores de destino fixo
+// O alerta leva os dados da página e as regras disparadas, mas não a inscrição, os alvos
+// nem o frete cotado para o CEP de algum chat
+func productAlert(product models.Product, snapshot scraper.ProductSnapshot, alerts []notify.Alert) notify.Alert {
+	fired := make(map[notify.Reason]bool)
+	for _, alert := range alerts {
+		fired[alert.Reason] = true
+	}
+	var rules []notify.Reason
+	for _, reason := range notify.Reasons {
+		if fired[reason] {
+			rules = append(rules, reason)
+		}
 	}
 
-	// Buscar preço atual, original e desconto
-	currentPrice, err := scraper.GetPrice(product.URL)
+	coupon, _ := models.BestCoupon(snapshot.Coupons, snapshot.CurrentPrice)
+	return notify.Alert{
+		Product:       product,
+		OldPrice:      product.CurrentPrice,
+		NewPrice:      snapshot.CurrentPrice,
+		OriginalPrice: snapshot.OriginalPrice,
+		Discount:      snapshot.Discount,
+		Availability:  string(snapshot.Availability),
+		StockQuantity: snapshot.StockQuantity,
+		Installments:  snapshot.Installments,
+		Coupon:        coupon,
+		Deal:          snapshot.Deal,
+		Shipping:      snapshot.Shipping,
+		Seller:        snapshot.Seller,
+		Reason:        rules[0],
+		Rules:         rules,
+		Link:          product.URL,
+		CreatedAt:     snapshot.FetchedAt,
+	}
+}
+
+// subscriptionShipping retorna o frete para o CEP do chat, usando o frete da página quando o chat
+// não tem CEP ou a loja não permite cotar
+func (m *Monitor) subscriptionShipping(ctx context.Context, product models.Product, chatID int64, pageShipping models.Shipping, quotes map[string]models.Shipping) models.Shipping {
+	cep, err := m.db.GetChatCEP(chatID)
 	if err != nil {
-		log.Printf("Erro ao buscar preço do produto %d (%s): %v", product.ID, product.URL, err)
-		return
+		log.Printf("Erro ao buscar CEP do chat %d: %v", chatID, err)
+		return pageShipping
+	}
+	if cep == "" {
+		return pageShipping
+	}
+	if quote, ok := quotes[cep]; ok {
+		return quote
 	}
 
-	originalPrice, _ := scraper.GetOriginalPrice(product.URL)
-	discount, _ := scraper.GetDiscount(product.URL)
+	quote := m.QuoteShipping(ctx, product, cep, pageShipping)
+	quotes[cep] = quote
+	return quote
+}
 
-	// Atualizar preços no banco (sempre atualizar, mesmo se o preço não mudou)
-	if discount > 0 || originalPrice > 0 {
-		if err := m.db.UpdateProductPricesWithDiscount(product.ID, currentPrice, originalPrice, discount); err != nil {
-			log.Printf("Erro ao atualizar preços no banco: %v", err)
-			return
+// QuoteShipping cota o frete do produto para o CEP; se a loja não permitir a cotação
+// ou ela falhar, retorna fallback (normalmente o frete exibido na página)
+func (m *Monitor) QuoteShipping(ctx context.Context, product models.Product, cep string, fallback models.Shipping) models.Shipping {
+	quote, err := m.registry.QuoteShipping(ctx, product.URL, cep)
+	if err != nil {
+		if !errors.Is(err, scraper.ErrShippingUnsupported) {
+			log.Printf("Erro ao cotar frete do produto %d para o CEP %s: %v", product.ID, cep, err)
 		}
-	} else {
-		if err := m.db.UpdateProductPrice(product.ID, currentPrice); err != nil {
-			log.Printf("Erro ao atualizar preço no banco: %v", err)
-			return
+		return fallback
+	}
+	return quote
+}
+
+// dispatch envia o alerta para os notificadores por chat (perChat true) ou para os de destino fixo
+func (m *Monitor) dispatch(ctx context.Context, alert notify.Alert, perChat bool) {
+	for _, notifier := range m.notifiers {
+		if notify.IsPerChat(notifier) != perChat {
+			continue
+		}
+		if err := notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Erro ao enviar notificação do produto %d (chat %d) via %T: %v", alert.Product.ID, alert.Subscription.ChatID, notifier, err)
+		} else {
+			log.Printf("Notificação enviada para produto %d (chat %d) via %T", alert.Product.ID, alert.Subscription.ChatID, notifier)
+		}
+	}
+}
+
+// evaluateSubscription verifica se o snapshot atinge os alvos da inscrição
+// product contém os valores anteriores à verificação, usados para evitar notificações repetidas;
+// shipping é o frete mostrado no alerta e, se a inscrição pedir, somado ao preço comparado com o alvo
+func evaluateSubscription(product models.Product, sub models.Subscription, snapshot scraper.ProductSnapshot, shipping models.Shipping) (notify.Alert, bool) {
+	// Em páginas com várias ofertas, o filtro de vendedor da inscrição escolhe a oferta acompanhada
+	if sub.SellerFilter.Active() && len(snapshot.Offers) > 0 {
+		var ok bool
+		product, snapshot, ok = filterOffers(product, snapshot, sub.SellerFilter)
+		if !ok {
+			return notify.Alert{}, false
 		}
+		shipping = snapshot.Shipping
+	}
+
+	currentPrice := snapshot.CurrentPrice
+	originalPrice := snapshot.OriginalPrice
+	discount := snapshot.Discount
+	coupon, _ := models.BestCoupon(snapshot.Coupons, currentPrice)
+
+	alert := notify.Alert{
+		Product:       product,
+		Subscription:  sub,
+		OldPrice:      product.CurrentPrice,
+		NewPrice:      currentPrice,
+		OriginalPrice: originalPrice,
+		Availability:  string(snapshot.Availability),
+		StockQuantity: snapshot.StockQuantity,
+		Installments:  snapshot.Installments,
+		Coupon:        coupon,
+		Deal:          snapshot.Deal,
+		Shipping:      shipping,
+		Seller:        snapshot.Seller,
+		Link:          product.URL,
+		CreatedAt:     snapshot.FetchedAt,
+	}
+
+	// Avisar quando um produto esgotado na verificação anterior volta a ser vendido
+	if sub.NotifyInStock && product.Availability == string(scraper.AvailabilityOutOfStock) && snapshot.Availability.Purchasable() {
+		alert.Reason = notify.ReasonBackInStock
+		return alert, true
+	}
+
+	// Alvos de preço e desconto só valem para produtos à venda e com preço
+	if snapshot.Availability == scraper.AvailabilityOutOfStock || !currentPrice.IsPositive() {
+		return alert, false
 	}
 
 	// Verificar se há promoção
 	shouldNotify := false
-	message := ""
 
 	// Verificar se atingiu preço alvo
-	if product.TargetPrice > 0 && currentPrice <= product.TargetPrice {
+	// O alvo se aplica ao preço da base escolhida na inscrição (à vista, cartão, parcela...),
+	// depois do cupom e com o frete quando a inscrição pedir
+	basisPrice := sub.PriceBasis.Select(currentPrice, snapshot.CashPrice, snapshot.CardPrice, snapshot.Installments)
+	alert.BasisPrice = basisPrice
+	comparedPrice := sub.ComparedPrice(basisPrice, coupon, shipping)
+	// A queda de preço é medida sem o frete e com o cupom de cada verificação,
+	// para que um cupom novo também conte como queda
+	priceNow := sub.ComparedPrice(basisPrice, coupon, models.Shipping{})
+	previousPrice := sub.ComparedPrice(product.PriceFor(sub.PriceBasis), product.Coupon, models.Shipping{})
+	if sub.TargetPrice.IsPositive() && !sub.TargetPrice.Less(comparedPrice) {
 		// Só notificar se o preço mudou (não é a primeira verificação) ou se já está abaixo do alvo
-		if product.CurrentPrice == 0 || currentPrice < product.CurrentPrice {
+		if previousPrice.IsZero() || priceNow.Less(previousPrice) {
 			shouldNotify = true
-			discount := 0.0
-			if product.CurrentPrice > 0 {
-				discount = ((product.CurrentPrice - currentPrice) / product.CurrentPrice) * 100
-			}
-			message = fmt.Sprintf(
-				"🎉 PROMOÇÃO DETECTADA!\n\n"+
-					"Produto: %s\n"+
-					"Preço atual: R$ %.2f\n"+
-					"Preço alvo: R$ %.2f\n",
-				product.Name,
-				currentPrice,
-				product.TargetPrice,
-			)
-			if discount > 0 {
-				message += fmt.Sprintf("Desconto: %.1f%%\n", discount)
-			}
-			message += fmt.Sprintf("\nLink: %s", product.URL)
+			alert.Reason = notify.ReasonTargetPrice
+			alert.Discount = priceNow.DiscountFrom(previousPrice)
 		}
 	}
 
 	// Verificar se atingiu desconto alvo
 	// Para Mercado Livre, usar o desconto do site quando disponível
-	if product.TargetDiscount > 0 {
+	if sub.TargetDiscount > 0 {
 		var currentDiscount float64
-		
+
 		// Se o produto tem desconto do site (Mercado Livre), usar esse valor
 		if discount > 0 {
 			currentDiscount = discount
-		} else if product.CurrentPrice > 0 && currentPrice < product.CurrentPrice {
+		} else if product.CurrentPrice.IsPositive() && currentPrice.Less(product.CurrentPrice) {
 			// Se não tem desconto do site, calcular baseado na mudança de preço
-			currentDiscount = ((product.CurrentPrice - currentPrice) / product.CurrentPrice) * 100
-		} else if originalPrice > 0 && originalPrice > currentPrice {
+			currentDiscount = currentPrice.DiscountFrom(product.CurrentPrice)
+		} else if originalPrice.IsPositive() && currentPrice.Less(originalPrice) {
 			// Se tem preço original, calcular desconto baseado nele
-			currentDiscount = ((originalPrice - currentPrice) / originalPrice) * 100
+			currentDiscount = currentPrice.DiscountFrom(originalPrice)
 		}
-		
+
 		// Verificar se atingiu o desconto alvo
-		if currentDiscount >= product.TargetDiscount {
+		if currentDiscount >= sub.TargetDiscount {
 			// Só notificar se o desconto mudou ou se é a primeira verificação
 			if product.Discount == 0 || discount != product.Discount {
 				shouldNotify = true
-				message = fmt.Sprintf(
-					"🎉 PROMOÇÃO DETECTADA!\n\n"+
-						"Produto: %s\n"+
-						"Preço atual: R$ %.2f\n"+
-						"Desconto: %.1f%% (meta: %.1f%%)\n",
-					product.Name,
-					currentPrice,
-					currentDiscount,
-					product.TargetDiscount,
-				)
-				if originalPrice > 0 {
-					message += fmt.Sprintf("Preço original: R$ %.2f\n", originalPrice)
-				}
-				message += fmt.Sprintf("\nLink: %s", product.URL)
+				alert.Reason = notify.ReasonTargetDiscount
+				alert.Discount = currentDiscount
 			}
 		}
 	}
 
-	// Enviar notificação se necessário
-	if shouldNotify {
-		chatID, err := strconv.ParseInt(os.Getenv("TELEGRAM_CHAT_ID"), 10, 64)
-		if err != nil {
-			log.Printf("Erro ao parsear TELEGRAM_CHAT_ID: %v", err)
-			return
-		}
-
-		msg := tgbotapi.NewMessage(chatID, message)
-		if _, err := m.bot.Send(msg); err != nil {
-			log.Printf("Erro ao enviar mensagem: %v", err)
-		} else {
-			log.Printf("Notificação enviada para produto %d", product.ID)
-		}
+	// Verificar se atingiu o parcelamento alvo (ex: 12x sem juros com parcela de até R$ 200)
+	// Só notificar quando o parcelamento passa a atender o alvo, e não a cada verificação
+	if sub.InstallmentsMet(snapshot.Installments) && !sub.InstallmentsMet(product.Installments) {
+		shouldNotify = true
+		alert.Reason = notify.ReasonTargetInstallments
 	}
+
+	return alert, shouldNotify
 }
 
+// filterOffers troca os preços do snapshot e os valores anteriores do produto pelos da oferta mais barata
+// entre os vendedores aceitos pelo filtro; retorna false se nenhuma oferta atual atender ao filtro
+// A oferta é comparada pelo próprio preço: parcelamento, cupons e preços à vista da página são do anúncio exibido nela
+func filterOffers(product models.Product, snapshot scraper.ProductSnapshot, filter models.SellerFilter) (models.Product, scraper.ProductSnapshot, bool) {
+	offer, ok := models.BestOffer(snapshot.Offers, filter)
+	if !ok {
+		return product, snapshot, false
+	}
+	snapshot.CurrentPrice = offer.Price
+	snapshot.CashPrice = money.Money{}
+	snapshot.CardPrice = money.Money{}
+	snapshot.Installments = models.Installments{}
+	snapshot.OriginalPrice = offer.OriginalPrice
+	snapshot.Discount = offer.Discount()
+	snapshot.Coupons = nil
+	snapshot.Deal = models.Deal{}
+	snapshot.Shipping = offer.Shipping
+	snapshot.Seller = offer.Seller
+	// As ofertas listadas estão ativas, mas a quantidade disponível não é informada
+	snapshot.Availability = scraper.AvailabilityInStock
+	snapshot.StockQuantity = 0
+
+	// Sem oferta anterior aceita pelo filtro, a verificação é tratada como a primeira
+	previous, hadOffer := models.BestOffer(product.Offers, filter)
+	product.CurrentPrice = previous.Price
+	product.CashPrice = money.Money{}
+	product.CardPrice = money.Money{}
+	product.Installments = models.Installments{}
+	product.Coupon = models.Coupon{}
+	product.OriginalPrice = previous.OriginalPrice
+	product.Discount = previous.Discount()
+	// Para a inscrição, o produto estava esgotado se havia ofertas na verificação anterior mas nenhuma
+	// atendia ao filtro; nos demais casos vale a disponibilidade anterior da página, já que as ofertas
+	// guardadas podem ser de antes de o produto esgotar
+	if !hadOffer && len(product.Offers) > 0 {
+		product.Availability = string(scraper.AvailabilityOutOfStock)
+	}
+	return product, snapshot, true
+}
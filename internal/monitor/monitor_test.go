@@ -0,0 +1,613 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+	"bot-produtos/internal/notify"
+	"bot-produtos/internal/scraper"
+)
+
+// fakeScraper devolve sempre o mesmo preço e registra as páginas lidas e quantas são lidas ao mesmo tempo
+// As primeiras leituras esperam até que wantInFlight estejam em andamento, para que o paralelismo
+// do pool de workers seja observado sem depender do tempo de cada leitura
+type fakeScraper struct {
+	price        money.Money
+	wantInFlight int
+	release      chan struct{}
+	releaseOnce  sync.Once
+
+	mu          sync.Mutex
+	scraped     map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func newFakeScraper(price money.Money, wantInFlight int) *fakeScraper {
+	return &fakeScraper{
+		price:        price,
+		wantInFlight: wantInFlight,
+		release:      make(chan struct{}),
+		scraped:      make(map[string]int),
+	}
+}
+
+func (f *fakeScraper) Scrape(ctx context.Context, url string) (scraper.ProductSnapshot, error) {
+	f.mu.Lock()
+	f.scraped[url]++
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	if f.inFlight >= f.wantInFlight {
+		f.releaseOnce.Do(func() { close(f.release) })
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.release:
+	case <-time.After(2 * time.Second):
+	}
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	return scraper.ProductSnapshot{
+		CurrentPrice: f.price,
+		Availability: scraper.AvailabilityInStock,
+		FetchedAt:    time.Now(),
+	}, nil
+}
+
+func (f *fakeScraper) CanHandle(url string) bool { return true }
+
+// fakeRegistry entrega todas as URLs ao mesmo scraper e não cota frete
+type fakeRegistry struct {
+	scraper scraper.Scraper
+}
+
+func (r fakeRegistry) FindScraper(url string) scraper.Scraper { return r.scraper }
+
+func (r fakeRegistry) QuoteShipping(ctx context.Context, url, cep string) (models.Shipping, error) {
+	return models.Shipping{}, scraper.ErrShippingUnsupported
+}
+
+// recordingNotifier guarda os alertas recebidos; perChat indica se é entregue por chat, como o Telegram
+type recordingNotifier struct {
+	perChat bool
+
+	mu     sync.Mutex
+	alerts []notify.Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) PerChat() bool { return n.perChat }
+
+func (n *recordingNotifier) received() []notify.Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notify.Alert(nil), n.alerts...)
+}
+
+func TestCheckProductsWorkerPool(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Dois chats acompanham cada produto com alvos que a primeira verificação já atinge
+	const productCount, concurrency = 8, 3
+	for i := 1; i <= productCount; i++ {
+		url := fmt.Sprintf("https://loja.example/produto/%d", i)
+		for _, chatID := range []int64{10, 20} {
+			if _, _, err := db.AddProductSubscription(url, url, fmt.Sprintf("Produto %d", i), "", models.Subscription{ChatID: chatID, TargetPrice: money.BRL(10000)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	products, err := db.GetActiveProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chats := &recordingNotifier{perChat: true}
+	fixed := &recordingNotifier{}
+	m := New(db, []notify.Notifier{chats, fixed}, nil, Options{Interval: time.Minute, Concurrency: concurrency})
+	fake := newFakeScraper(money.BRL(9990), concurrency)
+	m.registry = fakeRegistry{scraper: fake}
+
+	if checked := m.checkProducts(context.Background(), products); checked != productCount {
+		t.Errorf("checkProducts verificou %d produtos, esperado %d", checked, productCount)
+	}
+
+	fake.mu.Lock()
+	scraped, maxInFlight := len(fake.scraped), fake.maxInFlight
+	for url, count := range fake.scraped {
+		if count != 1 {
+			t.Errorf("%s lido %d vezes, esperado uma vez por ciclo", url, count)
+		}
+	}
+	fake.mu.Unlock()
+	if scraped != productCount {
+		t.Errorf("%d páginas lidas, esperado %d", scraped, productCount)
+	}
+	if maxInFlight != concurrency {
+		t.Errorf("%d leituras simultâneas, esperado %d", maxInFlight, concurrency)
+	}
+
+	// Cada chat recebe o seu alerta; os notificadores de destino fixo recebem um por produto, sem o chat
+	if alerts := chats.received(); len(alerts) != 2*productCount {
+		t.Errorf("%d alertas por chat, esperado %d", len(alerts), 2*productCount)
+	}
+	alerts := fixed.received()
+	if len(alerts) != productCount {
+		t.Errorf("%d alertas de destino fixo, esperado %d", len(alerts), productCount)
+	}
+	for _, alert := range alerts {
+		if alert.Subscription.ChatID != 0 || len(alert.Rules) != 1 || alert.Rules[0] != notify.ReasonTargetPrice {
+			t.Errorf("alerta de destino fixo = chat %d, regras %v; esperado sem chat e com target_price", alert.Subscription.ChatID, alert.Rules)
+		}
+	}
+
+	for _, product := range products {
+		stored, err := db.GetProductByID(product.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.CurrentPrice != money.BRL(9990) {
+			t.Errorf("preço do produto %d = %s, esperado R$ 99,90", product.ID, stored.CurrentPrice)
+		}
+	}
+}
+
+// evaluateTest é um caso de evaluateSubscription; product tem os valores da verificação anterior
+type evaluateTest struct {
+	name       string
+	product    models.Product
+	sub        models.Subscription
+	snapshot   scraper.ProductSnapshot
+	shipping   models.Shipping // Frete cotado para o chat; vazio usa o frete da página
+	wantNotify bool
+	wantReason notify.Reason
+}
+
+func runEvaluateTests(t *testing.T, tests []evaluateTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shipping := tt.shipping
+			if !shipping.Known() {
+				shipping = tt.snapshot.Shipping
+			}
+			alert, notified := evaluateSubscription(tt.product, tt.sub, tt.snapshot, shipping)
+			if notified != tt.wantNotify {
+				t.Fatalf("evaluateSubscription notificou = %v (motivo %q), esperado %v", notified, alert.Reason, tt.wantNotify)
+			}
+			if notified && alert.Reason != tt.wantReason {
+				t.Errorf("motivo = %q, esperado %q", alert.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+// inStock retorna o snapshot de um produto à venda pelo preço informado, em centavos
+func inStock(cents int64) scraper.ProductSnapshot {
+	return scraper.ProductSnapshot{CurrentPrice: money.BRL(cents), Availability: scraper.AvailabilityInStock}
+}
+
+func TestEvaluateSubscription(t *testing.T) {
+	discounted := inStock(8500)
+	discounted.OriginalPrice = money.BRL(10000)
+	discounted.Discount = 15
+
+	limited := inStock(10000)
+	limited.Availability = scraper.AvailabilityLimited
+
+	soldOut := inStock(5000)
+	soldOut.Availability = scraper.AvailabilityOutOfStock
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "preço alvo na primeira verificação",
+			sub:        models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot:   inStock(9990),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:       "preço caiu abaixo do alvo",
+			product:    models.Product{CurrentPrice: money.BRL(10500)},
+			sub:        models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot:   inStock(9990),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "preço acima do alvo",
+			product:  models.Product{CurrentPrice: money.BRL(12000)},
+			sub:      models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot: inStock(10500),
+		},
+		{
+			name:     "preço abaixo do alvo sem nova queda não repete",
+			product:  models.Product{CurrentPrice: money.BRL(9990)},
+			sub:      models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot: inStock(9990),
+		},
+		{
+			name:       "desconto alvo",
+			sub:        models.Subscription{TargetDiscount: 10},
+			snapshot:   discounted,
+			wantNotify: true,
+			wantReason: notify.ReasonTargetDiscount,
+		},
+		{
+			name:     "mesmo desconto não repete",
+			product:  models.Product{CurrentPrice: money.BRL(8500), Discount: 15},
+			sub:      models.Subscription{TargetDiscount: 10},
+			snapshot: discounted,
+		},
+		{
+			name:       "volta ao estoque",
+			product:    models.Product{Availability: string(scraper.AvailabilityOutOfStock)},
+			sub:        models.Subscription{NotifyInStock: true},
+			snapshot:   inStock(10000),
+			wantNotify: true,
+			wantReason: notify.ReasonBackInStock,
+		},
+		{
+			name:       "volta ao estoque limitado",
+			product:    models.Product{Availability: string(scraper.AvailabilityOutOfStock)},
+			sub:        models.Subscription{NotifyInStock: true},
+			snapshot:   limited,
+			wantNotify: true,
+			wantReason: notify.ReasonBackInStock,
+		},
+		{
+			name:     "produto que já estava à venda não repete o aviso de estoque",
+			product:  models.Product{CurrentPrice: money.BRL(10000), Availability: string(scraper.AvailabilityInStock)},
+			sub:      models.Subscription{NotifyInStock: true},
+			snapshot: inStock(10000),
+		},
+		{
+			name:     "volta ao estoque sem pedir o aviso",
+			product:  models.Product{Availability: string(scraper.AvailabilityOutOfStock)},
+			sub:      models.Subscription{},
+			snapshot: inStock(10000),
+		},
+		{
+			name:     "produto esgotado não atinge o preço alvo",
+			sub:      models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot: soldOut,
+		},
+	})
+}
+
+func TestEvaluateSubscriptionShipping(t *testing.T) {
+	withShipping := models.Subscription{TargetPrice: money.BRL(10000), WithShipping: true}
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "preço com frete dentro do alvo",
+			sub:        withShipping,
+			snapshot:   inStock(9000),
+			shipping:   models.Shipping{Cost: money.BRL(990), CEP: "01310100"},
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "frete leva o preço para acima do alvo",
+			sub:      withShipping,
+			snapshot: inStock(9000),
+			shipping: models.Shipping{Cost: money.BRL(1500), CEP: "01310100"},
+		},
+		{
+			name:       "frete grátis",
+			sub:        withShipping,
+			snapshot:   inStock(9990),
+			shipping:   models.Shipping{Free: true, CEP: "01310100"},
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:       "inscrição sem frete ignora o frete",
+			sub:        models.Subscription{TargetPrice: money.BRL(10000)},
+			snapshot:   inStock(9000),
+			shipping:   models.Shipping{Cost: money.BRL(1500), CEP: "01310100"},
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "queda só do frete não repete o alerta",
+			product:  models.Product{CurrentPrice: money.BRL(9000)},
+			sub:      withShipping,
+			snapshot: inStock(9000),
+			shipping: models.Shipping{Cost: money.BRL(500), CEP: "01310100"},
+		},
+	})
+}
+
+func TestEvaluateSubscriptionPriceBasis(t *testing.T) {
+	// À vista R$ 90,00; no cartão R$ 100,00 em 10x de R$ 10,00
+	payment := inStock(9000)
+	payment.CashPrice = money.BRL(9000)
+	payment.CardPrice = money.BRL(10000)
+	payment.Installments = models.Installments{Count: 10, Value: money.BRL(1000), InterestFree: true}
+
+	// Sem preço no cartão, a base cartão usa o total parcelado (10x de R$ 10,50)
+	installmentsOnly := inStock(9000)
+	installmentsOnly.Installments = models.Installments{Count: 10, Value: money.BRL(1050)}
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "alvo à vista",
+			sub:        models.Subscription{TargetPrice: money.BRL(9500), PriceBasis: models.PriceBasisCash},
+			snapshot:   payment,
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "alvo no cartão acima do preço à vista",
+			sub:      models.Subscription{TargetPrice: money.BRL(9500), PriceBasis: models.PriceBasisCard},
+			snapshot: payment,
+		},
+		{
+			name:       "alvo no cartão",
+			sub:        models.Subscription{TargetPrice: money.BRL(10000), PriceBasis: models.PriceBasisCard},
+			snapshot:   payment,
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "alvo no cartão pelo total parcelado",
+			sub:      models.Subscription{TargetPrice: money.BRL(10000), PriceBasis: models.PriceBasisCard},
+			snapshot: installmentsOnly,
+		},
+		{
+			name:       "alvo da parcela ignora o frete",
+			sub:        models.Subscription{TargetPrice: money.BRL(1000), PriceBasis: models.PriceBasisInstallment, WithShipping: true},
+			snapshot:   payment,
+			shipping:   models.Shipping{Cost: money.BRL(5000)},
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name: "preço no cartão sem queda não repete",
+			product: models.Product{
+				CurrentPrice: money.BRL(8500),
+				CashPrice:    money.BRL(8500),
+				CardPrice:    money.BRL(10000),
+			},
+			sub:      models.Subscription{TargetPrice: money.BRL(10000), PriceBasis: models.PriceBasisCard},
+			snapshot: payment,
+		},
+	})
+}
+
+func TestEvaluateSubscriptionInstallments(t *testing.T) {
+	withInstallments := func(count int, cents int64, interestFree bool) scraper.ProductSnapshot {
+		snapshot := inStock(int64(count) * cents)
+		snapshot.Installments = models.Installments{Count: count, Value: money.BRL(cents), InterestFree: interestFree}
+		return snapshot
+	}
+	twelveTimes := models.Subscription{TargetInstallments: 12, MaxInstallmentValue: money.BRL(20000)}
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "12x sem juros disponível",
+			sub:        twelveTimes,
+			snapshot:   withInstallments(12, 15000, true),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetInstallments,
+		},
+		{
+			name:       "mais parcelas que o alvo",
+			sub:        twelveTimes,
+			snapshot:   withInstallments(18, 10000, true),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetInstallments,
+		},
+		{
+			name:     "parcelamento com juros",
+			sub:      twelveTimes,
+			snapshot: withInstallments(12, 15000, false),
+		},
+		{
+			name:     "menos parcelas que o alvo",
+			sub:      twelveTimes,
+			snapshot: withInstallments(10, 15000, true),
+		},
+		{
+			name:     "parcela acima do valor máximo",
+			sub:      twelveTimes,
+			snapshot: withInstallments(12, 25000, true),
+		},
+		{
+			name: "parcelamento que já atendia o alvo não repete",
+			product: models.Product{
+				CurrentPrice: money.BRL(180000),
+				Installments: models.Installments{Count: 12, Value: money.BRL(15000), InterestFree: true},
+			},
+			sub:      twelveTimes,
+			snapshot: withInstallments(12, 15000, true),
+		},
+	})
+}
+
+func TestEvaluateSubscriptionCoupon(t *testing.T) {
+	withCoupon := func(coupons ...models.Coupon) scraper.ProductSnapshot {
+		snapshot := inStock(10000)
+		snapshot.Coupons = coupons
+		return snapshot
+	}
+	couponTarget := models.Subscription{TargetPrice: money.BRL(9000), WithCoupon: true}
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "cupom leva o preço ao alvo",
+			sub:        couponTarget,
+			snapshot:   withCoupon(models.Coupon{Amount: money.BRL(1500)}),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:       "melhor entre os cupons",
+			sub:        couponTarget,
+			snapshot:   withCoupon(models.Coupon{Amount: money.BRL(500)}, models.Coupon{Percent: 10}),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "cupom percentual limitado pelo desconto máximo",
+			sub:      couponTarget,
+			snapshot: withCoupon(models.Coupon{Percent: 20, MaxDiscount: money.BRL(500)}),
+		},
+		{
+			name:     "inscrição sem cupom ignora o cupom",
+			sub:      models.Subscription{TargetPrice: money.BRL(9000)},
+			snapshot: withCoupon(models.Coupon{Amount: money.BRL(1500)}),
+		},
+		{
+			name:       "cupom novo conta como queda de preço",
+			product:    models.Product{CurrentPrice: money.BRL(10000)},
+			sub:        couponTarget,
+			snapshot:   withCoupon(models.Coupon{Amount: money.BRL(1500)}),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "mesmo cupom não repete",
+			product:  models.Product{CurrentPrice: money.BRL(10000), Coupon: models.Coupon{Amount: money.BRL(1500)}},
+			sub:      couponTarget,
+			snapshot: withCoupon(models.Coupon{Amount: money.BRL(1500)}),
+		},
+	})
+}
+
+var (
+	regularOffer = models.Offer{
+		ItemID: "MLB1",
+		Seller: models.Seller{ID: 1, Name: "LOJA_A", Reputation: 3},
+		Price:  money.BRL(8000),
+	}
+	officialOffer = models.Offer{
+		ItemID:        "MLB2",
+		Seller:        models.Seller{ID: 2, Name: "MARCA", OfficialStore: true, Reputation: 5},
+		Price:         money.BRL(9500),
+		OriginalPrice: money.BRL(10000),
+		Shipping:      models.Shipping{Free: true},
+	}
+)
+
+// catalogSnapshot retorna uma página de catálogo com as ofertas informadas; a página exibe a mais barata
+func catalogSnapshot(offers ...models.Offer) scraper.ProductSnapshot {
+	snapshot := inStock(offers[0].Price.Cents)
+	snapshot.Seller = offers[0].Seller
+	snapshot.Coupons = []models.Coupon{{Amount: money.BRL(1000)}}
+	snapshot.Installments = models.Installments{Count: 10, Value: money.BRL(800), InterestFree: true}
+	snapshot.Offers = offers
+	return snapshot
+}
+
+func TestEvaluateSubscriptionSellerFilter(t *testing.T) {
+	officialOnly := models.SellerFilter{OfficialStoreOnly: true}
+
+	runEvaluateTests(t, []evaluateTest{
+		{
+			name:       "loja oficial dentro do alvo",
+			sub:        models.Subscription{TargetPrice: money.BRL(10000), SellerFilter: officialOnly},
+			snapshot:   catalogSnapshot(regularOffer, officialOffer),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "só a oferta de outro vendedor está dentro do alvo",
+			sub:      models.Subscription{TargetPrice: money.BRL(9000), SellerFilter: officialOnly},
+			snapshot: catalogSnapshot(regularOffer, officialOffer),
+		},
+		{
+			name:       "reputação mínima aceita a oferta mais barata",
+			sub:        models.Subscription{TargetPrice: money.BRL(9000), SellerFilter: models.SellerFilter{MinReputation: 3}},
+			snapshot:   catalogSnapshot(regularOffer, officialOffer),
+			wantNotify: true,
+			wantReason: notify.ReasonTargetPrice,
+		},
+		{
+			name:     "nenhuma oferta atende ao filtro",
+			sub:      models.Subscription{TargetPrice: money.BRL(10000), SellerFilter: officialOnly},
+			snapshot: catalogSnapshot(regularOffer),
+		},
+		{
+			name:     "mesma oferta aceita sem queda não repete",
+			product:  models.Product{CurrentPrice: money.BRL(8000), Offers: []models.Offer{regularOffer, officialOffer}},
+			sub:      models.Subscription{TargetPrice: money.BRL(10000), SellerFilter: officialOnly},
+			snapshot: catalogSnapshot(regularOffer, officialOffer),
+		},
+		{
+			name: "vendedor aceito volta a vender",
+			product: models.Product{
+				CurrentPrice: money.BRL(8000),
+				Availability: string(scraper.AvailabilityInStock),
+				Offers:       []models.Offer{regularOffer},
+			},
+			sub:        models.Subscription{NotifyInStock: true, SellerFilter: officialOnly},
+			snapshot:   catalogSnapshot(regularOffer, officialOffer),
+			wantNotify: true,
+			wantReason: notify.ReasonBackInStock,
+		},
+	})
+}
+
+func TestFilterOffers(t *testing.T) {
+	officialOnly := models.SellerFilter{OfficialStoreOnly: true}
+	product := models.Product{
+		CurrentPrice: money.BRL(8000),
+		CashPrice:    money.BRL(7800),
+		Coupon:       models.Coupon{Amount: money.BRL(1000)},
+		Offers:       []models.Offer{regularOffer, officialOffer},
+	}
+
+	filteredProduct, snapshot, ok := filterOffers(product, catalogSnapshot(regularOffer, officialOffer), officialOnly)
+	if !ok {
+		t.Fatal("filterOffers não encontrou a oferta da loja oficial")
+	}
+	if snapshot.CurrentPrice != officialOffer.Price || snapshot.Seller != officialOffer.Seller || !snapshot.Shipping.Free {
+		t.Errorf("snapshot = %s de %s, esperado a oferta da loja oficial", snapshot.CurrentPrice, snapshot.Seller)
+	}
+	if snapshot.Discount != 5 {
+		t.Errorf("desconto = %.1f, esperado o desconto da oferta (5%%)", snapshot.Discount)
+	}
+	// Cupons e parcelamento da página são do anúncio exibido, não da oferta escolhida
+	if len(snapshot.Coupons) != 0 || snapshot.Installments.Known() {
+		t.Errorf("snapshot manteve cupons %v e parcelamento %v da página", snapshot.Coupons, snapshot.Installments)
+	}
+	if filteredProduct.CurrentPrice != officialOffer.Price || filteredProduct.CashPrice.IsPositive() || filteredProduct.Coupon.Known() {
+		t.Errorf("produto = %s (à vista %s, cupom %s), esperado o preço anterior da oferta", filteredProduct.CurrentPrice, filteredProduct.CashPrice, filteredProduct.Coupon)
+	}
+
+	if _, _, ok := filterOffers(product, catalogSnapshot(regularOffer), officialOnly); ok {
+		t.Error("filterOffers aceitou uma página sem oferta da loja oficial")
+	}
+
+	// Sem oferta anterior aceita pelo filtro, a verificação é tratada como a primeira
+	product.Offers = []models.Offer{regularOffer}
+	filteredProduct, _, _ = filterOffers(product, catalogSnapshot(regularOffer, officialOffer), officialOnly)
+	if !filteredProduct.CurrentPrice.IsZero() || filteredProduct.Availability != string(scraper.AvailabilityOutOfStock) {
+		t.Errorf("produto = %s, %q; esperado sem preço anterior e esgotado para o filtro", filteredProduct.CurrentPrice, filteredProduct.Availability)
+	}
+}
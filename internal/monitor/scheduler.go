@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"container/heap"
+	"time"
+
+	"bot-produtos/internal/models"
+)
+
+// scheduleItem representa a próxima verificação de um produto
+type scheduleItem struct {
+	productID int64
+	interval  time.Duration
+	due       time.Time
+	index     int // Posição na heap, mantida por scheduleQueue
+}
+
+// scheduleQueue é uma heap ordenada pelo horário da próxima verificação
+type scheduleQueue []*scheduleItem
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	item := x.(*scheduleItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*q = old[:n-1]
+	return item
+}
+
+// scheduler mantém a fila de prioridade com o horário da próxima verificação de cada produto
+type scheduler struct {
+	defaultInterval time.Duration
+	queue           scheduleQueue
+	items           map[int64]*scheduleItem
+}
+
+func newScheduler(defaultInterval time.Duration) *scheduler {
+	return &scheduler{
+		defaultInterval: defaultInterval,
+		items:           make(map[int64]*scheduleItem),
+	}
+}
+
+// intervalFor retorna o menor intervalo pedido pelas inscrições do produto
+// Inscrições sem intervalo próprio usam o intervalo global
+func (s *scheduler) intervalFor(product models.Product) time.Duration {
+	interval := time.Duration(0)
+	for _, requested := range product.CheckIntervals {
+		if requested <= 0 {
+			requested = s.defaultInterval
+		}
+		if interval == 0 || requested < interval {
+			interval = requested
+		}
+	}
+	if interval == 0 {
+		return s.defaultInterval
+	}
+	return interval
+}
+
+// Sync alinha a fila com os produtos ativos: agenda os novos, remove os inativos
+// e reagenda os que tiveram o intervalo alterado
+func (s *scheduler) Sync(products []models.Product, now time.Time) {
+	active := make(map[int64]bool, len(products))
+	for _, product := range products {
+		active[product.ID] = true
+		interval := s.intervalFor(product)
+
+		item, ok := s.items[product.ID]
+		if !ok {
+			// Produtos nunca verificados (ou atrasados) entram na fila para agora
+			due := product.LastChecked.Add(interval)
+			if due.Before(now) {
+				due = now
+			}
+			item = &scheduleItem{productID: product.ID, interval: interval, due: due}
+			s.items[product.ID] = item
+			heap.Push(&s.queue, item)
+			continue
+		}
+
+		if item.interval != interval {
+			// Recalcular a partir da última verificação com o novo intervalo
+			item.due = item.due.Add(interval - item.interval)
+			if item.due.Before(now) {
+				item.due = now
+			}
+			item.interval = interval
+			heap.Fix(&s.queue, item.index)
+		}
+	}
+
+	for id, item := range s.items {
+		if !active[id] {
+			heap.Remove(&s.queue, item.index)
+			delete(s.items, id)
+		}
+	}
+}
+
+// PopDue remove e retorna os IDs dos produtos cuja verificação já venceu
+func (s *scheduler) PopDue(now time.Time) []int64 {
+	var due []int64
+	for len(s.queue) > 0 && !s.queue[0].due.After(now) {
+		item := heap.Pop(&s.queue).(*scheduleItem)
+		delete(s.items, item.productID)
+		due = append(due, item.productID)
+	}
+	return due
+}
+
+// Reschedule agenda a próxima verificação do produto a partir de now
+func (s *scheduler) Reschedule(product models.Product, now time.Time) {
+	if item, ok := s.items[product.ID]; ok {
+		heap.Remove(&s.queue, item.index)
+	}
+	interval := s.intervalFor(product)
+	item := &scheduleItem{productID: product.ID, interval: interval, due: now.Add(interval)}
+	s.items[product.ID] = item
+	heap.Push(&s.queue, item)
+}
+
+// Next retorna o horário da próxima verificação agendada
+func (s *scheduler) Next() (time.Time, bool) {
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].due, true
+}
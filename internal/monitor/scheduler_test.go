@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/models"
+)
+
+var schedulerNow = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestSchedulerIntervalFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []time.Duration
+		want      time.Duration
+	}{
+		{"sem inscrições", nil, 10 * time.Minute},
+		{"inscrição com o intervalo global", []time.Duration{0}, 10 * time.Minute},
+		{"intervalo próprio menor que o global", []time.Duration{0, 5 * time.Minute}, 5 * time.Minute},
+		{"intervalo próprio maior que o global", []time.Duration{30 * time.Minute, 0}, 10 * time.Minute},
+		{"só intervalos próprios", []time.Duration{30 * time.Minute, 20 * time.Minute}, 20 * time.Minute},
+	}
+
+	s := newScheduler(10 * time.Minute)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.intervalFor(models.Product{ID: 1, CheckIntervals: tt.intervals}); got != tt.want {
+				t.Errorf("intervalFor = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		products []models.Product
+		wantNext time.Time
+		// Produtos vencidos em cada instante, em ordem; os instantes são aplicados em sequência
+		popAt   []time.Time
+		wantDue [][]int64
+	}{
+		{
+			name:     "produto nunca verificado vence agora",
+			products: []models.Product{{ID: 1}},
+			wantNext: schedulerNow,
+			popAt:    []time.Time{schedulerNow},
+			wantDue:  [][]int64{{1}},
+		},
+		{
+			name:     "produto atrasado vence agora",
+			products: []models.Product{{ID: 1, LastChecked: schedulerNow.Add(-time.Hour)}},
+			wantNext: schedulerNow,
+			popAt:    []time.Time{schedulerNow},
+			wantDue:  [][]int64{{1}},
+		},
+		{
+			name:     "produto verificado vence depois do intervalo global",
+			products: []models.Product{{ID: 1, LastChecked: schedulerNow.Add(-4 * time.Minute)}},
+			wantNext: schedulerNow.Add(6 * time.Minute),
+			popAt:    []time.Time{schedulerNow.Add(5 * time.Minute), schedulerNow.Add(6 * time.Minute)},
+			wantDue:  [][]int64{nil, {1}},
+		},
+		{
+			name: "ordem do próximo vencimento com intervalos por produto",
+			products: []models.Product{
+				{ID: 1, LastChecked: schedulerNow, CheckIntervals: []time.Duration{0}},
+				{ID: 2, LastChecked: schedulerNow, CheckIntervals: []time.Duration{2 * time.Minute}},
+				{ID: 3, LastChecked: schedulerNow.Add(-time.Minute), CheckIntervals: []time.Duration{5 * time.Minute}},
+			},
+			wantNext: schedulerNow.Add(2 * time.Minute),
+			popAt:    []time.Time{schedulerNow.Add(4 * time.Minute), schedulerNow.Add(10 * time.Minute)},
+			wantDue:  [][]int64{{2, 3}, {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(10 * time.Minute)
+			s.Sync(tt.products, schedulerNow)
+
+			next, ok := s.Next()
+			if !ok || !next.Equal(tt.wantNext) {
+				t.Errorf("Next = %v, %v; esperado %v", next, ok, tt.wantNext)
+			}
+			for i, at := range tt.popAt {
+				if got := s.PopDue(at); !reflect.DeepEqual(got, tt.wantDue[i]) {
+					t.Errorf("PopDue(%v) = %v, esperado %v", at.Sub(schedulerNow), got, tt.wantDue[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerSyncChanges(t *testing.T) {
+	s := newScheduler(10 * time.Minute)
+	s.Sync([]models.Product{
+		{ID: 1, LastChecked: schedulerNow},
+		{ID: 2, LastChecked: schedulerNow},
+	}, schedulerNow)
+
+	// Uma inscrição pede 4 minutos: o vencimento é recalculado a partir da última verificação
+	later := schedulerNow.Add(time.Minute)
+	s.Sync([]models.Product{
+		{ID: 1, LastChecked: schedulerNow, CheckIntervals: []time.Duration{0, 4 * time.Minute}},
+		{ID: 2, LastChecked: schedulerNow},
+	}, later)
+	if next, _ := s.Next(); !next.Equal(schedulerNow.Add(4 * time.Minute)) {
+		t.Errorf("Next depois de reduzir o intervalo = %v, esperado 4m", next.Sub(schedulerNow))
+	}
+
+	// Um intervalo que já teria vencido faz o produto vencer agora
+	s.Sync([]models.Product{
+		{ID: 1, LastChecked: schedulerNow, CheckIntervals: []time.Duration{time.Second}},
+		{ID: 2, LastChecked: schedulerNow},
+	}, later)
+	if due := s.PopDue(later); !reflect.DeepEqual(due, []int64{1}) {
+		t.Errorf("PopDue = %v, esperado [1]", due)
+	}
+
+	// Produtos que deixaram de estar ativos saem da fila
+	s.Sync(nil, later)
+	if next, ok := s.Next(); ok {
+		t.Errorf("Next = %v depois de remover todos os produtos, esperado fila vazia", next)
+	}
+	if due := s.PopDue(schedulerNow.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("PopDue = %v, esperado nenhum produto", due)
+	}
+}
+
+func TestSchedulerReschedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		product models.Product
+		want    time.Duration
+	}{
+		{"intervalo global", models.Product{ID: 1}, 10 * time.Minute},
+		{"intervalo da inscrição", models.Product{ID: 1, CheckIntervals: []time.Duration{3 * time.Minute}}, 3 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(10 * time.Minute)
+			s.Sync([]models.Product{tt.product}, schedulerNow)
+			if due := s.PopDue(schedulerNow); len(due) != 1 {
+				t.Fatalf("PopDue = %v, esperado o produto", due)
+			}
+			if _, ok := s.Next(); ok {
+				t.Fatal("produto retirado pelo PopDue continua na fila")
+			}
+
+			checkedAt := schedulerNow.Add(30 * time.Second)
+			s.Reschedule(tt.product, checkedAt)
+			next, ok := s.Next()
+			if !ok || !next.Equal(checkedAt.Add(tt.want)) {
+				t.Errorf("Next = %v, %v; esperado %v depois da verificação", next.Sub(checkedAt), ok, tt.want)
+			}
+
+			// Reagendar de novo substitui o vencimento anterior em vez de duplicar o produto
+			s.Reschedule(tt.product, checkedAt.Add(time.Minute))
+			if due := s.PopDue(checkedAt.Add(time.Minute + tt.want)); !reflect.DeepEqual(due, []int64{1}) {
+				t.Errorf("PopDue = %v, esperado o produto uma única vez", due)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modos de envio do EmailNotifier
+const (
+	EmailModeImmediate = "immediate" // Um e-mail por alerta
+	EmailModeDigest    = "digest"    // Um resumo diário com os alertas acumulados
+)
+
+// EmailConfig configura o EmailNotifier
+type EmailConfig struct {
+	Host       string
+	Port       int
+	Username   string // Vazio desativa a autenticação
+	Password   string
+	From       string
+	To         []string
+	StartTLS   bool   // Exige STARTTLS antes de autenticar e enviar
+	Mode       string // EmailModeImmediate ou EmailModeDigest
+	DigestHour int    // Hora local (0-23) de envio do resumo diário
+	TLSConfig  *tls.Config
+	Timeout    time.Duration // Prazo de cada envio, da conexão ao QUIT (padrão: 30 segundos)
+}
+
+// EmailNotifier envia alertas por e-mail em HTML e texto simples via SMTP
+type EmailNotifier struct {
+	config EmailConfig
+
+	mu      sync.Mutex
+	pending []Alert // Alertas aguardando o próximo resumo diário
+}
+
+// NewEmailNotifier cria um notificador de e-mail
+func NewEmailNotifier(config EmailConfig) *EmailNotifier {
+	if config.Mode == "" {
+		config.Mode = EmailModeImmediate
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &EmailNotifier{config: config}
+}
+
+// Notify envia o alerta imediatamente ou o guarda para o resumo diário, conforme o modo
+func (e *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	if e.config.Mode == EmailModeDigest {
+		e.mu.Lock()
+		e.pending = append(e.pending, alert)
+		e.mu.Unlock()
+		return nil
+	}
+
+	subject := fmt.Sprintf("Promoção: %s", alert.Product.Name)
+	switch alert.Reason {
+	case ReasonBackInStock:
+		subject = fmt.Sprintf("De volta ao estoque: %s", alert.Product.Name)
+	case ReasonTargetInstallments:
+		subject = fmt.Sprintf("Parcelamento disponível: %s", alert.Product.Name)
+	}
+	return e.send(ctx, subject, []Alert{alert})
+}
+
+// Run envia o resumo diário no horário configurado até o contexto ser cancelado
+// Não faz nada no modo imediato
+func (e *EmailNotifier) Run(ctx context.Context) {
+	if e.config.Mode != EmailModeDigest {
+		return
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(nextDigestTime(time.Now(), e.config.DigestHour)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := e.Flush(ctx); err != nil {
+				log.Printf("Erro ao enviar resumo diário por e-mail: %v", err)
+			}
+		}
+	}
+}
+
+// Flush envia imediatamente o resumo com os alertas pendentes, se houver
+func (e *EmailNotifier) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	alerts := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	subject := fmt.Sprintf("Resumo diário: %d promoção(ões) detectada(s)", len(alerts))
+	if err := e.send(ctx, subject, alerts); err != nil {
+		// Devolver os alertas para a fila para tentar no próximo resumo
+		e.mu.Lock()
+		e.pending = append(alerts, e.pending...)
+		e.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// nextDigestTime retorna o próximo instante com a hora informada, depois de now
+func nextDigestTime(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// send monta a mensagem multipart e a entrega ao servidor SMTP
+func (e *EmailNotifier) send(ctx context.Context, subject string, alerts []Alert) error {
+	message, err := buildEmail(e.config.From, e.config.To, subject, alerts)
+	if err != nil {
+		return err
+	}
+
+	// O envio sempre tem prazo, para que um servidor que não responde não trave o monitor
+	deadline := time.Now().Add(e.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := e.config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: e.config.Host}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %v", err)
+		}
+	} else if e.config.StartTLS {
+		return fmt.Errorf("servidor SMTP %s não suporta STARTTLS", addr)
+	}
+
+	if e.config.Username != "" {
+		auth := smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %v", err)
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return err
+	}
+	for _, to := range e.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail monta uma mensagem multipart/alternative com as versões em texto e HTML
+func buildEmail(from string, to []string, subject string, alerts []Alert) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		render      func() (string, error)
+	}{
+		{"text/plain; charset=UTF-8", func() (string, error) { return renderEmailText(alerts), nil }},
+		{"text/html; charset=UTF-8", func() (string, error) { return renderEmailHTML(subject, alerts) }},
+	}
+
+	for _, part := range parts {
+		content, err := part.render()
+		if err != nil {
+			return nil, err
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// renderEmailText monta a versão em texto simples, reaproveitando o texto dos alertas
+func renderEmailText(alerts []Alert) string {
+	texts := make([]string, len(alerts))
+	for i, alert := range alerts {
+		texts[i] = alert.Text()
+	}
+	return strings.Join(texts, "\r\n\r\n----------\r\n\r\n")
+}
+
+// emailAlertView contém os campos já formatados usados no template HTML
+type emailAlertView struct {
+	Name          string
+	Link          string
+	NewPrice      string
+	OldPrice      string
+	OriginalPrice string
+	Discount      string
+	Shipping      string
+	Installments  string
+	Coupon        string
+	Deal          string
+	Seller        string
+	Rule          string
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333; max-width: 600px; margin: 0 auto;">
+  <h2 style="color: #3483fa;">{{.Title}}</h2>
+  {{range .Alerts}}
+  <div style="border: 1px solid #e5e5e5; border-radius: 6px; padding: 12px 16px; margin-bottom: 12px;">
+    <h3 style="margin: 0 0 8px 0;"><a href="{{.Link}}" style="color: #333;">{{.Name}}</a></h3>
+    {{if .Deal}}<p style="margin: 4px 0; color: #fff;"><span style="background: #3483fa; border-radius: 3px; padding: 2px 6px;">{{.Deal}}</span></p>{{end}}
+    {{if .NewPrice}}<p style="margin: 4px 0; font-size: 20px;"><b>{{.NewPrice}}</b>{{if .OldPrice}} <span style="color: #999; text-decoration: line-through;">{{.OldPrice}}</span>{{end}}</p>{{end}}
+    {{if .OriginalPrice}}<p style="margin: 4px 0;">Preço original: {{.OriginalPrice}}</p>{{end}}
+    {{if .Installments}}<p style="margin: 4px 0;">{{.Installments}}</p>{{end}}
+    {{if .Coupon}}<p style="margin: 4px 0; color: #00a650;">{{.Coupon}}</p>{{end}}
+    {{if .Shipping}}<p style="margin: 4px 0;">{{.Shipping}}</p>{{end}}
+    {{if .Seller}}<p style="margin: 4px 0;">Vendedor: {{.Seller}}</p>{{end}}
+    {{if .Discount}}<p style="margin: 4px 0; color: #00a650;"><b>{{.Discount}} OFF</b></p>{{end}}
+    <p style="margin: 4px 0; color: #666;">{{.Rule}}</p>
+    <p style="margin: 8px 0 0 0;"><a href="{{.Link}}" style="color: #3483fa;">Ver produto</a></p>
+  </div>
+  {{end}}
+  <p style="color: #999; font-size: 12px;">Enviado pelo Bot de Monitoramento de Preços</p>
+</body>
+</html>
+`))
+
+// renderEmailHTML monta a versão HTML usada tanto para alertas imediatos quanto para o resumo
+func renderEmailHTML(title string, alerts []Alert) (string, error) {
+	views := make([]emailAlertView, len(alerts))
+	for i, alert := range alerts {
+		view := emailAlertView{
+			Name: alert.Product.Name,
+			Link: alert.Link,
+		}
+		if alert.NewPrice.IsPositive() {
+			view.NewPrice = alert.NewPrice.String()
+		}
+		if alert.OldPrice.IsPositive() && alert.OldPrice != alert.NewPrice {
+			view.OldPrice = alert.OldPrice.String()
+		}
+		if alert.OriginalPrice.IsPositive() {
+			view.OriginalPrice = alert.OriginalPrice.String()
+		}
+		if alert.Discount > 0 {
+			view.Discount = fmt.Sprintf("%.1f%%", alert.Discount)
+		}
+		view.Shipping = alert.ShippingText()
+		if alert.Installments.Known() {
+			view.Installments = "Parcelamento: " + alert.Installments.String()
+		}
+		view.Coupon = alert.CouponText()
+		if alert.Deal.Active() {
+			view.Deal = alert.Deal.String()
+		}
+		view.Seller = alert.Seller.String()
+		switch {
+		case alert.ProductLevel():
+			view.Rule = "Regras atingidas: " + alert.RulesText()
+		case alert.Reason == ReasonBackInStock:
+			view.Rule = "Produto de volta ao estoque"
+		case alert.Reason == ReasonTargetInstallments:
+			view.Rule = fmt.Sprintf("Parcelamento alvo de %s atingido", alert.Subscription.InstallmentsTarget())
+		case alert.Reason == ReasonTargetDiscount:
+			view.Rule = fmt.Sprintf("Desconto alvo de %.1f%% atingido", alert.Subscription.TargetDiscount)
+		default:
+			view.Rule = fmt.Sprintf("Preço alvo de %s atingido", alert.Subscription.TargetPrice)
+			if note := alert.targetNote(); note != "" {
+				view.Rule = fmt.Sprintf("Preço alvo de %s (%s) atingido", alert.Subscription.TargetPrice, note)
+			}
+		}
+		views[i] = view
+	}
+
+	var buf bytes.Buffer
+	err := emailTemplate.Execute(&buf, struct {
+		Title  string
+		Alerts []emailAlertView
+	}{title, views})
+	return buf.String(), err
+}
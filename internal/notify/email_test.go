@@ -0,0 +1,458 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// smtpMessage é uma mensagem recebida pelo servidor SMTP de teste
+type smtpMessage struct {
+	from string
+	to   []string
+	data []byte
+	tls  bool   // A mensagem foi enviada depois do STARTTLS
+	auth string // Credenciais do AUTH PLAIN, decodificadas
+}
+
+// smtpServer é um servidor SMTP mínimo que guarda as mensagens e os comandos recebidos
+type smtpServer struct {
+	host      string
+	port      int
+	offerTLS  bool        // Anuncia STARTTLS no EHLO
+	tlsConfig *tls.Config // Certificado do servidor para o STARTTLS
+	client    *tls.Config // Configuração que confia no certificado, para o EmailConfig
+
+	mu       sync.Mutex
+	messages []smtpMessage
+	commands []string
+}
+
+func newSMTPServer(t *testing.T, offerTLS bool) *smtpServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("erro ao abrir o servidor SMTP: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	// O servidor HTTPS de teste fornece um certificado válido para 127.0.0.1
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(certServer.Close)
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+
+	addr := listener.Addr().(*net.TCPAddr)
+	s := &smtpServer{
+		host:      addr.IP.String(),
+		port:      addr.Port,
+		offerTLS:  offerTLS,
+		tlsConfig: &tls.Config{Certificates: certServer.TLS.Certificates},
+		client:    &tls.Config{RootCAs: roots, ServerName: addr.IP.String()},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	// conn passa a ser a conexão TLS depois do STARTTLS
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			extensions := []string{"localhost"}
+			if s.offerTLS && !msg.tls {
+				extensions = append(extensions, "STARTTLS")
+			}
+			extensions = append(extensions, "AUTH PLAIN")
+			for i, extension := range extensions {
+				separator := "-"
+				if i == len(extensions)-1 {
+					separator = " "
+				}
+				tp.PrintfLine("250%s%s", separator, extension)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 pronto para TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(tlsConn)
+			msg.tls = true
+		case "AUTH":
+			_, initial, _ := strings.Cut(arg, " ")
+			credentials, _ := base64.StdEncoding.DecodeString(initial)
+			msg.auth = string(credentials)
+			tp.PrintfLine("235 autenticado")
+		case "MAIL":
+			msg.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 envie a mensagem")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg.from, msg.to, msg.data = "", nil, nil
+			tp.PrintfLine("250 mensagem aceita")
+		case "QUIT":
+			tp.PrintfLine("221 até logo")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *smtpServer) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+func (s *smtpServer) sawCommand(verb string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, command := range s.commands {
+		if strings.HasPrefix(strings.ToUpper(command), verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// config retorna um EmailConfig apontando para o servidor de teste
+func (s *smtpServer) config(mode string, startTLS bool) EmailConfig {
+	return EmailConfig{
+		Host:      s.host,
+		Port:      s.port,
+		From:      "bot@example.com",
+		To:        []string{"ana@example.com", "bruno@example.com"},
+		StartTLS:  startTLS,
+		Mode:      mode,
+		TLSConfig: s.client,
+	}
+}
+
+// parsedEmail é uma mensagem recebida com o assunto e as partes já decodificados
+type parsedEmail struct {
+	subject string
+	from    string
+	to      string
+	text    string
+	html    string
+}
+
+func parseEmail(t *testing.T, data []byte) parsedEmail {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("assunto inválido: %v", err)
+	}
+	email := parsedEmail{subject: subject, from: msg.Header.Get("From"), to: msg.Header.Get("To")}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, esperado multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		// NextPart decodifica o quoted-printable das partes
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("parte inválida: %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("parte inválida: %v", err)
+		}
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			email.text = string(content)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			email.html = string(content)
+		}
+	}
+	return email
+}
+
+func emailAlert(id int64, name string, price int64) Alert {
+	return Alert{
+		Product:      models.Product{ID: id, Name: name},
+		Subscription: models.Subscription{ChatID: 1001, TargetPrice: money.BRL(price + 1000)},
+		OldPrice:     money.BRL(price + 5000),
+		NewPrice:     money.BRL(price),
+		Reason:       ReasonTargetPrice,
+		Link:         "https://www.mercadolivre.com.br/p/MLB50097091",
+		CreatedAt:    time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestEmailImmediate(t *testing.T) {
+	server := newSMTPServer(t, false)
+	notifier := NewEmailNotifier(server.config(EmailModeImmediate, false))
+
+	if err := notifier.Notify(context.Background(), emailAlert(1, "Fone Bluetooth", 14990)); err != nil {
+		t.Fatalf("Notify retornou erro: %v", err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("%d mensagens recebidas, esperado 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.from != "FROM:<bot@example.com>" {
+		t.Errorf("MAIL = %q, esperado o remetente configurado", msg.from)
+	}
+	if strings.Join(msg.to, " ") != "TO:<ana@example.com> TO:<bruno@example.com>" {
+		t.Errorf("RCPT = %q, esperado os dois destinatários", msg.to)
+	}
+	if msg.tls {
+		t.Error("mensagem enviada com TLS, esperado texto puro quando o servidor não oferece STARTTLS")
+	}
+
+	email := parseEmail(t, msg.data)
+	if email.subject != "Promoção: Fone Bluetooth" {
+		t.Errorf("Subject = %q, esperado o assunto do alerta", email.subject)
+	}
+	if email.to != "ana@example.com, bruno@example.com" {
+		t.Errorf("To = %q, esperado os dois destinatários", email.to)
+	}
+	for _, part := range []string{email.text, email.html} {
+		if !strings.Contains(part, "Fone Bluetooth") || !strings.Contains(part, "149,90") {
+			t.Errorf("parte sem o produto e o preço: %q", part)
+		}
+	}
+}
+
+func TestEmailProductLevel(t *testing.T) {
+	// Alertas por produto descrevem as regras atingidas, sem os alvos de nenhuma inscrição
+	alert := emailAlert(1, "Fone Bluetooth", 14990)
+	alert.Subscription = models.Subscription{}
+	alert.Rules = []Reason{ReasonTargetPrice, ReasonBackInStock}
+
+	html, err := renderEmailHTML("Promoção: Fone Bluetooth", []Alert{alert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{alert.Text(), html} {
+		if !strings.Contains(part, "Regras atingidas: preço alvo, volta ao estoque") {
+			t.Errorf("parte sem as regras atingidas: %q", part)
+		}
+		if strings.Contains(part, "0,00") {
+			t.Errorf("parte com o alvo vazio da inscrição: %q", part)
+		}
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	server := newSMTPServer(t, false)
+	notifier := NewEmailNotifier(server.config(EmailModeDigest, false))
+
+	for _, alert := range []Alert{emailAlert(1, "Fone Bluetooth", 14990), emailAlert(2, "Cafeteira Expresso", 24990)} {
+		if err := notifier.Notify(context.Background(), alert); err != nil {
+			t.Fatalf("Notify retornou erro: %v", err)
+		}
+	}
+	if messages := server.received(); len(messages) != 0 {
+		t.Fatalf("%d mensagens antes do resumo, esperado nenhuma", len(messages))
+	}
+
+	if err := notifier.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush retornou erro: %v", err)
+	}
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("%d mensagens recebidas, esperado 1 resumo", len(messages))
+	}
+	email := parseEmail(t, messages[0].data)
+	if email.subject != "Resumo diário: 2 promoção(ões) detectada(s)" {
+		t.Errorf("Subject = %q, esperado o assunto do resumo", email.subject)
+	}
+	for _, name := range []string{"Fone Bluetooth", "Cafeteira Expresso"} {
+		if !strings.Contains(email.text, name) || !strings.Contains(email.html, name) {
+			t.Errorf("resumo sem o produto %q", name)
+		}
+	}
+
+	// Sem alertas pendentes, o resumo não é enviado
+	if err := notifier.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush retornou erro: %v", err)
+	}
+	if messages := server.received(); len(messages) != 1 {
+		t.Errorf("%d mensagens recebidas, esperado nenhum resumo vazio", len(messages))
+	}
+}
+
+func TestEmailDigestRequeuesOnFailure(t *testing.T) {
+	server := newSMTPServer(t, false)
+	notifier := NewEmailNotifier(server.config(EmailModeDigest, true))
+
+	notifier.Notify(context.Background(), emailAlert(1, "Fone Bluetooth", 14990))
+	if err := notifier.Flush(context.Background()); err == nil {
+		t.Fatal("Flush não retornou erro, esperado falha por falta de STARTTLS")
+	}
+
+	// O alerta volta para a fila e sai no próximo resumo
+	notifier.config.StartTLS = false
+	notifier.Notify(context.Background(), emailAlert(2, "Cafeteira Expresso", 24990))
+	if err := notifier.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush retornou erro: %v", err)
+	}
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("%d mensagens recebidas, esperado 1", len(messages))
+	}
+	if email := parseEmail(t, messages[0].data); email.subject != "Resumo diário: 2 promoção(ões) detectada(s)" {
+		t.Errorf("Subject = %q, esperado os dois alertas no resumo", email.subject)
+	}
+}
+
+func TestEmailTimeout(t *testing.T) {
+	// Servidor que aceita a conexão e nunca envia a saudação
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		listener.Close()
+	})
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		<-done
+		conn.Close()
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier(EmailConfig{
+		Host:    "127.0.0.1",
+		Port:    addr.Port,
+		From:    "bot@example.com",
+		To:      []string{"ana@example.com"},
+		Timeout: 100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	err = notifier.Notify(context.Background(), emailAlert(1, "Fone Bluetooth", 14990))
+	if err == nil {
+		t.Fatal("Notify não retornou erro, esperado o fim do prazo")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify levou %v, esperado o prazo configurado", elapsed)
+	}
+}
+
+func TestEmailStartTLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		offerTLS bool // O servidor anuncia STARTTLS
+		startTLS bool // EmailConfig.StartTLS
+		wantErr  bool
+		wantTLS  bool
+	}{
+		{"exigido e oferecido", true, true, false, true},
+		{"opcional e oferecido", true, false, false, true},
+		{"exigido e não oferecido", false, true, true, false},
+		{"opcional e não oferecido", false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPServer(t, tt.offerTLS)
+			config := server.config(EmailModeImmediate, tt.startTLS)
+			config.Username = "bot@example.com"
+			config.Password = "senha"
+			notifier := NewEmailNotifier(config)
+
+			err := notifier.Notify(context.Background(), emailAlert(1, "Fone Bluetooth", 14990))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "não suporta STARTTLS") {
+					t.Fatalf("Notify retornou erro %v, esperado a falta de STARTTLS", err)
+				}
+				// Nada é enviado, nem as credenciais, sem a conexão protegida
+				if server.sawCommand("AUTH") || server.sawCommand("MAIL") {
+					t.Error("credenciais ou mensagem enviadas sem STARTTLS")
+				}
+				if messages := server.received(); len(messages) != 0 {
+					t.Errorf("%d mensagens recebidas, esperado nenhuma", len(messages))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Notify retornou erro: %v", err)
+			}
+
+			messages := server.received()
+			if len(messages) != 1 {
+				t.Fatalf("%d mensagens recebidas, esperado 1", len(messages))
+			}
+			if messages[0].tls != tt.wantTLS {
+				t.Errorf("TLS = %v, esperado %v", messages[0].tls, tt.wantTLS)
+			}
+			if messages[0].auth != "\x00bot@example.com\x00senha" {
+				t.Errorf("AUTH PLAIN = %q, esperado as credenciais configuradas", messages[0].auth)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// Reason identifica a regra que disparou um alerta
+type Reason string
+
+const (
+	ReasonTargetPrice        Reason = "target_price"        // Preço atingiu o preço alvo
+	ReasonTargetDiscount     Reason = "target_discount"     // Desconto atingiu o desconto alvo
+	ReasonBackInStock        Reason = "back_in_stock"       // Produto esgotado voltou ao estoque
+	ReasonTargetInstallments Reason = "target_installments" // Parcelamento sem juros atingiu o alvo
+)
+
+// Reasons lista as regras na ordem em que aparecem nos alertas por produto
+var Reasons = []Reason{ReasonTargetPrice, ReasonTargetDiscount, ReasonTargetInstallments, ReasonBackInStock}
+
+// Label descreve a regra em texto (ex: "preço alvo")
+func (r Reason) Label() string {
+	switch r {
+	case ReasonTargetPrice:
+		return "preço alvo"
+	case ReasonTargetDiscount:
+		return "desconto alvo"
+	case ReasonBackInStock:
+		return "volta ao estoque"
+	case ReasonTargetInstallments:
+		return "parcelamento alvo"
+	}
+	return string(r)
+}
+
+// Alert contém os dados de uma promoção detectada pelo monitor
+type Alert struct {
+	Product       models.Product      // Produto com os dados anteriores à verificação
+	Subscription  models.Subscription // Inscrição cujos alvos foram atingidos (vazia nos alertas por produto)
+	OldPrice      money.Money         // Preço antes da verificação (zero na primeira verificação)
+	NewPrice      money.Money         // Preço encontrado na verificação
+	BasisPrice    money.Money         // Preço na base da inscrição (à vista, cartão, parcela...) comparado com o alvo
+	Installments  models.Installments // Parcelamento encontrado na verificação
+	Coupon        models.Coupon       // Melhor cupom ativo na página, se houver
+	Deal          models.Deal         // Selo promocional da página (ex: "Oferta relâmpago")
+	OriginalPrice money.Money         // Preço original informado pela loja (zero se não houver)
+	Discount      float64             // Percentual de desconto considerado pela regra
+	Availability  string              // Disponibilidade encontrada na verificação (valores de scraper.Availability)
+	StockQuantity int                 // Unidades disponíveis informadas pela loja (0 se não houver)
+	Shipping      models.Shipping     // Frete da página ou cotado para o CEP do chat
+	Seller        models.Seller       // Vendedor da oferta encontrada, quando a loja informa
+	Reason        Reason
+	Rules         []Reason // Regras disparadas pelas inscrições, nos alertas por produto
+	Link          string
+	CreatedAt     time.Time
+}
+
+// Notifier envia alertas por algum canal (Telegram, e-mail, webhook...)
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// ChatNotifier é implementado por notificadores que entregam o alerta ao chat da inscrição (ex: Telegram)
+// Os demais notificadores têm destino fixo e recebem no máximo um alerta por produto a cada verificação,
+// mesmo quando vários chats acompanham o produto
+type ChatNotifier interface {
+	Notifier
+	PerChat() bool
+}
+
+// IsPerChat indica se o notificador entrega cada alerta ao chat da inscrição
+func IsPerChat(n Notifier) bool {
+	chat, ok := n.(ChatNotifier)
+	return ok && chat.PerChat()
+}
+
+// Flusher é implementado por notificadores que acumulam alertas para enviar depois
+// Flush é chamado no encerramento do bot para não perder alertas pendentes
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// ProductLevel indica se o alerta resume as inscrições de um produto para os notificadores de destino fixo
+// Esses alertas não levam dados dos chats: a inscrição fica vazia e o frete é o da página
+func (a Alert) ProductLevel() bool {
+	return len(a.Rules) > 0
+}
+
+// RulesText lista as regras disparadas em um alerta por produto (ex: "preço alvo, volta ao estoque")
+func (a Alert) RulesText() string {
+	labels := make([]string, len(a.Rules))
+	for i, rule := range a.Rules {
+		labels[i] = rule.Label()
+	}
+	return strings.Join(labels, ", ")
+}
+
+// Text formata o alerta como texto simples
+func (a Alert) Text() string {
+	var message string
+
+	switch {
+	case a.ProductLevel():
+		message = fmt.Sprintf(
+			"🔔 ALERTA DE PREÇO!\n\n"+
+				"Produto: %s\n",
+			a.Product.Name,
+		)
+		if a.NewPrice.IsPositive() {
+			message += fmt.Sprintf("Preço atual: %s\n", a.NewPrice)
+		}
+		if a.OriginalPrice.IsPositive() {
+			message += fmt.Sprintf("Preço original: %s\n", a.OriginalPrice)
+		}
+		if a.Discount > 0 {
+			message += fmt.Sprintf("Desconto: %.1f%%\n", a.Discount)
+		}
+		if a.StockQuantity > 0 {
+			message += fmt.Sprintf("Unidades disponíveis: %d\n", a.StockQuantity)
+		}
+		message += fmt.Sprintf("Regras atingidas: %s\n", a.RulesText())
+	case a.Reason == ReasonBackInStock:
+		message = fmt.Sprintf(
+			"📦 DE VOLTA AO ESTOQUE!\n\n"+
+				"Produto: %s\n",
+			a.Product.Name,
+		)
+		if a.NewPrice.IsPositive() {
+			message += fmt.Sprintf("Preço atual: %s\n", a.NewPrice)
+		}
+		if a.StockQuantity > 0 {
+			message += fmt.Sprintf("Unidades disponíveis: %d\n", a.StockQuantity)
+		}
+	case a.Reason == ReasonTargetInstallments:
+		message = fmt.Sprintf(
+			"💳 PARCELAMENTO DISPONÍVEL!\n\n"+
+				"Produto: %s\n"+
+				"Preço atual: %s\n"+
+				"Meta: %s\n",
+			a.Product.Name,
+			a.NewPrice,
+			a.Subscription.InstallmentsTarget(),
+		)
+	case a.Reason == ReasonTargetDiscount:
+		message = fmt.Sprintf(
+			"🎉 PROMOÇÃO DETECTADA!\n\n"+
+				"Produto: %s\n"+
+				"Preço atual: %s\n"+
+				"Desconto: %.1f%% (meta: %.1f%%)\n",
+			a.Product.Name,
+			a.NewPrice,
+			a.Discount,
+			a.Subscription.TargetDiscount,
+		)
+		if a.OriginalPrice.IsPositive() {
+			message += fmt.Sprintf("Preço original: %s\n", a.OriginalPrice)
+		}
+	default:
+		message = fmt.Sprintf(
+			"🎉 PROMOÇÃO DETECTADA!\n\n"+
+				"Produto: %s\n"+
+				"Preço atual: %s\n"+
+				"Preço alvo: %s\n",
+			a.Product.Name,
+			a.NewPrice,
+			a.Subscription.TargetPrice,
+		)
+		if note := a.targetNote(); note != "" {
+			message = strings.TrimSuffix(message, "\n") + " (" + note + ")\n"
+		}
+		if a.Subscription.PriceBasis != models.PriceBasisLowest && a.BasisPrice.IsPositive() {
+			message += fmt.Sprintf("Preço %s: %s\n", a.Subscription.PriceBasis.Label(), a.BasisPrice)
+		}
+		if a.Discount > 0 {
+			message += fmt.Sprintf("Desconto: %.1f%%\n", a.Discount)
+		}
+	}
+
+	if a.Installments.Known() {
+		message += fmt.Sprintf("Parcelamento: %s\n", a.Installments)
+	}
+	if coupon := a.CouponText(); coupon != "" {
+		message += coupon + "\n"
+	}
+	if a.Deal.Active() {
+		message += fmt.Sprintf("Oferta: %s\n", a.Deal)
+	}
+	if shipping := a.ShippingText(); shipping != "" {
+		message += shipping + "\n"
+	}
+	if a.Seller.Known() {
+		message += fmt.Sprintf("Vendedor: %s\n", a.Seller)
+	}
+
+	message += fmt.Sprintf("\nLink: %s", a.Link)
+	return message
+}
+
+// targetNote descreve a base de preço e o frete considerados pelo alvo (ex: "no cartão, com frete")
+func (a Alert) targetNote() string {
+	var notes []string
+	if a.Subscription.PriceBasis != models.PriceBasisLowest {
+		notes = append(notes, a.Subscription.PriceBasis.Label())
+	}
+	if a.Subscription.WithCoupon && a.Subscription.PriceBasis != models.PriceBasisInstallment {
+		notes = append(notes, "com cupom")
+	}
+	if a.Subscription.WithShipping && a.Subscription.PriceBasis != models.PriceBasisInstallment {
+		notes = append(notes, "com frete")
+	}
+	if a.Subscription.SellerFilter.Active() {
+		notes = append(notes, "vendedor: "+a.Subscription.SellerFilter.String())
+	}
+	return strings.Join(notes, ", ")
+}
+
+// CouponText descreve o cupom ativo e o preço com o cupom (vazio se não houver cupom)
+func (a Alert) CouponText() string {
+	if !a.Coupon.Known() || !a.NewPrice.IsPositive() {
+		return ""
+	}
+	return fmt.Sprintf("Cupom: %s (preço com cupom: %s)", a.Coupon, a.Coupon.Apply(a.NewPrice))
+}
+
+// ShippingText descreve o frete e o total com frete (vazio se o frete for desconhecido)
+func (a Alert) ShippingText() string {
+	if !a.Shipping.Known() || !a.NewPrice.IsPositive() {
+		return ""
+	}
+	destination := ""
+	if a.Shipping.CEP != "" {
+		destination = " para o CEP " + models.FormatCEP(a.Shipping.CEP)
+	}
+	if a.Shipping.Free {
+		return "Frete grátis" + destination
+	}
+	return fmt.Sprintf("Frete%s: %s (total com frete: %s)", destination, a.Shipping.Cost, a.Shipping.Total(a.NewPrice))
+}
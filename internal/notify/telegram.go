@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TelegramNotifier envia alertas para o chat dono da inscrição
+type TelegramNotifier struct {
+	bot *tgbotapi.BotAPI
+}
+
+// NewTelegramNotifier cria um notificador que usa o bot do Telegram
+func NewTelegramNotifier(bot *tgbotapi.BotAPI) *TelegramNotifier {
+	return &TelegramNotifier{bot: bot}
+}
+
+// PerChat indica que cada inscrição recebe o alerta no próprio chat
+func (t *TelegramNotifier) PerChat() bool {
+	return true
+}
+
+// Notify envia o alerta como mensagem de texto para o chat da inscrição
+func (t *TelegramNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg := tgbotapi.NewMessage(alert.Subscription.ChatID, alert.Text())
+	_, err := t.bot.Send(msg)
+	return err
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"bot-produtos/internal/models"
+)
+
+// SignatureHeader contém a assinatura HMAC-SHA256 do corpo da requisição, no formato "sha256=<hex>"
+const SignatureHeader = "X-PriceBot-Signature"
+
+// WebhookPayload é o corpo JSON enviado para os webhooks
+type WebhookPayload struct {
+	Event                   string     `json:"event"`
+	ProductID               int64      `json:"product_id"`
+	Name                    string     `json:"name"`
+	URL                     string     `json:"url"`
+	PreviousPrice           float64    `json:"previous_price"`
+	CurrentPrice            float64    `json:"current_price"`
+	OriginalPrice           float64    `json:"original_price,omitempty"`
+	Currency                string     `json:"currency"`
+	Discount                float64    `json:"discount"`
+	Rule                    Reason     `json:"rule"`
+	Rules                   []Reason   `json:"rules,omitempty"` // Todas as regras disparadas, nos alertas por produto
+	TargetPrice             float64    `json:"target_price,omitempty"`
+	TargetDiscount          float64    `json:"target_discount,omitempty"`
+	TargetInstallments      int        `json:"target_installments,omitempty"`
+	MaxInstallmentValue     float64    `json:"max_installment_value,omitempty"`
+	PriceBasis              string     `json:"price_basis,omitempty"` // Base do alvo: cash, card ou installment (ausente para o menor preço)
+	BasisPrice              float64    `json:"basis_price,omitempty"` // Preço na base do alvo
+	Installments            int        `json:"installments,omitempty"`
+	InstallmentValue        float64    `json:"installment_value,omitempty"`
+	InstallmentInterestFree bool       `json:"installment_interest_free,omitempty"`
+	Availability            string     `json:"availability,omitempty"`
+	StockQuantity           int        `json:"stock_quantity,omitempty"`
+	CouponAmount            float64    `json:"coupon_amount,omitempty"`
+	CouponPercent           float64    `json:"coupon_percent,omitempty"`
+	PriceWithCoupon         float64    `json:"price_with_coupon,omitempty"`
+	DealBadge               string     `json:"deal_badge,omitempty"`
+	DealEndsAt              *time.Time `json:"deal_ends_at,omitempty"`
+	ShippingCost            *float64   `json:"shipping_cost,omitempty"` // Ausente quando o frete é desconhecido; 0 para frete grátis
+	ShippingCEP             string     `json:"shipping_cep,omitempty"`
+	SellerID                int64      `json:"seller_id,omitempty"`
+	SellerName              string     `json:"seller_name,omitempty"`
+	SellerOfficialStore     bool       `json:"seller_official_store,omitempty"`
+	SellerReputation        int        `json:"seller_reputation,omitempty"` // Nível de 1 (vermelho) a 5 (verde)
+	TotalPrice              float64    `json:"total_price"`                 // Preço atual somado ao frete conhecido
+	ChatID                  int64      `json:"chat_id,omitempty"`           // Ausente nos alertas por produto, que não são de um chat
+	Timestamp               time.Time  `json:"timestamp"`
+}
+
+// DeliveryLogger registra as tentativas de entrega dos webhooks
+type DeliveryLogger interface {
+	LogWebhookDelivery(delivery models.WebhookDelivery) error
+}
+
+// WebhookConfig configura o WebhookNotifier
+type WebhookConfig struct {
+	URLs       []string
+	Secret     string        // Chave usada para assinar o corpo (vazio desativa a assinatura)
+	MaxRetries int           // Tentativas extras após a primeira falha
+	Backoff    time.Duration // Espera antes da primeira nova tentativa, dobrando a cada falha
+	Client     *http.Client  // Cliente HTTP (padrão: timeout de 10 segundos)
+}
+
+// WebhookNotifier envia alertas como JSON assinado para URLs configuradas
+type WebhookNotifier struct {
+	config     WebhookConfig
+	client     *http.Client
+	deliveries DeliveryLogger
+}
+
+// NewWebhookNotifier cria um notificador de webhooks
+// deliveries pode ser nil quando não for necessário registrar as entregas
+func NewWebhookNotifier(config WebhookConfig, deliveries DeliveryLogger) *WebhookNotifier {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookNotifier{
+		config:     config,
+		client:     client,
+		deliveries: deliveries,
+	}
+}
+
+// NewWebhookPayload converte um alerta no corpo enviado aos webhooks
+func NewWebhookPayload(alert Alert) WebhookPayload {
+	payload := WebhookPayload{
+		Event:               "price_alert",
+		ProductID:           alert.Product.ID,
+		Name:                alert.Product.Name,
+		URL:                 alert.Link,
+		PreviousPrice:       alert.OldPrice.Float64(),
+		CurrentPrice:        alert.NewPrice.Float64(),
+		OriginalPrice:       alert.OriginalPrice.Float64(),
+		Currency:            alert.NewPrice.CurrencyCode(),
+		Discount:            alert.Discount,
+		Rule:                alert.Reason,
+		Rules:               alert.Rules,
+		TargetPrice:         alert.Subscription.TargetPrice.Float64(),
+		TargetDiscount:      alert.Subscription.TargetDiscount,
+		PriceBasis:          string(alert.Subscription.PriceBasis),
+		TargetInstallments:  alert.Subscription.TargetInstallments,
+		MaxInstallmentValue: alert.Subscription.MaxInstallmentValue.Float64(),
+		BasisPrice:          alert.BasisPrice.Float64(),
+		Availability:        alert.Availability,
+		StockQuantity:       alert.StockQuantity,
+		ShippingCEP:         alert.Shipping.CEP,
+		SellerID:            alert.Seller.ID,
+		SellerName:          alert.Seller.Name,
+		SellerOfficialStore: alert.Seller.OfficialStore,
+		SellerReputation:    alert.Seller.Reputation,
+		TotalPrice:          alert.Shipping.Total(alert.NewPrice).Float64(),
+		ChatID:              alert.Subscription.ChatID,
+		Timestamp:           alert.CreatedAt.UTC(),
+	}
+	if alert.Installments.Known() {
+		payload.Installments = alert.Installments.Count
+		payload.InstallmentValue = alert.Installments.Value.Float64()
+		payload.InstallmentInterestFree = alert.Installments.InterestFree
+	}
+	if alert.Coupon.Known() {
+		payload.CouponAmount = alert.Coupon.Amount.Float64()
+		payload.CouponPercent = alert.Coupon.Percent
+		payload.PriceWithCoupon = alert.Coupon.Apply(alert.NewPrice).Float64()
+	}
+	if alert.Deal.Active() {
+		payload.DealBadge = alert.Deal.Badge
+		if !alert.Deal.EndsAt.IsZero() {
+			endsAt := alert.Deal.EndsAt.UTC()
+			payload.DealEndsAt = &endsAt
+		}
+	}
+	if alert.Shipping.Known() {
+		var cost float64
+		if !alert.Shipping.Free {
+			cost = alert.Shipping.Cost.Float64()
+		}
+		payload.ShippingCost = &cost
+	}
+	return payload
+}
+
+// Sign calcula a assinatura HMAC-SHA256 do corpo no formato do cabeçalho SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify envia o alerta para todas as URLs configuradas
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(NewWebhookPayload(alert))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, url := range w.config.URLs {
+		if err := w.deliver(ctx, url, alert.Product.ID, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver envia o corpo para uma URL, tentando novamente com backoff exponencial
+func (w *WebhookNotifier) deliver(ctx context.Context, url string, productID int64, body []byte) error {
+	backoff := w.config.Backoff
+	var lastErr error
+
+	for attempt := 1; attempt <= w.config.MaxRetries+1; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		start := time.Now()
+		status, err := w.post(ctx, url, body)
+		w.logDelivery(models.WebhookDelivery{
+			ProductID:  productID,
+			URL:        url,
+			Attempt:    attempt,
+			StatusCode: status,
+			Success:    err == nil,
+			Error:      errorText(err),
+			Payload:    string(body),
+			Duration:   time.Since(start),
+		})
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		// Erros do cliente (exceto 429) não são resolvidos com novas tentativas
+		if status >= 400 && status < 500 && status != http.StatusTooManyRequests {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// post faz uma única requisição e retorna o status HTTP
+func (w *WebhookNotifier) post(ctx context.Context, url string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "price-monitoring-bot")
+	if w.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.config.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (w *WebhookNotifier) logDelivery(delivery models.WebhookDelivery) {
+	if w.deliveries == nil {
+		return
+	}
+	if err := w.deliveries.LogWebhookDelivery(delivery); err != nil {
+		// Falha ao registrar não deve impedir a entrega
+		log.Printf("Erro ao registrar entrega de webhook: %v", err)
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
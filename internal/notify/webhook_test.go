@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/database"
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// webhookRequest é uma requisição recebida pelo servidor de teste
+type webhookRequest struct {
+	body       []byte
+	signature  string
+	receivedAt time.Time
+}
+
+// webhookServer responde com os status informados, um por tentativa, e guarda as requisições recebidas
+// Depois do último status, as tentativas recebem 200
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, webhookRequest{body: body, signature: r.Header.Get(SignatureHeader), receivedAt: time.Now()})
+		status := http.StatusOK
+		if len(s.requests) <= len(s.statuses) {
+			status = s.statuses[len(s.requests)-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.requests...)
+}
+
+func testAlert() Alert {
+	return Alert{
+		Product:      models.Product{ID: 42, Name: "Fone Bluetooth", URL: "https://www.amazon.com.br/dp/B0C1234567"},
+		Subscription: models.Subscription{ChatID: 1001, TargetPrice: money.BRL(15000)},
+		OldPrice:     money.BRL(19990),
+		NewPrice:     money.BRL(14990),
+		Reason:       ReasonTargetPrice,
+		Link:         "https://www.amazon.com.br/dp/B0C1234567",
+		CreatedAt:    time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Valor calculado de forma independente (HMAC-SHA256 com a chave "segredo")
+	const want = "sha256=ab047bc9778266d4646e8ea0d5b5aa46eabd824149679cacd2ff3f0293a18101"
+	if got := Sign("segredo", []byte(`{"event":"price_alert"}`)); got != want {
+		t.Errorf("Sign = %q, esperado %q", got, want)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	for _, secret := range []string{"segredo", ""} {
+		server := newWebhookServer(t)
+		notifier := NewWebhookNotifier(WebhookConfig{URLs: []string{server.URL}, Secret: secret}, nil)
+
+		if err := notifier.Notify(context.Background(), testAlert()); err != nil {
+			t.Fatalf("Notify retornou erro: %v", err)
+		}
+
+		requests := server.received()
+		if len(requests) != 1 {
+			t.Fatalf("%d requisições recebidas, esperado 1", len(requests))
+		}
+		want := ""
+		if secret != "" {
+			want = Sign(secret, requests[0].body)
+		}
+		if requests[0].signature != want {
+			t.Errorf("secret %q: %s = %q, esperado %q", secret, SignatureHeader, requests[0].signature, want)
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+			t.Fatalf("corpo inválido: %v", err)
+		}
+		if payload.ProductID != 42 || payload.CurrentPrice != 149.9 || payload.ChatID != 1001 || payload.Rule != ReasonTargetPrice {
+			t.Errorf("payload = %+v, esperado os dados do alerta", payload)
+		}
+	}
+}
+
+func TestWebhookPayloadProductLevel(t *testing.T) {
+	// Alertas por produto não levam dados dos chats
+	alert := testAlert()
+	alert.Subscription = models.Subscription{}
+	alert.Rules = []Reason{ReasonTargetPrice, ReasonBackInStock}
+	alert.Shipping = models.Shipping{Cost: money.BRL(1990)}
+
+	body, err := json.Marshal(NewWebhookPayload(alert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"chat_id", "shipping_cep", "target_price", "price_basis"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("payload por produto contém %q: %s", key, body)
+		}
+	}
+	rules, _ := fields["rules"].([]any)
+	if len(rules) != 2 || rules[0] != string(ReasonTargetPrice) || rules[1] != string(ReasonBackInStock) {
+		t.Errorf("rules = %v, esperado [target_price back_in_stock]", fields["rules"])
+	}
+	if fields["shipping_cost"] != 19.9 {
+		t.Errorf("shipping_cost = %v, esperado 19.9", fields["shipping_cost"])
+	}
+}
+
+// deliveryRow é uma linha de webhook_deliveries
+type deliveryRow struct {
+	productID  int64
+	url        string
+	attempt    int
+	statusCode int
+	success    bool
+	errorText  string
+	payload    string
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // Status respondidos em cada tentativa
+		attempts int   // Tentativas esperadas
+		success  bool
+	}{
+		{"sucesso na primeira", nil, 1, true},
+		{"5xx e depois sucesso", []int{500, 502}, 3, true},
+		{"429 e depois sucesso", []int{429}, 2, true},
+		{"5xx em todas", []int{503, 503, 503}, 3, false},
+		{"400 sem nova tentativa", []int{400}, 1, false},
+		{"404 sem nova tentativa", []int{404}, 1, false},
+		{"410 sem nova tentativa", []int{410}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "bot.db")
+			db, err := database.New(dbPath)
+			if err != nil {
+				t.Fatalf("erro ao abrir o banco: %v", err)
+			}
+			defer db.Close()
+
+			const backoff = 20 * time.Millisecond
+			server := newWebhookServer(t, tt.statuses...)
+			notifier := NewWebhookNotifier(WebhookConfig{
+				URLs:       []string{server.URL},
+				Secret:     "segredo",
+				MaxRetries: 2,
+				Backoff:    backoff,
+			}, db)
+
+			err = notifier.Notify(context.Background(), testAlert())
+			if (err == nil) != tt.success {
+				t.Fatalf("Notify retornou erro %v, esperado sucesso = %v", err, tt.success)
+			}
+
+			requests := server.received()
+			if len(requests) != tt.attempts {
+				t.Fatalf("%d tentativas, esperado %d", len(requests), tt.attempts)
+			}
+			// A espera dobra a cada nova tentativa
+			for i := 1; i < len(requests); i++ {
+				wait := backoff << (i - 1)
+				if gap := requests[i].receivedAt.Sub(requests[i-1].receivedAt); gap < wait {
+					t.Errorf("tentativa %d após %v, esperado ao menos %v", i+1, gap, wait)
+				}
+			}
+
+			rows := readDeliveries(t, dbPath)
+			if len(rows) != tt.attempts {
+				t.Fatalf("%d linhas em webhook_deliveries, esperado %d", len(rows), tt.attempts)
+			}
+			for i, row := range rows {
+				status := http.StatusOK
+				if i < len(tt.statuses) {
+					status = tt.statuses[i]
+				}
+				want := deliveryRow{
+					productID:  42,
+					url:        server.URL,
+					attempt:    i + 1,
+					statusCode: status,
+					success:    status == http.StatusOK,
+					payload:    string(requests[i].body),
+				}
+				if !want.success {
+					want.errorText = "status code: " + strconv.Itoa(status)
+				}
+				if row != want {
+					t.Errorf("linha %d = %+v, esperado %+v", i+1, row, want)
+				}
+			}
+		})
+	}
+}
+
+// readDeliveries lê as linhas de webhook_deliveries na ordem em que foram gravadas
+func readDeliveries(t *testing.T, dbPath string) []deliveryRow {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("erro ao abrir o banco: %v", err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("SELECT product_id, url, attempt, status_code, success, error, payload FROM webhook_deliveries ORDER BY id")
+	if err != nil {
+		t.Fatalf("erro ao ler webhook_deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []deliveryRow
+	for rows.Next() {
+		var row deliveryRow
+		if err := rows.Scan(&row.productID, &row.url, &row.attempt, &row.statusCode, &row.success, &row.errorText, &row.payload); err != nil {
+			t.Fatalf("erro ao ler webhook_deliveries: %v", err)
+		}
+		deliveries = append(deliveries, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("erro ao ler webhook_deliveries: %v", err)
+	}
+	return deliveries
+}
@@ -0,0 +1,296 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	amazonASINRe     = regexp.MustCompile(`/(?:dp|gp/product|gp/aw/d)/([A-Z0-9]{10})(?:[/?#]|$)`)
+	amazonDiscountRe = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*%`)
+	amazonStockRe    = regexp.MustCompile(`restam apenas (\d+)`)
+)
+
+// AmazonScraper implementa o scraper para a Amazon Brasil
+type AmazonScraper struct {
+	client *http.Client
+}
+
+// NewAmazonScraper cria uma nova instância do scraper da Amazon Brasil
+func NewAmazonScraper(limiter *HostLimiter) *AmazonScraper {
+	return &AmazonScraper{client: newHTTPClient(limiter)}
+}
+
+// CanHandle verifica se o scraper pode lidar com a URL fornecida
+// Aceita páginas de produto da amazon.com.br (/dp/ e /gp/product/) e links encurtados amzn.to
+func (a *AmazonScraper) CanHandle(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch host {
+	case "amzn.to":
+		return true
+	case "amazon.com.br":
+		return amazonASINRe.MatchString(u.Path)
+	}
+	return false
+}
+
+// Scrape baixa a página do produto uma única vez e extrai nome, preços, desconto e disponibilidade
+func (a *AmazonScraper) Scrape(ctx context.Context, rawURL string) (ProductSnapshot, error) {
+	start := time.Now()
+	pageURL := a.cleanURL(rawURL)
+
+	snapshot := ProductSnapshot{
+		URL:       pageURL,
+		Currency:  "BRL",
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "amazon",
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, a.client, pageURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	// Links amzn.to só revelam o produto depois do redirecionamento
+	if doc.Url != nil {
+		snapshot.URL = a.cleanURL(doc.Url.String())
+	}
+
+	if a.isCaptcha(doc) {
+		return snapshot, fmt.Errorf("a Amazon respondeu com uma página de verificação (captcha)")
+	}
+
+	snapshot.Name = a.extractName(doc)
+	snapshot.Availability, snapshot.StockQuantity = a.extractAvailability(doc)
+	// Exemplo: "Entrega GRÁTIS: sexta-feira" ou "R$ 19,90 de frete"
+	snapshot.Shipping = shippingFromText(doc.Find("#mir-layout-DELIVERY_BLOCK, #deliveryBlockMessage").First().Text())
+	// Exemplo: "Aplicar cupom de R$ 20,00" ou "Economize 5% com cupom"
+	snapshot.Coupons = couponsFromSelection(doc.Find("#couponBadgeRegularVpc, #vpcButton, #promoPriceBlockMessage_feature_div"))
+	// Exemplo: "Oferta Relâmpago" com "Termina em 05:12:33"
+	snapshot.Deal = dealFromText(doc.Find("#dealBadge_feature_div, #dealBadgeSupportingText").Text(), start)
+
+	price, source, err := a.extractPrice(doc)
+	if err != nil {
+		// Produtos indisponíveis não exibem preço; a indisponibilidade é registrada sem erro
+		if snapshot.Availability == AvailabilityOutOfStock {
+			snapshot.Extraction.Duration = time.Since(start)
+			return snapshot, nil
+		}
+		return snapshot, err
+	}
+
+	snapshot.CurrentPrice = price
+	snapshot.OriginalPrice = a.extractOriginalPrice(doc)
+	snapshot.Discount = a.extractDiscount(doc, price, snapshot.OriginalPrice)
+	// Exemplo: "Em até 10x R$ 29,99 sem juros"
+	snapshot.Installments = installmentsFromText(doc.Find("#installmentCalculator_feature_div, #best-offer-string-cc").First().Text())
+	snapshot.Extraction.PriceSource = source
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// isCaptcha detecta a página de verificação de robôs que a Amazon mostra no lugar do produto
+func (a *AmazonScraper) isCaptcha(doc *goquery.Document) bool {
+	return doc.Find("form[action*='validateCaptcha']").Length() > 0 ||
+		doc.Find("#captchacharacters").Length() > 0
+}
+
+// extractPrice extrai o preço atual do produto, retornando também o seletor que o encontrou
+func (a *AmazonScraper) extractPrice(doc *goquery.Document) (money.Money, string, error) {
+	// A ordem vai do layout atual (corePrice) para os layouts antigos (priceblock)
+	priceSelectors := []string{
+		"#corePriceDisplay_desktop_feature_div .priceToPay .a-offscreen",
+		"#corePrice_desktop .apexPriceToPay .a-offscreen",
+		"#corePrice_feature_div .a-price .a-offscreen",
+		"#apex_desktop .a-price:not(.a-text-price) .a-offscreen",
+		"#priceblock_dealprice",
+		"#priceblock_ourprice",
+		"#priceblock_saleprice",
+		"#price_inside_buybox",
+		"#newBuyBoxPrice",
+	}
+
+	for _, selector := range priceSelectors {
+		text := strings.TrimSpace(doc.Find(selector).First().Text())
+		if text == "" {
+			continue
+		}
+		price, err := parsePrice(text)
+		if err != nil || !price.IsPositive() {
+			continue
+		}
+		return price, selector, nil
+	}
+
+	// Alguns layouts só trazem as partes inteira e decimal separadas
+	whole := strings.TrimSpace(doc.Find(".priceToPay .a-price-whole, #corePrice_feature_div .a-price-whole").First().Text())
+	if whole != "" {
+		fraction := strings.TrimSpace(doc.Find(".priceToPay .a-price-fraction, #corePrice_feature_div .a-price-fraction").First().Text())
+		if cents, err := money.ParseSplit(strings.TrimRight(whole, ",."), fraction); err == nil && cents > 0 {
+			return money.BRL(cents), ".a-price-whole", nil
+		}
+	}
+
+	return money.Money{}, "", fmt.Errorf("preço não encontrado na página")
+}
+
+// extractOriginalPrice extrai o preço de lista ("De:"), retornando 0 se não houver
+func (a *AmazonScraper) extractOriginalPrice(doc *goquery.Document) money.Money {
+	originalPriceSelectors := []string{
+		"#corePriceDisplay_desktop_feature_div .basisPrice .a-offscreen",
+		"#corePrice_desktop .a-text-price[data-a-strike='true'] .a-offscreen",
+		"#corePrice_feature_div .basisPrice .a-offscreen",
+		".basisPrice .a-offscreen",
+		"#priceblock_listprice",
+		"#listPrice",
+	}
+
+	for _, selector := range originalPriceSelectors {
+		text := strings.TrimSpace(doc.Find(selector).First().Text())
+		if text == "" {
+			continue
+		}
+		if price, err := parsePrice(text); err == nil && price.IsPositive() {
+			return price
+		}
+	}
+
+	// Layouts sem classe específica: procurar o preço riscado ao lado do rótulo "De:"
+	var originalPrice money.Money
+	doc.Find(".a-text-price[data-a-strike='true']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		label := s.Parent().Text()
+		if !strings.Contains(label, "De:") && !strings.Contains(label, "Preço anterior") {
+			return true
+		}
+		text := strings.TrimSpace(s.Find(".a-offscreen").First().Text())
+		if price, err := parsePrice(text); err == nil && price.IsPositive() {
+			originalPrice = price
+			return false
+		}
+		return true
+	})
+
+	return originalPrice
+}
+
+// extractDiscount extrai o percentual de desconto, calculando-o a partir do preço de lista se a página não o mostrar
+func (a *AmazonScraper) extractDiscount(doc *goquery.Document, price, originalPrice money.Money) float64 {
+	discountSelectors := []string{
+		"#corePriceDisplay_desktop_feature_div .savingsPercentage",
+		"#corePrice_desktop .savingsPercentage",
+		".savingsPercentage",
+		"#regularprice_savings .a-color-price",
+		"#dealprice_savings .a-color-price",
+	}
+
+	for _, selector := range discountSelectors {
+		text := strings.TrimSpace(doc.Find(selector).First().Text())
+		// Exemplo: "-17%" ou "R$ 50,00 (17%)"
+		if matches := amazonDiscountRe.FindStringSubmatch(text); len(matches) > 1 {
+			discount, err := strconv.ParseFloat(strings.ReplaceAll(matches[1], ",", "."), 64)
+			if err == nil && discount > 0 && discount <= 100 {
+				return discount
+			}
+		}
+	}
+
+	return price.DiscountFrom(originalPrice)
+}
+
+// extractName extrai o nome do produto
+func (a *AmazonScraper) extractName(doc *goquery.Document) string {
+	nameSelectors := []string{
+		"#productTitle",
+		"#title",
+		"meta[name='title']",
+	}
+
+	for _, selector := range nameSelectors {
+		s := doc.Find(selector).First()
+		name := strings.TrimSpace(s.Text())
+		if name == "" {
+			name = strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		if name != "" {
+			return strings.Join(strings.Fields(name), " ")
+		}
+	}
+
+	return "Produto sem nome"
+}
+
+// extractAvailability interpreta o bloco "#availability" e os botões de compra da página
+// Retorna também a quantidade quando a página a informa (ex: "Restam apenas 3 em estoque")
+func (a *AmazonScraper) extractAvailability(doc *goquery.Document) (Availability, int) {
+	if doc.Find("#outOfStock, #outOfStockBuyBox_feature_div #outOfStock").Length() > 0 {
+		return AvailabilityOutOfStock, 0
+	}
+
+	text := strings.ToLower(strings.TrimSpace(doc.Find("#availability").First().Text()))
+	if matches := amazonStockRe.FindStringSubmatch(text); len(matches) > 1 {
+		if quantity, err := strconv.Atoi(matches[1]); err == nil && quantity > 0 {
+			return AvailabilityLimited, quantity
+		}
+	}
+	switch {
+	case strings.Contains(text, "indisponível"),
+		strings.Contains(text, "não disponível"),
+		strings.Contains(text, "esgotado"):
+		return AvailabilityOutOfStock, 0
+	case strings.Contains(text, "em estoque"),
+		strings.Contains(text, "disponível"):
+		return AvailabilityInStock, 0
+	}
+
+	if doc.Find("#add-to-cart-button, #buy-now-button").Length() > 0 {
+		return AvailabilityInStock, 0
+	}
+	return AvailabilityUnknown, 0
+}
+
+// ProductKey identifica o produto pelo ASIN da URL (ex: AMZ:B0C1234567)
+func (a *AmazonScraper) ProductKey(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	if matches := amazonASINRe.FindStringSubmatch(u.Path); len(matches) > 1 {
+		return "AMZ:" + matches[1], true
+	}
+	return "", false
+}
+
+// cleanURL reduz URLs de produto da Amazon para a forma canônica https://www.amazon.com.br/dp/<ASIN>
+// Links encurtados (amzn.to) são mantidos como estão até serem resolvidos pelo redirecionamento
+func (a *AmazonScraper) cleanURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	if matches := amazonASINRe.FindStringSubmatch(u.Path); len(matches) > 1 {
+		return "https://www.amazon.com.br/dp/" + matches[1]
+	}
+
+	u.Fragment = ""
+	return u.String()
+}
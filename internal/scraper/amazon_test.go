@@ -0,0 +1,183 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+func TestAmazonScrape(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		name          string
+		price         int64
+		originalPrice int64
+		discount      float64
+		availability  Availability
+		stock         int
+		installments  models.Installments
+		freeShipping  bool
+		source        string
+	}{
+		{
+			fixture:       "amazon_coreprice.html",
+			name:          "Fone de Ouvido Bluetooth com Cancelamento de Ruído",
+			price:         129990,
+			originalPrice: 159900,
+			discount:      19,
+			availability:  AvailabilityInStock,
+			installments:  models.Installments{Count: 10, Value: money.BRL(12999), InterestFree: true},
+			freeShipping:  true,
+			source:        "#corePriceDisplay_desktop_feature_div .priceToPay .a-offscreen",
+		},
+		{
+			fixture:       "amazon_priceblock.html",
+			name:          "Cafeteira Expresso 15 Bar",
+			price:         24990,
+			originalPrice: 29990,
+			discount:      17,
+			availability:  AvailabilityInStock,
+			source:        "#priceblock_ourprice",
+		},
+		{
+			fixture:      "amazon_split.html",
+			name:         "Aspirador Robô Wi-Fi",
+			price:        109950,
+			availability: AvailabilityInStock,
+			source:       ".a-price-whole",
+		},
+		{
+			fixture:      "amazon_limited.html",
+			name:         "O Senhor dos Anéis: Volume Único",
+			price:        8990,
+			availability: AvailabilityLimited,
+			stock:        3,
+			source:       "#corePriceDisplay_desktop_feature_div .priceToPay .a-offscreen",
+		},
+		{
+			fixture:      "amazon_out_of_stock.html",
+			name:         "Console de Videogame 1TB",
+			availability: AvailabilityOutOfStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			a := NewAmazonScraper(nil)
+			a.client = newStoreServer(t, serveFixture(t, tt.fixture))
+
+			snapshot, err := a.Scrape(context.Background(), "https://www.amazon.com.br/Produto/dp/B0C1234567/ref=sr_1_1?tag=afiliado-20")
+			if err != nil {
+				t.Fatalf("Scrape retornou erro: %v", err)
+			}
+
+			if snapshot.URL != "https://www.amazon.com.br/dp/B0C1234567" {
+				t.Errorf("URL = %q, esperado a URL canônica", snapshot.URL)
+			}
+			if snapshot.Name != tt.name {
+				t.Errorf("Name = %q, esperado %q", snapshot.Name, tt.name)
+			}
+			if snapshot.CurrentPrice.Cents != tt.price {
+				t.Errorf("CurrentPrice = %d, esperado %d", snapshot.CurrentPrice.Cents, tt.price)
+			}
+			if snapshot.OriginalPrice.Cents != tt.originalPrice {
+				t.Errorf("OriginalPrice = %d, esperado %d", snapshot.OriginalPrice.Cents, tt.originalPrice)
+			}
+			if int(snapshot.Discount) != int(tt.discount) {
+				t.Errorf("Discount = %.1f, esperado %.1f", snapshot.Discount, tt.discount)
+			}
+			if snapshot.Availability != tt.availability {
+				t.Errorf("Availability = %q, esperado %q", snapshot.Availability, tt.availability)
+			}
+			if snapshot.StockQuantity != tt.stock {
+				t.Errorf("StockQuantity = %d, esperado %d", snapshot.StockQuantity, tt.stock)
+			}
+			if snapshot.Installments != tt.installments {
+				t.Errorf("Installments = %+v, esperado %+v", snapshot.Installments, tt.installments)
+			}
+			if snapshot.Shipping.Free != tt.freeShipping {
+				t.Errorf("Shipping.Free = %v, esperado %v", snapshot.Shipping.Free, tt.freeShipping)
+			}
+			if snapshot.Extraction.PriceSource != tt.source {
+				t.Errorf("PriceSource = %q, esperado %q", snapshot.Extraction.PriceSource, tt.source)
+			}
+		})
+	}
+}
+
+func TestAmazonScrapeCaptcha(t *testing.T) {
+	a := NewAmazonScraper(nil)
+	a.client = newStoreServer(t, serveFixture(t, "amazon_captcha.html"))
+
+	_, err := a.Scrape(context.Background(), "https://www.amazon.com.br/dp/B0C1234567")
+	if err == nil || !strings.Contains(err.Error(), "captcha") {
+		t.Fatalf("Scrape retornou erro %v, esperado o erro de captcha", err)
+	}
+}
+
+func TestAmazonScrapeShortLink(t *testing.T) {
+	// O link encurtado redireciona para a página do produto com parâmetros de afiliado
+	page := serveFixture(t, "amazon_coreprice.html")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "amzn.to" {
+			http.Redirect(w, r, "https://www.amazon.com.br/Fone-Bluetooth/dp/B0C1234567?tag=afiliado-20&linkCode=ll1", http.StatusMovedPermanently)
+			return
+		}
+		page(w, r)
+	})
+	a := NewAmazonScraper(nil)
+	a.client = newStoreServer(t, handler)
+
+	if !a.CanHandle("https://amzn.to/3AbCdEf") {
+		t.Fatal("CanHandle(amzn.to) = false, esperado true")
+	}
+	snapshot, err := a.Scrape(context.Background(), "https://amzn.to/3AbCdEf")
+	if err != nil {
+		t.Fatalf("Scrape retornou erro: %v", err)
+	}
+	if snapshot.URL != "https://www.amazon.com.br/dp/B0C1234567" {
+		t.Errorf("URL = %q, esperado a URL canônica do produto", snapshot.URL)
+	}
+	if snapshot.CurrentPrice.Cents != 129990 {
+		t.Errorf("CurrentPrice = %d, esperado 129990", snapshot.CurrentPrice.Cents)
+	}
+}
+
+func TestAmazonProductKey(t *testing.T) {
+	const want = "AMZ:B0C1234567"
+
+	// O link encurtado redireciona para a página do produto com parâmetros de afiliado
+	redirects := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "amzn.to" {
+			http.Redirect(w, r, "https://www.amazon.com.br/Fone-Bluetooth/dp/B0C1234567?tag=afiliado-20&linkCode=ll1", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	registry := NewRegistry(Options{})
+	registry.resolver = newStoreServer(t, redirects)
+	registry.resolver.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	urls := []string{
+		"https://www.amazon.com.br/dp/B0C1234567",
+		"https://www.amazon.com.br/Fone-Bluetooth/dp/B0C1234567/ref=sr_1_1?keywords=fone",
+		"https://amazon.com.br/gp/product/B0C1234567?th=1",
+		"https://www.amazon.com.br/gp/aw/d/B0C1234567",
+		"https://amzn.to/3AbCdEf",
+	}
+	for _, rawURL := range urls {
+		resolved, err := registry.ResolveURL(context.Background(), rawURL)
+		if err != nil {
+			t.Fatalf("ResolveURL(%q) retornou erro: %v", rawURL, err)
+		}
+		if key := registry.ProductKey(resolved); key != want {
+			t.Errorf("ProductKey(%q) = %q (resolvida: %q), esperado %q", rawURL, key, resolved, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CasasBahiaScraper implementa o scraper para a Casas Bahia
+type CasasBahiaScraper struct {
+	client *http.Client
+}
+
+// NewCasasBahiaScraper cria uma nova instância do scraper da Casas Bahia
+func NewCasasBahiaScraper(limiter *HostLimiter) *CasasBahiaScraper {
+	return &CasasBahiaScraper{client: newHTTPClient(limiter)}
+}
+
+// CanHandle verifica se o scraper pode lidar com a URL fornecida
+func (c *CasasBahiaScraper) CanHandle(url string) bool {
+	return matchesHost(url, "casasbahia.com.br")
+}
+
+// ProductKey identifica o produto pela URL sem parâmetros, a mesma consultada por Scrape
+func (c *CasasBahiaScraper) ProductKey(url string) (string, bool) {
+	return urlKey(strings.Split(url, "?")[0]), true
+}
+
+// Scrape baixa a página do produto uma única vez e extrai nome, preços à vista e no cartão e disponibilidade
+// A ordem de preferência é: estado do Next.js, JSON-LD e, por último, seletores CSS
+func (c *CasasBahiaScraper) Scrape(ctx context.Context, url string) (ProductSnapshot, error) {
+	start := time.Now()
+	cleanURL := strings.Split(url, "?")[0]
+
+	snapshot := ProductSnapshot{
+		URL:       cleanURL,
+		Currency:  "BRL",
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "casasbahia",
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, c.client, cleanURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	if !c.extractNextData(doc, &snapshot) && !c.extractJSONLD(doc, &snapshot) && !c.extractSelectors(doc, &snapshot) {
+		// Produtos esgotados podem não exibir preço; a indisponibilidade é registrada sem erro
+		name, soldOut := jsonLDOutOfStock(doc)
+		if !soldOut {
+			return snapshot, fmt.Errorf("preço não encontrado na página")
+		}
+		snapshot.Name = name
+		snapshot.Availability = AvailabilityOutOfStock
+	}
+
+	if snapshot.Name == "" {
+		snapshot.Name = "Produto sem nome"
+	}
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// extractNextData lê a oferta do estado do Next.js
+// Exemplo: "sellPrice": {"priceValue": 2099.9, "pixPrice": 1889.91, "listPrice": 2599.9,
+// "installment": {"quantity": 10, "value": 209.99, "hasInterest": false}}
+func (c *CasasBahiaScraper) extractNextData(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	data, ok := extractNextData(doc)
+	if !ok {
+		return false
+	}
+
+	prices := findObject(data, func(obj map[string]any) bool {
+		return hasKeys(obj, "priceValue") && (obj["pixPrice"] != nil || obj["listPrice"] != nil)
+	})
+	if prices == nil {
+		return false
+	}
+
+	cash := jsonMoneyField(prices, money.DefaultCurrency, "pixPrice", "cashPrice")
+	card := jsonMoneyField(prices, money.DefaultCurrency, "priceValue")
+	original := jsonMoneyField(prices, money.DefaultCurrency, "listPrice", "fromPrice")
+	if !cash.IsPositive() && !card.IsPositive() {
+		return false
+	}
+
+	product := findObject(data, func(obj map[string]any) bool {
+		return hasKeys(obj, "name", "sku") || hasKeys(obj, "name", "skuId")
+	})
+	if product != nil {
+		snapshot.Name = strings.TrimSpace(jsonString(product, "name"))
+		if available, ok := jsonBool(product, "available"); ok {
+			snapshot.Availability = AvailabilityOutOfStock
+			if available {
+				snapshot.Availability = AvailabilityInStock
+			}
+		}
+	}
+	applyPrices(snapshot, cash, card, original)
+	for _, key := range []string{"installment", "bestInstallment"} {
+		if installment, ok := prices[key].(map[string]any); ok {
+			snapshot.Installments = jsonInstallments(installment, money.DefaultCurrency)
+			break
+		}
+	}
+	snapshot.Extraction.PriceSource = "__NEXT_DATA__"
+	return true
+}
+
+// extractJSONLD usa o Product publicado em JSON-LD, que traz apenas o preço à vista
+func (c *CasasBahiaScraper) extractJSONLD(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	product, ok := extractJSONLDProduct(doc)
+	if !ok {
+		return false
+	}
+
+	snapshot.Name = product.Name
+	snapshot.Availability = product.Availability
+	snapshot.Shipping = product.Shipping
+	applyPrices(snapshot, product.Price, money.Money{}, money.Money{})
+	snapshot.Extraction.PriceSource = "json-ld"
+	return true
+}
+
+// extractSelectors lê os preços exibidos na página quando não há dados estruturados
+func (c *CasasBahiaScraper) extractSelectors(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	cashText := strings.TrimSpace(doc.Find("#product-price-pix, [data-testid='product-pix-price']").First().Text())
+	cardText := strings.TrimSpace(doc.Find("#product-price, [data-testid='product-price']").First().Text())
+
+	cash, _ := parsePrice(cashText)
+	card, _ := parsePrice(cardText)
+	if !cash.IsPositive() && !card.IsPositive() {
+		return false
+	}
+
+	original, _ := parsePrice(strings.TrimSpace(doc.Find("#product-original-price, [data-testid='product-original-price']").First().Text()))
+
+	snapshot.Name = strings.TrimSpace(doc.Find("h1").First().Text())
+	if doc.Find("[data-testid='product-unavailable']").Length() > 0 {
+		snapshot.Availability = AvailabilityOutOfStock
+	}
+	applyPrices(snapshot, cash, card, original)
+	snapshot.Installments = installmentsFromText(doc.Find("#product-installment, [data-testid='product-installment']").First().Text())
+	snapshot.Extraction.PriceSource = "#product-price"
+	return true
+}
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"net/http"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+func TestCasasBahiaScrape(t *testing.T) {
+	newScraper := func(client *http.Client) Scraper {
+		c := NewCasasBahiaScraper(nil)
+		c.client = client
+		return c
+	}
+
+	runPageCases(t, "https://www.casasbahia.com.br/lavadora-de-roupas-12kg/p/55012345?utm_medium=cpc", newScraper, []pageCase{
+		{
+			fixture:      "casasbahia_nextdata.html",
+			name:         "Lavadora de Roupas 12kg",
+			cash:         188991,
+			card:         209990,
+			current:      188991,
+			original:     259990,
+			installments: models.Installments{Count: 10, Value: money.BRL(20999), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "__NEXT_DATA__",
+		},
+		{
+			// Com várias ofertas, vale a mais barata, com o seu frete
+			fixture:      "casasbahia_jsonld.html",
+			name:         "Micro-ondas 30L Inox",
+			cash:         68990,
+			current:      68990,
+			availability: AvailabilityInStock,
+			shipping:     models.Shipping{Cost: money.BRL(2990)},
+			source:       "json-ld",
+		},
+		{
+			fixture:      "casasbahia_selectors.html",
+			name:         "Smartphone 128GB 5G",
+			cash:         134910,
+			card:         149900,
+			current:      134910,
+			original:     179900,
+			installments: models.Installments{Count: 10, Value: money.BRL(14990), InterestFree: true},
+			source:       "#product-price",
+		},
+		{
+			fixture:      "casasbahia_out_of_stock.html",
+			name:         "Fogão 4 Bocas Branco",
+			availability: AvailabilityOutOfStock,
+		},
+	})
+}
@@ -0,0 +1,287 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+var declarativeDiscountRe = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*%?`)
+
+// StoreConfig descreve uma loja cujo scraper é montado a partir do arquivo de configuração
+type StoreConfig struct {
+	Name     string   `json:"name"`
+	Hosts    []string `json:"hosts"`    // Domínios aceitos; "*.loja.com.br" também aceita subdomínios
+	Locale   string   `json:"locale"`   // Formato dos preços: "pt-BR" (1.299,90, padrão), "en-US" (1,299.90) etc.
+	Currency string   `json:"currency"` // Código ISO 4217, padrão BRL
+
+	// JSONScripts são os seletores dos <script> com JSON consultados pelos caminhos "json" dos campos
+	// Exemplo: "script#__NEXT_DATA__" ou "script[type='application/ld+json']"
+	JSONScripts []string `json:"json_scripts"`
+
+	Fields StoreFields `json:"fields"`
+}
+
+// StoreFields agrupa as regras de extração de cada campo do produto
+type StoreFields struct {
+	Name          FieldRule `json:"name"`
+	Price         FieldRule `json:"price"`
+	OriginalPrice FieldRule `json:"original_price"`
+	Discount      FieldRule `json:"discount"`
+	Shipping      FieldRule `json:"shipping"`     // Texto como "Frete grátis" ou "Frete: R$ 19,90"
+	Installments  FieldRule `json:"installments"` // Texto como "10x de R$ 129,90 sem juros"
+}
+
+// FieldRule lista onde procurar um campo; a primeira regra que encontrar um valor é usada
+// Caminhos JSON são tentados antes dos seletores CSS
+type FieldRule struct {
+	JSON      []string `json:"json"`      // Caminhos separados por ponto (ex: "props.pageProps.product.price", "offers.0.price")
+	Selectors []string `json:"selectors"` // Seletores CSS
+	Attr      string   `json:"attr"`      // Atributo lido nos seletores (ex: "content"); vazio usa o texto
+}
+
+// storesFile é o formato do arquivo de configuração de scrapers
+type storesFile struct {
+	Stores []StoreConfig `json:"stores"`
+}
+
+// LoadStoreConfigs lê e valida o arquivo JSON de scrapers configuráveis
+func LoadStoreConfigs(path string) ([]StoreConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file storesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %v", path, err)
+	}
+
+	for i, store := range file.Stores {
+		if err := store.validate(); err != nil {
+			return nil, fmt.Errorf("loja %d (%s): %v", i+1, store.Name, err)
+		}
+	}
+	return file.Stores, nil
+}
+
+func (c StoreConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name é obrigatório")
+	}
+	if len(c.Hosts) == 0 {
+		return fmt.Errorf("informe ao menos um host")
+	}
+	if len(c.Fields.Price.JSON) == 0 && len(c.Fields.Price.Selectors) == 0 {
+		return fmt.Errorf("fields.price precisa de ao menos um caminho JSON ou seletor")
+	}
+	if len(c.Fields.Price.JSON)+len(c.Fields.Name.JSON)+len(c.Fields.OriginalPrice.JSON)+len(c.Fields.Discount.JSON)+len(c.Fields.Shipping.JSON)+len(c.Fields.Installments.JSON) > 0 && len(c.JSONScripts) == 0 {
+		return fmt.Errorf("caminhos JSON exigem json_scripts")
+	}
+	if _, ok := money.LookupLocale(c.Locale); c.Locale != "" && !ok {
+		return fmt.Errorf("locale %q não suportado (use pt-BR, en-US, de-DE, es-ES, fr-FR ou de-CH)", c.Locale)
+	}
+	// goquery ignora seletores inválidos em silêncio; validar aqui evita uma loja que nunca encontra nada
+	for _, selector := range c.allSelectors() {
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("seletor inválido %q: %v", selector, err)
+		}
+	}
+	return nil
+}
+
+func (c StoreConfig) allSelectors() []string {
+	selectors := append([]string{}, c.JSONScripts...)
+	for _, rule := range []FieldRule{c.Fields.Name, c.Fields.Price, c.Fields.OriginalPrice, c.Fields.Discount, c.Fields.Shipping, c.Fields.Installments} {
+		selectors = append(selectors, rule.Selectors...)
+	}
+	return selectors
+}
+
+// DeclarativeScraper implementa um scraper definido em StoreConfig
+type DeclarativeScraper struct {
+	config StoreConfig
+	locale money.Locale
+	client *http.Client
+}
+
+// NewDeclarativeScraper cria um scraper a partir da configuração de uma loja
+func NewDeclarativeScraper(config StoreConfig, limiter *HostLimiter) *DeclarativeScraper {
+	locale, ok := money.LookupLocale(config.Locale)
+	if !ok {
+		locale = money.PtBR
+	}
+	if config.Currency == "" {
+		config.Currency = money.DefaultCurrency
+	}
+	return &DeclarativeScraper{config: config, locale: locale, client: newHTTPClient(limiter)}
+}
+
+// CanHandle verifica se a URL pertence a um dos hosts configurados
+// "loja.com.br" aceita também "www.loja.com.br"; "*.loja.com.br" aceita qualquer subdomínio
+func (d *DeclarativeScraper) CanHandle(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, pattern := range d.config.Hosts {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		} else if host == pattern || host == "www."+pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrape baixa a página uma única vez e aplica as regras configuradas
+func (d *DeclarativeScraper) Scrape(ctx context.Context, rawURL string) (ProductSnapshot, error) {
+	start := time.Now()
+	cleanURL := strings.Split(rawURL, "#")[0]
+
+	snapshot := ProductSnapshot{
+		URL:       cleanURL,
+		Currency:  d.config.Currency,
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "config:" + d.config.Name,
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, d.client, cleanURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	scripts := d.jsonScripts(doc)
+
+	priceText, source := d.extractField(doc, scripts, d.config.Fields.Price)
+	if priceText == "" {
+		return snapshot, fmt.Errorf("preço não encontrado na página (loja %s)", d.config.Name)
+	}
+	price, err := money.Parse(priceText, d.locale, d.config.Currency)
+	if err != nil || !price.IsPositive() {
+		return snapshot, fmt.Errorf("erro ao parsear preço '%s' (loja %s)", priceText, d.config.Name)
+	}
+	snapshot.CurrentPrice = price
+	snapshot.Extraction.PriceSource = source
+
+	if discountText, _ := d.extractField(doc, scripts, d.config.Fields.Discount); discountText != "" {
+		if matches := declarativeDiscountRe.FindStringSubmatch(discountText); len(matches) > 1 {
+			if discount, err := strconv.ParseFloat(strings.ReplaceAll(matches[1], ",", "."), 64); err == nil && discount <= 100 {
+				snapshot.Discount = discount
+			}
+		}
+	}
+	if originalText, _ := d.extractField(doc, scripts, d.config.Fields.OriginalPrice); originalText != "" {
+		if original, err := money.Parse(originalText, d.locale, d.config.Currency); err == nil {
+			setOriginalPrice(&snapshot, original)
+		}
+	}
+
+	if shippingText, _ := d.extractField(doc, scripts, d.config.Fields.Shipping); shippingText != "" {
+		snapshot.Shipping = shippingFromText(shippingText)
+		if !snapshot.Shipping.Known() {
+			// Valores numéricos vindos do JSON não têm "R$"
+			if cost, err := money.Parse(shippingText, d.locale, d.config.Currency); err == nil {
+				snapshot.Shipping = models.Shipping{Cost: cost, Free: cost.IsZero()}
+			}
+		}
+	}
+
+	if installmentsText, _ := d.extractField(doc, scripts, d.config.Fields.Installments); installmentsText != "" {
+		snapshot.Installments = installmentsFromText(installmentsText)
+	}
+
+	snapshot.Name, _ = d.extractField(doc, scripts, d.config.Fields.Name)
+	if snapshot.Name == "" {
+		snapshot.Name = "Produto sem nome"
+	}
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// jsonScripts decodifica os blocos JSON da página indicados em json_scripts
+func (d *DeclarativeScraper) jsonScripts(doc *goquery.Document) []any {
+	var scripts []any
+	for _, selector := range d.config.JSONScripts {
+		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+			var data any
+			if err := json.Unmarshal([]byte(s.Text()), &data); err == nil {
+				scripts = append(scripts, decodeNestedJSON(data))
+			}
+		})
+	}
+	return scripts
+}
+
+// extractField aplica uma regra e retorna o texto encontrado e sua origem
+func (d *DeclarativeScraper) extractField(doc *goquery.Document, scripts []any, rule FieldRule) (string, string) {
+	for _, path := range rule.JSON {
+		for _, data := range scripts {
+			if value, ok := lookupJSONPath(data, path); ok {
+				return value, "json:" + path
+			}
+		}
+	}
+
+	for _, selector := range rule.Selectors {
+		s := doc.Find(selector).First()
+		text := strings.TrimSpace(s.Text())
+		if rule.Attr != "" {
+			text = strings.TrimSpace(s.AttrOr(rule.Attr, ""))
+		}
+		if text != "" {
+			return strings.Join(strings.Fields(text), " "), selector
+		}
+	}
+	return "", ""
+}
+
+// lookupJSONPath segue um caminho separado por pontos (índices numéricos acessam listas)
+// e retorna o valor encontrado como texto
+func lookupJSONPath(value any, path string) (string, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return "", false
+			}
+			value = v[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), strings.TrimSpace(v) != ""
+	case float64:
+		// Duas casas decimais evitam que "1234.567" seja lido como milhar em locales como pt-BR
+		return strconv.FormatFloat(v, 'f', 2, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,248 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// validStoreConfig retorna uma configuração válida, alterada por cada caso de teste
+func validStoreConfig() StoreConfig {
+	return StoreConfig{
+		Name:        "Loja Configurada",
+		Hosts:       []string{"loja.example"},
+		JSONScripts: []string{"script#__NEXT_DATA__"},
+		Fields: StoreFields{
+			Name:  FieldRule{Selectors: []string{"h1.product-title"}},
+			Price: FieldRule{JSON: []string{"props.pageProps.product.price"}, Selectors: []string{".price"}},
+		},
+	}
+}
+
+func TestStoreConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *StoreConfig)
+		wantErr string
+	}{
+		{"válida", func(c *StoreConfig) {}, ""},
+		{"sem nome", func(c *StoreConfig) { c.Name = "" }, "name é obrigatório"},
+		{"sem hosts", func(c *StoreConfig) { c.Hosts = nil }, "host"},
+		{"sem regra de preço", func(c *StoreConfig) { c.Fields.Price = FieldRule{} }, "fields.price"},
+		{"caminho JSON sem json_scripts", func(c *StoreConfig) { c.JSONScripts = nil }, "json_scripts"},
+		{"locale desconhecido", func(c *StoreConfig) { c.Locale = "xx-YY" }, "locale"},
+		{"locale conhecido", func(c *StoreConfig) { c.Locale = "en-US" }, ""},
+		{"seletor de preço inválido", func(c *StoreConfig) { c.Fields.Price.Selectors = []string{".price["} }, "seletor inválido"},
+		{"seletor de outro campo inválido", func(c *StoreConfig) { c.Fields.Installments.Selectors = []string{"div >> span"} }, "seletor inválido"},
+		{"seletor de json_scripts inválido", func(c *StoreConfig) { c.JSONScripts = []string{"script#"} }, "seletor inválido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validStoreConfig()
+			tt.change(&config)
+			err := config.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate retornou erro: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate = %v, esperado erro com %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadStoreConfigs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrapers.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"stores": [{"name": "Loja", "hosts": ["loja.example"], "fields": {"price": {"selectors": [".price"]}}}]}`)
+	stores, err := LoadStoreConfigs(path)
+	if err != nil {
+		t.Fatalf("LoadStoreConfigs retornou erro: %v", err)
+	}
+	if len(stores) != 1 || stores[0].Name != "Loja" || stores[0].Fields.Price.Selectors[0] != ".price" {
+		t.Errorf("LoadStoreConfigs = %+v, esperado a loja do arquivo", stores)
+	}
+
+	// Uma loja inválida rejeita o arquivo inteiro, indicando qual loja
+	write(`{"stores": [
+		{"name": "Loja", "hosts": ["loja.example"], "fields": {"price": {"selectors": [".price"]}}},
+		{"name": "Outra", "hosts": ["outra.example"], "fields": {"price": {"selectors": ["span[data-price"]}}}
+	]}`)
+	if _, err := LoadStoreConfigs(path); err == nil || !strings.Contains(err.Error(), "loja 2 (Outra)") {
+		t.Errorf("LoadStoreConfigs = %v, esperado o erro da loja 2", err)
+	}
+
+	write(`{"stores": [`)
+	if _, err := LoadStoreConfigs(path); err == nil {
+		t.Error("LoadStoreConfigs aceitou um JSON inválido")
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	data := map[string]any{
+		"product": map[string]any{
+			"name":   " Cadeira Gamer ",
+			"price":  1234.567,
+			"empty":  "",
+			"stock":  true,
+			"offers": []any{map[string]any{"price": "1.249,90"}, map[string]any{"price": 1279.9}},
+		},
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"product.name", "Cadeira Gamer", true},
+		{"product.price", "1234.57", true},
+		{"product.offers.0.price", "1.249,90", true},
+		{"product.offers.1.price", "1279.90", true},
+		{"product.offers.2.price", "", false},
+		{"product.offers.-1.price", "", false},
+		{"product.offers.first.price", "", false},
+		{"product.missing", "", false},
+		{"product.empty", "", false},
+		{"product.stock", "", false},
+		{"product.name.first", "", false},
+		{"product", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := lookupJSONPath(data, tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("lookupJSONPath(%q) = %q, %v; esperado %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDeclarativeCanHandle(t *testing.T) {
+	s := NewDeclarativeScraper(StoreConfig{Name: "Loja", Hosts: []string{"loja.example", " *.Outra.example "}}, nil)
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://loja.example/produto/1", true},
+		{"https://www.loja.example/produto/1", true},
+		{"https://WWW.LOJA.EXAMPLE/produto/1", true},
+		{"https://m.loja.example/produto/1", false},
+		{"https://loja.example.evil.example/produto/1", false},
+		{"https://outra.example/produto/1", true},
+		{"https://m.outra.example/produto/1", true},
+		{"https://a.b.outra.example/produto/1", true},
+		{"https://naooutra.example/produto/1", false},
+		{"https://outra.example.evil.example/produto/1", false},
+	}
+
+	for _, tt := range tests {
+		if got := s.CanHandle(tt.url); got != tt.want {
+			t.Errorf("CanHandle(%q) = %v, esperado %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDeclarativeScraper(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      FieldRule
+		wantPrice  int64
+		wantSource string
+	}{
+		{
+			"caminho JSON antes dos seletores",
+			FieldRule{JSON: []string{"props.pageProps.product.price"}, Selectors: []string{".price"}},
+			129990, "json:props.pageProps.product.price",
+		},
+		{
+			"próximo caminho JSON quando o primeiro não existe",
+			FieldRule{JSON: []string{"props.pageProps.product.salePrice", "props.pageProps.offers.0.price"}},
+			124990, "json:props.pageProps.offers.0.price",
+		},
+		{
+			"seletor quando nenhum caminho JSON existe",
+			FieldRule{JSON: []string{"props.pageProps.product.salePrice"}, Selectors: []string{".price"}},
+			119990, ".price",
+		},
+		{
+			"próximo seletor quando o primeiro não encontra nada",
+			FieldRule{Selectors: []string{".price-sale", ".price"}},
+			119990, ".price",
+		},
+		{
+			"atributo do seletor",
+			FieldRule{Selectors: []string{"meta[itemprop='price']"}, Attr: "content"},
+			118990, "meta[itemprop='price']",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validStoreConfig()
+			config.Fields.Price = tt.price
+			config.Fields.OriginalPrice = FieldRule{JSON: []string{"props.pageProps.product.listPrice"}, Selectors: []string{".price-old"}}
+			config.Fields.Discount = FieldRule{Selectors: []string{".discount"}}
+			config.Fields.Shipping = FieldRule{Selectors: []string{".shipping"}}
+			config.Fields.Installments = FieldRule{Selectors: []string{".installments"}}
+			if err := config.validate(); err != nil {
+				t.Fatalf("configuração inválida: %v", err)
+			}
+
+			s := NewDeclarativeScraper(config, nil)
+			s.client = newStoreServer(t, serveFixture(t, "declarative_store.html"))
+
+			snapshot, err := s.Scrape(context.Background(), "https://loja.example/produto/1#detalhes")
+			if err != nil {
+				t.Fatalf("Scrape retornou erro: %v", err)
+			}
+			if snapshot.CurrentPrice.Cents != tt.wantPrice {
+				t.Errorf("CurrentPrice = %d, esperado %d", snapshot.CurrentPrice.Cents, tt.wantPrice)
+			}
+			if snapshot.Extraction.PriceSource != tt.wantSource {
+				t.Errorf("PriceSource = %q, esperado %q", snapshot.Extraction.PriceSource, tt.wantSource)
+			}
+			if snapshot.Extraction.Scraper != "config:Loja Configurada" {
+				t.Errorf("Scraper = %q, esperado o nome da loja configurada", snapshot.Extraction.Scraper)
+			}
+			if snapshot.Name != "Cadeira Gamer Pro" {
+				t.Errorf("Name = %q, esperado o texto do h1 sem espaços extras", snapshot.Name)
+			}
+			if snapshot.OriginalPrice.Cents != 159990 || snapshot.Discount != 25 {
+				t.Errorf("OriginalPrice = %d, Discount = %.1f; esperado 159990 e 25", snapshot.OriginalPrice.Cents, snapshot.Discount)
+			}
+			if !snapshot.Shipping.Free {
+				t.Errorf("Shipping = %+v, esperado frete grátis", snapshot.Shipping)
+			}
+			want := models.Installments{Count: 10, Value: money.BRL(11999), InterestFree: true}
+			if snapshot.Installments != want {
+				t.Errorf("Installments = %+v, esperado %+v", snapshot.Installments, want)
+			}
+		})
+	}
+}
+
+func TestDeclarativeScraperPriceNotFound(t *testing.T) {
+	config := validStoreConfig()
+	config.Fields.Price = FieldRule{JSON: []string{"props.pageProps.product.salePrice"}, Selectors: []string{".price-sale"}}
+
+	s := NewDeclarativeScraper(config, nil)
+	s.client = newStoreServer(t, serveFixture(t, "declarative_store.html"))
+	if _, err := s.Scrape(context.Background(), "https://loja.example/produto/1"); err == nil || !strings.Contains(err.Error(), "preço não encontrado") {
+		t.Errorf("Scrape = %v, esperado erro de preço não encontrado", err)
+	}
+}
@@ -0,0 +1,363 @@
+package scraper
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// jsonLDProduct reúne os campos de um objeto schema.org/Product publicado em JSON-LD
+type jsonLDProduct struct {
+	Name         string
+	Price        money.Money // Preço da oferta (ou lowPrice de uma AggregateOffer)
+	HighPrice    money.Money // highPrice de uma AggregateOffer, zero se não houver
+	Currency     string
+	Availability Availability
+	Shipping     models.Shipping // shippingDetails da oferta, se houver
+}
+
+// extractJSONLDProduct procura o primeiro Product com preço nos blocos application/ld+json da página
+func extractJSONLDProduct(doc *goquery.Document) (jsonLDProduct, bool) {
+	var product jsonLDProduct
+	found := false
+
+	doc.Find("script[type='application/ld+json']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+
+		obj := findObject(data, func(obj map[string]any) bool {
+			return isSchemaType(obj["@type"], "Product") && obj["offers"] != nil
+		})
+		if obj == nil {
+			return true
+		}
+
+		product.Name = strings.TrimSpace(jsonString(obj, "name"))
+
+		// offers pode ser uma Offer, uma AggregateOffer ou uma lista de ofertas (usa-se a mais barata)
+		offers, ok := obj["offers"].([]any)
+		if !ok {
+			offers = []any{obj["offers"]}
+		}
+		for _, item := range offers {
+			offer, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			currency := jsonString(offer, "priceCurrency")
+			price := jsonMoneyField(offer, currency, "price", "lowPrice")
+			if !price.IsPositive() || (product.Price.IsPositive() && !price.Less(product.Price)) {
+				continue
+			}
+			product.Price = price
+			product.HighPrice = jsonMoneyField(offer, currency, "highPrice")
+			product.Currency = currency
+			product.Availability = schemaAvailability(jsonString(offer, "availability"))
+			product.Shipping = schemaShipping(offer["shippingDetails"], currency)
+		}
+
+		found = product.Price.IsPositive()
+		return !found
+	})
+
+	return product, found
+}
+
+// isSchemaType verifica se o @type de um objeto JSON-LD (texto ou lista) contém o tipo informado
+func isSchemaType(value any, want string) bool {
+	switch t := value.(type) {
+	case string:
+		return t == want
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaAvailability converte valores como "https://schema.org/InStock" em Availability
+func schemaAvailability(value string) Availability {
+	value = value[strings.LastIndex(value, "/")+1:]
+	switch value {
+	case "InStock", "OnlineOnly", "InStoreOnly":
+		return AvailabilityInStock
+	case "LimitedAvailability":
+		return AvailabilityLimited
+	case "OutOfStock", "SoldOut", "Discontinued":
+		return AvailabilityOutOfStock
+	}
+	return AvailabilityUnknown
+}
+
+// schemaShipping lê o valor de um OfferShippingDetails (ou de uma lista deles, usando o mais barato)
+// Exemplo: "shippingDetails": {"shippingRate": {"value": "0", "currency": "BRL"}}
+func schemaShipping(value any, currency string) models.Shipping {
+	details, ok := value.([]any)
+	if !ok {
+		details = []any{value}
+	}
+
+	var shipping models.Shipping
+	found := false
+	for _, item := range details {
+		detail, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		rate, ok := detail["shippingRate"].(map[string]any)
+		if !ok {
+			continue
+		}
+		cost, ok := jsonMoney(rate["value"], currency)
+		if !ok {
+			continue
+		}
+		if !found || cost.Less(shipping.Cost) {
+			shipping = models.Shipping{Cost: cost, Free: cost.IsZero()}
+			found = true
+		}
+	}
+	return shipping
+}
+
+// jsonLDOutOfStock verifica se o Product em JSON-LD está marcado como esgotado, mesmo sem preço
+// Retorna também o nome do produto
+func jsonLDOutOfStock(doc *goquery.Document) (string, bool) {
+	var name string
+	soldOut := false
+
+	doc.Find("script[type='application/ld+json']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+
+		obj := findObject(data, func(obj map[string]any) bool {
+			return isSchemaType(obj["@type"], "Product") && obj["offers"] != nil
+		})
+		if obj == nil {
+			return true
+		}
+
+		offers, ok := obj["offers"].([]any)
+		if !ok {
+			offers = []any{obj["offers"]}
+		}
+		for _, item := range offers {
+			offer, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			// Basta uma oferta disponível para o produto não estar esgotado
+			if schemaAvailability(jsonString(offer, "availability")) != AvailabilityOutOfStock {
+				return false
+			}
+		}
+
+		name = strings.TrimSpace(jsonString(obj, "name"))
+		soldOut = len(offers) > 0
+		return false
+	})
+
+	return name, soldOut
+}
+
+// extractNextData decodifica o estado embutido em páginas Next.js (script#__NEXT_DATA__)
+func extractNextData(doc *goquery.Document) (any, bool) {
+	text := strings.TrimSpace(doc.Find("script#__NEXT_DATA__").First().Text())
+	if text == "" {
+		return nil, false
+	}
+
+	var data any
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// decodeNestedJSON substitui textos que contêm JSON (ex: pageProps.data da KaBuM!) pelo valor decodificado
+func decodeNestedJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = decodeNestedJSON(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = decodeNestedJSON(child)
+		}
+	case string:
+		if strings.HasPrefix(v, "{") || strings.HasPrefix(v, "[") {
+			var nested any
+			if err := json.Unmarshal([]byte(v), &nested); err == nil {
+				return decodeNestedJSON(nested)
+			}
+		}
+	}
+	return value
+}
+
+// jsonPath retorna o objeto no caminho informado (ex: "props", "pageProps", "data"), ou nil
+func jsonPath(value any, keys ...string) map[string]any {
+	for _, key := range keys {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	obj, _ := value.(map[string]any)
+	return obj
+}
+
+// findObject percorre um valor JSON em profundidade e retorna o primeiro objeto aceito por match
+// As chaves são visitadas em ordem alfabética para que o resultado não dependa da ordem do map
+func findObject(value any, match func(map[string]any) bool) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		if match(v) {
+			return v
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if found := findObject(v[key], match); found != nil {
+				return found
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if found := findObject(child, match); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// hasKeys verifica se o objeto possui todas as chaves informadas
+func hasKeys(obj map[string]any, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := obj[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonString retorna o primeiro campo de texto não vazio entre as chaves informadas
+func jsonString(obj map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := obj[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// jsonMoneyField retorna o primeiro valor positivo entre as chaves informadas
+func jsonMoneyField(obj map[string]any, currency string, keys ...string) money.Money {
+	for _, key := range keys {
+		if m, ok := jsonMoney(obj[key], currency); ok && m.IsPositive() {
+			return m
+		}
+	}
+	return money.Money{}
+}
+
+// jsonMoney converte números JSON e textos como "1299.90" ou "R$ 1.299,90" em um valor na moeda informada
+func jsonMoney(value any, currency string) (money.Money, bool) {
+	switch v := value.(type) {
+	case float64:
+		return money.FromFloat(v, currency), true
+	case string:
+		if m, err := money.Parse(v, money.PtBR, currency); err == nil {
+			return m, true
+		}
+	}
+	return money.Money{}, false
+}
+
+// jsonBool interpreta campos booleanos que algumas lojas publicam como texto
+func jsonBool(obj map[string]any, key string) (bool, bool) {
+	switch v := obj[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, true
+		}
+	}
+	return false, false
+}
+
+// jsonInstallments lê um parcelamento publicado como objeto JSON
+// Exemplo: {"quantity": 10, "value": 129.9, "interestFree": true}
+func jsonInstallments(obj map[string]any, currency string) models.Installments {
+	var installments models.Installments
+	for _, key := range []string{"quantity", "count", "installments", "installmentCount", "numberOfInstallments"} {
+		switch v := obj[key].(type) {
+		case float64:
+			installments.Count = int(v)
+		case string:
+			installments.Count, _ = strconv.Atoi(strings.TrimSpace(v))
+		}
+		if installments.Count > 0 {
+			break
+		}
+	}
+	installments.Value = jsonMoneyField(obj, currency, "value", "amount", "installmentValue", "installmentPrice")
+	if !installments.Known() {
+		return models.Installments{}
+	}
+
+	if free, ok := jsonBool(obj, "interestFree"); ok {
+		installments.InterestFree = free
+	} else if free, ok := jsonBool(obj, "withoutInterest"); ok {
+		installments.InterestFree = free
+	} else if interest, ok := jsonBool(obj, "hasInterest"); ok {
+		installments.InterestFree = !interest
+	} else {
+		installments.InterestFree = strings.Contains(strings.ToLower(jsonString(obj, "description", "text")), "sem juros")
+	}
+	return installments
+}
+
+// applyPrices preenche o snapshot com os preços à vista (Pix/boleto), no cartão e original
+// O preço atual é o à vista quando a loja o informa; o desconto é calculado a partir do original
+func applyPrices(snapshot *ProductSnapshot, cash, card, original money.Money) {
+	snapshot.CashPrice = cash
+	snapshot.CardPrice = card
+
+	snapshot.CurrentPrice = cash
+	if !snapshot.CurrentPrice.IsPositive() || (card.IsPositive() && card.Less(cash)) {
+		snapshot.CurrentPrice = card
+	}
+
+	setOriginalPrice(snapshot, original)
+}
+
+// setOriginalPrice registra o preço original quando ele é maior que o atual, calculando o desconto se faltar
+func setOriginalPrice(snapshot *ProductSnapshot, original money.Money) {
+	if snapshot.CurrentPrice.IsPositive() && snapshot.CurrentPrice.Less(original) {
+		snapshot.OriginalPrice = original
+		if snapshot.Discount <= 0 {
+			snapshot.Discount = snapshot.CurrentPrice.DiscountFrom(original)
+		}
+	}
+}
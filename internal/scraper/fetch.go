@@ -0,0 +1,226 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var priceInTextRe = regexp.MustCompile(`R\$\s*([0-9.]+,[0-9]{2})`)
+
+// installmentsRe reconhece parcelamentos como "10x de R$ 129,90" ou "em até 12x R$ 99,90"
+var installmentsRe = regexp.MustCompile(`(?i)(\d{1,2})\s*x\s*(?:de\s+)?R\$\s*([0-9.]+,[0-9]{2})`)
+
+// newHTTPClient cria o cliente HTTP padrão usado pelos scrapers
+// Os scrapers criam o cliente no construtor, já que a mesma instância atende várias verificações ao mesmo tempo;
+// cada requisição espera pelo limitador do host (nil desativa o limite)
+func newHTTPClient(limiter *HostLimiter) *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: limitTransport(nil, limiter),
+	}
+}
+
+// fetchDocument baixa uma página e retorna o documento já parseado e o status HTTP
+func fetchDocument(ctx context.Context, client *http.Client, url string) (*goquery.Document, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	// Guardar a URL final, depois de seguir redirecionamentos (ex: links encurtados)
+	doc.Url = resp.Request.URL
+	return doc, resp.StatusCode, nil
+}
+
+// matchesHost verifica se a URL pertence a um dos domínios informados (incluindo subdomínios como www.)
+func matchesHost(rawURL string, domains ...string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlKey normaliza a URL para uso como chave de produto (ex: URL:loja.com.br/produto/123)
+// O host vai para minúsculas, sem "www." ou "m.", e o fragmento e a barra final são removidos
+func urlKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return "URL:" + strings.TrimSpace(rawURL)
+	}
+
+	host := strings.ToLower(u.Host)
+	host = strings.TrimPrefix(host, "www.")
+	host = strings.TrimPrefix(host, "m.")
+	key := "URL:" + host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// shippingFromText interpreta avisos de frete como "Frete grátis" ou "Frete: R$ 19,90"
+func shippingFromText(text string) models.Shipping {
+	var shipping models.Shipping
+	lower := strings.ToLower(text)
+	if strings.Contains(lower, "grátis") || strings.Contains(lower, "gratis") {
+		shipping.Free = true
+		return shipping
+	}
+	if matches := priceInTextRe.FindStringSubmatch(text); len(matches) > 1 {
+		shipping.Cost, _ = parsePrice(matches[1])
+	}
+	return shipping
+}
+
+var (
+	couponPercentRe = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*%`)
+	couponAmountRe  = regexp.MustCompile(`R\$\s*([0-9.]+(?:,[0-9]{2})?)`)
+	couponLimitRe   = regexp.MustCompile(`(?i)(?:limite|máximo|maximo|até)\s+(?:de\s+)?R\$\s*([0-9.]+(?:,[0-9]{2})?)`)
+	dealClockRe     = regexp.MustCompile(`(?i)termina\s+em\s+(\d{1,2}):(\d{2})(?::(\d{2}))?`)
+	dealDurationRe  = regexp.MustCompile(`(?i)termina\s+em\s+(\d+)\s*(dias?|d|horas?|h|minutos?|min)\b`)
+)
+
+// couponFromText interpreta o texto de um cupom (ex: "Cupom de R$ 50", "Cupom 10% OFF. Limite de R$ 100")
+// Textos sem a palavra "cupom" são ignorados para não confundir o desconto do preço com um cupom
+func couponFromText(text string) (models.Coupon, bool) {
+	var coupon models.Coupon
+	if !strings.Contains(strings.ToLower(text), "cupom") {
+		return coupon, false
+	}
+
+	// O limite é procurado antes para que o seu valor não seja lido como o desconto fixo
+	limitText := ""
+	if matches := couponLimitRe.FindStringSubmatch(text); len(matches) > 1 {
+		limitText = matches[0]
+		coupon.MaxDiscount, _ = parsePrice(matches[1])
+	}
+	if matches := couponPercentRe.FindStringSubmatch(text); len(matches) > 1 {
+		percent, err := strconv.ParseFloat(strings.ReplaceAll(matches[1], ",", "."), 64)
+		if err == nil && percent > 0 && percent <= 100 {
+			coupon.Percent = percent
+			return coupon, true
+		}
+	}
+
+	if limitText != "" {
+		text = strings.Replace(text, limitText, "", 1)
+	}
+	if matches := couponAmountRe.FindStringSubmatch(text); len(matches) > 1 {
+		if amount, err := parsePrice(matches[1]); err == nil && amount.IsPositive() {
+			return models.Coupon{Amount: amount}, true
+		}
+	}
+	return models.Coupon{}, false
+}
+
+// couponsFromSelection lê os cupons de cada elemento da seleção, ignorando repetidos
+// (banners aninhados costumam repetir o mesmo cupom)
+func couponsFromSelection(s *goquery.Selection) []models.Coupon {
+	var coupons []models.Coupon
+	seen := make(map[models.Coupon]bool)
+	s.Each(func(i int, item *goquery.Selection) {
+		coupon, ok := couponFromText(strings.Join(strings.Fields(item.Text()), " "))
+		if ok && !seen[coupon] {
+			seen[coupon] = true
+			coupons = append(coupons, coupon)
+		}
+	})
+	return coupons
+}
+
+// dealFromText identifica selos como "Oferta do dia" e "Oferta relâmpago" e o fim da contagem regressiva
+// ("Termina em 02:15:30" ou "Termina em 5 horas"), calculado a partir de now
+func dealFromText(text string, now time.Time) models.Deal {
+	var deal models.Deal
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "relâmpago"), strings.Contains(lower, "relampago"):
+		deal.Badge = "Oferta relâmpago"
+	case strings.Contains(lower, "oferta do dia"):
+		deal.Badge = "Oferta do dia"
+	default:
+		return deal
+	}
+
+	if matches := dealClockRe.FindStringSubmatch(text); len(matches) > 2 {
+		hours, _ := strconv.Atoi(matches[1])
+		minutes, _ := strconv.Atoi(matches[2])
+		seconds, _ := strconv.Atoi(matches[3])
+		deal.EndsAt = now.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+	} else if matches := dealDurationRe.FindStringSubmatch(text); len(matches) > 2 {
+		amount, _ := strconv.Atoi(matches[1])
+		unit := time.Minute
+		switch strings.ToLower(matches[2])[0] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'h':
+			unit = time.Hour
+		}
+		deal.EndsAt = now.Add(time.Duration(amount) * unit)
+	}
+	return deal
+}
+
+// installmentsFromText interpreta textos de parcelamento (ex: "em até 10x de R$ 129,90 sem juros")
+// Sem a indicação "sem juros", o parcelamento é considerado com juros
+func installmentsFromText(text string) models.Installments {
+	var installments models.Installments
+	matches := installmentsRe.FindStringSubmatch(text)
+	if len(matches) < 3 {
+		return installments
+	}
+	count, err := strconv.Atoi(matches[1])
+	if err != nil || count <= 0 {
+		return installments
+	}
+	value, err := parsePrice(matches[2])
+	if err != nil || !value.IsPositive() {
+		return installments
+	}
+	installments.Count = count
+	installments.Value = value
+	installments.InterestFree = strings.Contains(strings.ToLower(text), "sem juros")
+	return installments
+}
+
+// parsePrice converte um texto de preço no formato brasileiro (ex: "R$ 1.299,90") em reais
+func parsePrice(text string) (money.Money, error) {
+	return money.Parse(text, money.PtBR, money.DefaultCurrency)
+}
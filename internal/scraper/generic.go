@@ -0,0 +1,190 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// GenericScraper é o scraper de último recurso para lojas sem scraper dedicado
+// Lê os dados estruturados que a maioria das lojas publica: JSON-LD, meta tags OpenGraph e microdata
+type GenericScraper struct {
+	client *http.Client
+}
+
+// NewGenericScraper cria uma nova instância do scraper genérico
+func NewGenericScraper(limiter *HostLimiter) *GenericScraper {
+	return &GenericScraper{client: newHTTPClient(limiter)}
+}
+
+// CanHandle aceita qualquer URL http(s) com host; por isso o registry só o usa quando nenhum outro scraper serve
+func (g *GenericScraper) CanHandle(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != ""
+}
+
+// Scrape baixa a página uma única vez e tenta, em ordem de confiança, JSON-LD, meta tags e microdata
+func (g *GenericScraper) Scrape(ctx context.Context, rawURL string) (ProductSnapshot, error) {
+	start := time.Now()
+	pageURL := strings.Split(rawURL, "#")[0]
+
+	snapshot := ProductSnapshot{
+		URL:       pageURL,
+		Currency:  "BRL",
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "generic",
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, g.client, pageURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	if !g.extractJSONLD(doc, &snapshot) && !g.extractMeta(doc, &snapshot) && !g.extractMicrodata(doc, &snapshot) {
+		name, soldOut := jsonLDOutOfStock(doc)
+		if !soldOut {
+			return snapshot, fmt.Errorf("nenhum dado estruturado de preço encontrado na página")
+		}
+		snapshot.Name = name
+		snapshot.Availability = AvailabilityOutOfStock
+		snapshot.Extraction.Confidence = ConfidenceHigh
+	}
+
+	if snapshot.Name == "" {
+		snapshot.Name = g.extractName(doc)
+	}
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// extractJSONLD usa o Product publicado em JSON-LD, a fonte mais confiável
+func (g *GenericScraper) extractJSONLD(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	product, ok := extractJSONLDProduct(doc)
+	if !ok {
+		return false
+	}
+
+	snapshot.Name = product.Name
+	snapshot.Availability = product.Availability
+	snapshot.Shipping = product.Shipping
+	if product.Currency != "" {
+		snapshot.Currency = strings.ToUpper(product.Currency)
+	}
+	snapshot.CurrentPrice = product.Price
+	snapshot.Extraction.PriceSource = "json-ld"
+	snapshot.Extraction.Confidence = ConfidenceHigh
+	return true
+}
+
+// extractMeta usa as meta tags de preço do OpenGraph e do Facebook (product:*)
+func (g *GenericScraper) extractMeta(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	metaContent := func(names ...string) (string, string) {
+		for _, name := range names {
+			selector := fmt.Sprintf("meta[property='%s'], meta[name='%s']", name, name)
+			if content := strings.TrimSpace(doc.Find(selector).First().AttrOr("content", "")); content != "" {
+				return content, name
+			}
+		}
+		return "", ""
+	}
+
+	if currency, _ := metaContent("product:price:currency", "og:price:currency"); currency != "" {
+		snapshot.Currency = strings.ToUpper(currency)
+	}
+
+	priceText, source := metaContent("product:sale_price:amount", "product:price:amount", "og:price:amount")
+	price, ok := jsonMoney(priceText, snapshot.Currency)
+	if !ok || !price.IsPositive() {
+		return false
+	}
+
+	// Com preço promocional, product:price:amount passa a ser o preço original
+	var original money.Money
+	if source == "product:sale_price:amount" {
+		originalText, _ := metaContent("product:price:amount", "og:price:amount")
+		original, _ = jsonMoney(originalText, snapshot.Currency)
+	}
+
+	if availability, _ := metaContent("product:availability", "og:availability"); availability != "" {
+		snapshot.Availability = g.parseAvailability(availability)
+	}
+	snapshot.Name, _ = metaContent("og:title")
+	snapshot.CurrentPrice = price
+	setOriginalPrice(snapshot, original)
+	snapshot.Extraction.PriceSource = "meta[" + source + "]"
+	snapshot.Extraction.Confidence = ConfidenceMedium
+	return true
+}
+
+// extractMicrodata usa os atributos itemprop do schema.org
+// O atributo content é confiável; o texto visível do elemento, nem sempre
+func (g *GenericScraper) extractMicrodata(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	priceEl := doc.Find("[itemprop='price']").First()
+	if priceEl.Length() == 0 {
+		return false
+	}
+
+	currencyEl := doc.Find("[itemprop='priceCurrency']").First()
+	if currency := strings.TrimSpace(currencyEl.AttrOr("content", currencyEl.Text())); currency != "" {
+		snapshot.Currency = strings.ToUpper(currency)
+	}
+
+	confidence := ConfidenceMedium
+	priceText := strings.TrimSpace(priceEl.AttrOr("content", ""))
+	if priceText == "" {
+		priceText = strings.TrimSpace(priceEl.Text())
+		confidence = ConfidenceLow
+	}
+	price, ok := jsonMoney(priceText, snapshot.Currency)
+	if !ok || !price.IsPositive() {
+		return false
+	}
+	if availability := doc.Find("[itemprop='availability']").First(); availability.Length() > 0 {
+		snapshot.Availability = schemaAvailability(availability.AttrOr("href", availability.AttrOr("content", "")))
+	}
+	snapshot.Name = strings.TrimSpace(doc.Find("[itemscope] [itemprop='name']").First().Text())
+	snapshot.CurrentPrice = price
+	snapshot.Extraction.PriceSource = "[itemprop='price']"
+	snapshot.Extraction.Confidence = confidence
+	return true
+}
+
+// parseAvailability interpreta os valores das meta tags (ex: "instock", "in stock", "out of stock")
+func (g *GenericScraper) parseAvailability(value string) Availability {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	switch {
+	case strings.Contains(value, "outofstock"), strings.Contains(value, "soldout"), strings.Contains(value, "unavailable"):
+		return AvailabilityOutOfStock
+	case strings.Contains(value, "instock"), strings.Contains(value, "available"):
+		return AvailabilityInStock
+	}
+	return AvailabilityUnknown
+}
+
+// extractName usa og:title, o primeiro h1 ou o título da página
+func (g *GenericScraper) extractName(doc *goquery.Document) string {
+	if name := strings.TrimSpace(doc.Find("meta[property='og:title']").First().AttrOr("content", "")); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(doc.Find("h1").First().Text()); name != "" {
+		return strings.Join(strings.Fields(name), " ")
+	}
+	if name := strings.TrimSpace(doc.Find("title").First().Text()); name != "" {
+		return name
+	}
+	return "Produto sem nome"
+}
@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenericScraper(t *testing.T) {
+	tests := []struct {
+		fixture      string
+		name         string
+		current      int64
+		original     int64
+		availability Availability
+		freeShipping bool
+		source       string
+		confidence   Confidence
+	}{
+		// JSON-LD tem prioridade sobre as meta tags e o microdata da mesma página
+		{"generic_jsonld.html", "Liquidificador 1200W", 19990, 0, AvailabilityInStock, true, "json-ld", ConfidenceHigh},
+		// Sem JSON-LD válido, as meta tags vêm antes do microdata; o preço promocional torna product:price:amount o original
+		{"generic_meta.html", "Cafeteira Expresso", 14990, 19990, AvailabilityInStock, false, "meta[product:sale_price:amount]", ConfidenceMedium},
+		{"generic_microdata.html", "Ventilador de Mesa 40cm", 8990, 0, AvailabilityLimited, false, "[itemprop='price']", ConfidenceMedium},
+		// Preço lido do texto visível, sem o atributo content
+		{"generic_microdata_text.html", "Geladeira Frost Free 400L", 329990, 0, AvailabilityUnknown, false, "[itemprop='price']", ConfidenceLow},
+		// Produto esgotado sem preço não é erro
+		{"generic_out_of_stock.html", "Air Fryer 4L", 0, 0, AvailabilityOutOfStock, false, "", ConfidenceHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := &GenericScraper{client: newStoreServer(t, serveFixture(t, tt.fixture))}
+
+			snapshot, err := s.Scrape(context.Background(), "https://loja.example/produto/1#avaliacoes")
+			if err != nil {
+				t.Fatalf("Scrape retornou erro: %v", err)
+			}
+			if snapshot.URL != "https://loja.example/produto/1" {
+				t.Errorf("URL = %q, esperado a URL sem o fragmento", snapshot.URL)
+			}
+			if snapshot.Name != tt.name {
+				t.Errorf("Name = %q, esperado %q", snapshot.Name, tt.name)
+			}
+			if snapshot.CurrentPrice.Cents != tt.current {
+				t.Errorf("CurrentPrice = %d, esperado %d", snapshot.CurrentPrice.Cents, tt.current)
+			}
+			if snapshot.OriginalPrice.Cents != tt.original {
+				t.Errorf("OriginalPrice = %d, esperado %d", snapshot.OriginalPrice.Cents, tt.original)
+			}
+			if snapshot.Availability != tt.availability {
+				t.Errorf("Availability = %q, esperado %q", snapshot.Availability, tt.availability)
+			}
+			if snapshot.Shipping.Free != tt.freeShipping {
+				t.Errorf("Shipping = %+v, esperado frete grátis = %v", snapshot.Shipping, tt.freeShipping)
+			}
+			if snapshot.Extraction.PriceSource != tt.source {
+				t.Errorf("PriceSource = %q, esperado %q", snapshot.Extraction.PriceSource, tt.source)
+			}
+			if snapshot.Extraction.Confidence != tt.confidence {
+				t.Errorf("Confidence = %q, esperado %q", snapshot.Extraction.Confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestGenericScraperNoPrice(t *testing.T) {
+	s := &GenericScraper{client: newStoreServer(t, serveFixture(t, "generic_no_price.html"))}
+	if _, err := s.Scrape(context.Background(), "https://loja.example/"); err == nil {
+		t.Error("Scrape não retornou erro para uma página sem dados de preço")
+	}
+}
+
+func TestGenericCanHandle(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://loja.example/produto/1", true},
+		{"http://loja.example/produto/1", true},
+		{"ftp://loja.example/produto/1", false},
+		{"https:///produto/1", false},
+		{"loja.example/produto/1", false},
+	}
+
+	s := NewGenericScraper(nil)
+	for _, tt := range tests {
+		if got := s.CanHandle(tt.url); got != tt.want {
+			t.Errorf("CanHandle(%q) = %v, esperado %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bot-produtos/internal/models"
+)
+
+// newStoreServer inicia um servidor HTTPS de teste e retorna um cliente que envia a ele as requisições
+// para qualquer host, mantendo a URL original: o handler diferencia as lojas e a API por r.Host e r.URL.Path
+func newStoreServer(t *testing.T, handler http.Handler) *http.Client {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	addr := server.Listener.Addr().String()
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+		// O certificado do servidor de teste não é dos domínios das lojas
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	t.Cleanup(transport.CloseIdleConnections)
+	return &http.Client{Transport: transport, Timeout: 5 * time.Second}
+}
+
+// serveFixture responde com o arquivo de testdata informado, como HTML
+func serveFixture(t *testing.T, name string) http.HandlerFunc {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("erro ao ler %s: %v", name, err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	}
+}
+
+// pageCase descreve os dados esperados de uma página de loja servida a partir de testdata
+type pageCase struct {
+	fixture      string
+	name         string
+	cash         int64 // Preço à vista (Pix/boleto) em centavos
+	card         int64 // Preço no cartão em centavos
+	current      int64 // Preço atual em centavos
+	original     int64 // Preço original em centavos
+	installments models.Installments
+	availability Availability
+	shipping     models.Shipping
+	source       string
+}
+
+// runPageCases serve cada fixture e compara o snapshot do scraper criado por newScraper com o esperado
+func runPageCases(t *testing.T, rawURL string, newScraper func(client *http.Client) Scraper, cases []pageCase) {
+	t.Helper()
+
+	for _, tt := range cases {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := newScraper(newStoreServer(t, serveFixture(t, tt.fixture)))
+
+			snapshot, err := s.Scrape(context.Background(), rawURL)
+			if err != nil {
+				t.Fatalf("Scrape retornou erro: %v", err)
+			}
+
+			if snapshot.Name != tt.name {
+				t.Errorf("Name = %q, esperado %q", snapshot.Name, tt.name)
+			}
+			if snapshot.CashPrice.Cents != tt.cash {
+				t.Errorf("CashPrice = %d, esperado %d", snapshot.CashPrice.Cents, tt.cash)
+			}
+			if snapshot.CardPrice.Cents != tt.card {
+				t.Errorf("CardPrice = %d, esperado %d", snapshot.CardPrice.Cents, tt.card)
+			}
+			if snapshot.CurrentPrice.Cents != tt.current {
+				t.Errorf("CurrentPrice = %d, esperado %d", snapshot.CurrentPrice.Cents, tt.current)
+			}
+			if snapshot.OriginalPrice.Cents != tt.original {
+				t.Errorf("OriginalPrice = %d, esperado %d", snapshot.OriginalPrice.Cents, tt.original)
+			}
+			if snapshot.Installments != tt.installments {
+				t.Errorf("Installments = %+v, esperado %+v", snapshot.Installments, tt.installments)
+			}
+			if snapshot.Availability != tt.availability {
+				t.Errorf("Availability = %q, esperado %q", snapshot.Availability, tt.availability)
+			}
+			if snapshot.Shipping != tt.shipping {
+				t.Errorf("Shipping = %+v, esperado %+v", snapshot.Shipping, tt.shipping)
+			}
+			if snapshot.Extraction.PriceSource != tt.source {
+				t.Errorf("PriceSource = %q, esperado %q", snapshot.Extraction.PriceSource, tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// KabumScraper implementa o scraper para a KaBuM!
+type KabumScraper struct {
+	client *http.Client
+}
+
+// NewKabumScraper cria uma nova instância do scraper da KaBuM!
+func NewKabumScraper(limiter *HostLimiter) *KabumScraper {
+	return &KabumScraper{client: newHTTPClient(limiter)}
+}
+
+// CanHandle verifica se o scraper pode lidar com a URL fornecida
+func (k *KabumScraper) CanHandle(url string) bool {
+	return matchesHost(url, "kabum.com.br")
+}
+
+// ProductKey identifica o produto pela URL sem parâmetros, a mesma consultada por Scrape
+func (k *KabumScraper) ProductKey(url string) (string, bool) {
+	return urlKey(strings.Split(url, "?")[0]), true
+}
+
+// Scrape baixa a página do produto uma única vez e extrai nome, preços à vista e no cartão e disponibilidade
+// A ordem de preferência é: estado do Next.js, JSON-LD e, por último, seletores CSS
+func (k *KabumScraper) Scrape(ctx context.Context, url string) (ProductSnapshot, error) {
+	start := time.Now()
+	cleanURL := strings.Split(url, "?")[0]
+
+	snapshot := ProductSnapshot{
+		URL:       cleanURL,
+		Currency:  "BRL",
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "kabum",
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, k.client, cleanURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	if !k.extractNextData(doc, &snapshot) && !k.extractJSONLD(doc, &snapshot) && !k.extractSelectors(doc, &snapshot) {
+		// Produtos esgotados podem não exibir preço; a indisponibilidade é registrada sem erro
+		name, soldOut := jsonLDOutOfStock(doc)
+		if !soldOut {
+			return snapshot, fmt.Errorf("preço não encontrado na página")
+		}
+		snapshot.Name = name
+		snapshot.Availability = AvailabilityOutOfStock
+	}
+
+	if snapshot.Name == "" {
+		snapshot.Name = "Produto sem nome"
+	}
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// extractNextData lê o produto do estado do Next.js, onde pageProps.data é um texto JSON
+// Exemplo: "productCatalog": {"name": "...", "price": 1999.99, "priceWithDiscount": 1699.99, "oldPrice": 2499.99, "available": true,
+// "maxInstallment": "10x de R$ 199,99 sem juros"}
+func (k *KabumScraper) extractNextData(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	data, ok := extractNextData(doc)
+	if !ok {
+		return false
+	}
+	data = decodeNestedJSON(data)
+
+	isProduct := func(obj map[string]any) bool {
+		return hasKeys(obj, "name", "price", "priceWithDiscount")
+	}
+
+	product := jsonPath(data, "props", "pageProps", "data", "productCatalog")
+	if product == nil || !isProduct(product) {
+		product = findObject(data, isProduct)
+	}
+	if product == nil {
+		return false
+	}
+
+	cash := jsonMoneyField(product, money.DefaultCurrency, "priceWithDiscount")
+	card := jsonMoneyField(product, money.DefaultCurrency, "price")
+	original := jsonMoneyField(product, money.DefaultCurrency, "oldPrice")
+
+	// Durante ofertas relâmpago os preços válidos ficam no objeto "offer"
+	if offer, ok := product["offer"].(map[string]any); ok {
+		if offerCash := jsonMoneyField(offer, money.DefaultCurrency, "priceWithDiscount"); offerCash.IsPositive() {
+			cash = offerCash
+		}
+		if offerCard := jsonMoneyField(offer, money.DefaultCurrency, "price"); offerCard.IsPositive() {
+			card = offerCard
+		}
+	}
+	if !cash.IsPositive() && !card.IsPositive() {
+		return false
+	}
+
+	snapshot.Name = strings.TrimSpace(jsonString(product, "name"))
+	if available, ok := jsonBool(product, "available"); ok {
+		snapshot.Availability = AvailabilityOutOfStock
+		if available {
+			snapshot.Availability = AvailabilityInStock
+		}
+	}
+	applyPrices(snapshot, cash, card, original)
+	if installment, ok := product["installment"].(map[string]any); ok {
+		snapshot.Installments = jsonInstallments(installment, money.DefaultCurrency)
+	} else {
+		snapshot.Installments = installmentsFromText(jsonString(product, "maxInstallment"))
+	}
+	snapshot.Extraction.PriceSource = "__NEXT_DATA__"
+	return true
+}
+
+// extractJSONLD usa o Product publicado em JSON-LD, que traz apenas o preço à vista
+func (k *KabumScraper) extractJSONLD(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	product, ok := extractJSONLDProduct(doc)
+	if !ok {
+		return false
+	}
+
+	snapshot.Name = product.Name
+	snapshot.Availability = product.Availability
+	snapshot.Shipping = product.Shipping
+	applyPrices(snapshot, product.Price, money.Money{}, money.Money{})
+	snapshot.Extraction.PriceSource = "json-ld"
+	return true
+}
+
+// extractSelectors lê os preços exibidos na página quando não há dados estruturados
+func (k *KabumScraper) extractSelectors(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	cashText := strings.TrimSpace(doc.Find("h4.finalPrice").First().Text())
+	cash, err := parsePrice(cashText)
+	if cashText == "" || err != nil || !cash.IsPositive() {
+		return false
+	}
+
+	card, _ := parsePrice(strings.TrimSpace(doc.Find("b.regularPrice").First().Text()))
+	original, _ := parsePrice(strings.TrimSpace(doc.Find("span.oldPrice").First().Text()))
+
+	snapshot.Name = strings.TrimSpace(doc.Find("h1").First().Text())
+	if doc.Find("#formularioProdutoIndisponivel, .unavailablePrice").Length() > 0 {
+		snapshot.Availability = AvailabilityOutOfStock
+	} else {
+		snapshot.Availability = AvailabilityInStock
+	}
+	applyPrices(snapshot, cash, card, original)
+	// Exemplo: "Em até 10x de R$ 199,99 sem juros no cartão"
+	snapshot.Installments = installmentsFromText(doc.Find(".cardParcels").First().Text())
+	snapshot.Extraction.PriceSource = "h4.finalPrice"
+	return true
+}
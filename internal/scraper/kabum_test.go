@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"net/http"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+func TestKabumScrape(t *testing.T) {
+	newScraper := func(client *http.Client) Scraper {
+		k := NewKabumScraper(nil)
+		k.client = client
+		return k
+	}
+
+	runPageCases(t, "https://www.kabum.com.br/produto/497537/placa-de-video?utm_source=google", newScraper, []pageCase{
+		{
+			fixture:      "kabum_nextdata.html",
+			name:         "Placa de Vídeo RTX 4060 8GB",
+			cash:         169999,
+			card:         199999,
+			current:      169999,
+			original:     249999,
+			installments: models.Installments{Count: 10, Value: money.BRL(19999), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "__NEXT_DATA__",
+		},
+		{
+			// Durante a oferta relâmpago, os preços do objeto "offer" substituem os normais
+			fixture:      "kabum_flash_offer.html",
+			name:         "Monitor Gamer 27 165Hz",
+			cash:         89999,
+			card:         105881,
+			current:      89999,
+			original:     159999,
+			installments: models.Installments{Count: 10, Value: money.BRL(10588), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "__NEXT_DATA__",
+		},
+		{
+			fixture:      "kabum_jsonld.html",
+			name:         "SSD 1TB NVMe M.2",
+			cash:         44999,
+			current:      44999,
+			availability: AvailabilityLimited,
+			source:       "json-ld",
+		},
+		{
+			fixture:      "kabum_selectors.html",
+			name:         "Teclado Mecânico Gamer ABNT2",
+			cash:         25499,
+			card:         29999,
+			current:      25499,
+			original:     39999,
+			installments: models.Installments{Count: 10, Value: money.BRL(2999), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "h4.finalPrice",
+		},
+		{
+			fixture:      "kabum_out_of_stock.html",
+			name:         "Processador 8 Núcleos 4.2GHz",
+			cash:         161499,
+			card:         189999,
+			current:      161499,
+			availability: AvailabilityOutOfStock,
+			source:       "__NEXT_DATA__",
+		},
+	})
+}
@@ -0,0 +1,169 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-produtos/internal/money"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MagazineLuizaScraper implementa o scraper para a Magazine Luiza
+type MagazineLuizaScraper struct {
+	client *http.Client
+}
+
+// NewMagazineLuizaScraper cria uma nova instância do scraper da Magazine Luiza
+func NewMagazineLuizaScraper(limiter *HostLimiter) *MagazineLuizaScraper {
+	return &MagazineLuizaScraper{client: newHTTPClient(limiter)}
+}
+
+// CanHandle verifica se o scraper pode lidar com a URL fornecida
+func (m *MagazineLuizaScraper) CanHandle(url string) bool {
+	return matchesHost(url, "magazineluiza.com.br")
+}
+
+// ProductKey identifica o produto pela URL sem parâmetros, a mesma consultada por Scrape
+func (m *MagazineLuizaScraper) ProductKey(url string) (string, bool) {
+	return urlKey(strings.Split(url, "?")[0]), true
+}
+
+// Scrape baixa a página do produto uma única vez e extrai nome, preços à vista e no cartão e disponibilidade
+// A ordem de preferência é: estado do Next.js, JSON-LD e, por último, seletores CSS
+func (m *MagazineLuizaScraper) Scrape(ctx context.Context, url string) (ProductSnapshot, error) {
+	start := time.Now()
+	cleanURL := strings.Split(url, "?")[0]
+
+	snapshot := ProductSnapshot{
+		URL:       cleanURL,
+		Currency:  "BRL",
+		FetchedAt: start,
+		Extraction: Extraction{
+			Scraper: "magalu",
+		},
+	}
+
+	doc, status, err := fetchDocument(ctx, m.client, cleanURL)
+	snapshot.Extraction.StatusCode = status
+	if err != nil {
+		return snapshot, err
+	}
+
+	if !m.extractNextData(doc, &snapshot) && !m.extractJSONLD(doc, &snapshot) && !m.extractSelectors(doc, &snapshot) {
+		// Produtos esgotados podem não exibir preço; a indisponibilidade é registrada sem erro
+		name, soldOut := jsonLDOutOfStock(doc)
+		if !soldOut {
+			return snapshot, fmt.Errorf("preço não encontrado na página")
+		}
+		snapshot.Name = name
+		snapshot.Availability = AvailabilityOutOfStock
+	}
+
+	if snapshot.Name == "" {
+		snapshot.Name = "Produto sem nome"
+	}
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// extractNextData lê o produto do estado do Next.js
+// Exemplo: "price": {"bestPrice": "1899.05", "price": "1999.00", "fullPrice": "2399.00", "idPaymentMethodBestPrice": "pix",
+// "installment": {"quantity": 10, "amount": "199.90", "description": "10x de R$ 199,90 sem juros"}}
+func (m *MagazineLuizaScraper) extractNextData(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	data, ok := extractNextData(doc)
+	if !ok {
+		return false
+	}
+
+	isProduct := func(obj map[string]any) bool {
+		price, ok := obj["price"].(map[string]any)
+		return ok && hasKeys(price, "bestPrice") && obj["title"] != nil
+	}
+
+	// O produto da página fica em pageProps.data.product; a busca cobre mudanças no layout do estado
+	product := jsonPath(data, "props", "pageProps", "data", "product")
+	if product == nil || !isProduct(product) {
+		product = findObject(data, isProduct)
+	}
+	if product == nil {
+		return false
+	}
+
+	price := product["price"].(map[string]any)
+	cash := jsonMoneyField(price, money.DefaultCurrency, "bestPrice")
+	card := jsonMoneyField(price, money.DefaultCurrency, "price")
+	original := jsonMoneyField(price, money.DefaultCurrency, "fullPrice")
+	if !cash.IsPositive() && !card.IsPositive() {
+		return false
+	}
+
+	// bestPrice só é o preço à vista quando o melhor meio de pagamento é Pix ou boleto
+	method := strings.ToLower(jsonString(price, "idPaymentMethodBestPrice", "paymentMethodDescription"))
+	if method != "" && !strings.Contains(method, "pix") && !strings.Contains(method, "boleto") {
+		card, cash = cash, money.Money{}
+	}
+
+	snapshot.Name = strings.TrimSpace(jsonString(product, "title", "name"))
+	if available, ok := jsonBool(product, "available"); ok {
+		snapshot.Availability = AvailabilityOutOfStock
+		if available {
+			snapshot.Availability = AvailabilityInStock
+		}
+	}
+	applyPrices(snapshot, cash, card, original)
+	if installment, ok := price["installment"].(map[string]any); ok {
+		snapshot.Installments = jsonInstallments(installment, money.DefaultCurrency)
+	}
+	snapshot.Extraction.PriceSource = "__NEXT_DATA__"
+	return true
+}
+
+// extractJSONLD usa o Product publicado em JSON-LD, que traz apenas um preço (o à vista)
+func (m *MagazineLuizaScraper) extractJSONLD(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	product, ok := extractJSONLDProduct(doc)
+	if !ok {
+		return false
+	}
+
+	snapshot.Name = product.Name
+	snapshot.Availability = product.Availability
+	snapshot.Shipping = product.Shipping
+	applyPrices(snapshot, product.Price, money.Money{}, money.Money{})
+	snapshot.Extraction.PriceSource = "json-ld"
+	return true
+}
+
+// extractSelectors lê os preços exibidos na página quando não há dados estruturados
+func (m *MagazineLuizaScraper) extractSelectors(doc *goquery.Document, snapshot *ProductSnapshot) bool {
+	cashText := strings.TrimSpace(doc.Find("[data-testid='price-value']").First().Text())
+	cash, err := parsePrice(cashText)
+	if cashText == "" || err != nil || !cash.IsPositive() {
+		return false
+	}
+
+	// Exemplo: "ou R$ 1.999,00 em 10x de R$ 199,90 sem juros"
+	var card money.Money
+	installmentText := doc.Find("[data-testid='installment']").First().Text()
+	if matches := priceInTextRe.FindStringSubmatch(installmentText); len(matches) > 1 {
+		card, _ = parsePrice(matches[1])
+	}
+
+	original, _ := parsePrice(strings.TrimSpace(doc.Find("[data-testid='price-original']").First().Text()))
+
+	snapshot.Name = strings.TrimSpace(doc.Find("[data-testid='heading-product-title']").First().Text())
+	if snapshot.Name == "" {
+		snapshot.Name = strings.TrimSpace(doc.Find("h1").First().Text())
+	}
+	if doc.Find("[data-testid='bagButton'], [data-testid='button-buy']").Length() > 0 {
+		snapshot.Availability = AvailabilityInStock
+	}
+	applyPrices(snapshot, cash, card, original)
+	snapshot.Installments = installmentsFromText(installmentText)
+	snapshot.Extraction.PriceSource = "[data-testid='price-value']"
+	return true
+}
@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"net/http"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+func TestMagazineLuizaScrape(t *testing.T) {
+	newScraper := func(client *http.Client) Scraper {
+		m := NewMagazineLuizaScraper(nil)
+		m.client = client
+		return m
+	}
+
+	runPageCases(t, "https://www.magazineluiza.com.br/produto/p/237456800/?seller_id=magazineluiza", newScraper, []pageCase{
+		{
+			fixture:      "magalu_nextdata.html",
+			name:         `Smart TV 50" 4K UHD LED`,
+			cash:         189905,
+			card:         199900,
+			current:      189905,
+			original:     239900,
+			installments: models.Installments{Count: 10, Value: money.BRL(19990), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "__NEXT_DATA__",
+		},
+		{
+			// Quando o melhor preço é no cartão, ele não é o preço à vista
+			fixture:      "magalu_nextdata_card.html",
+			name:         `Notebook 15,6" 8GB 256GB SSD`,
+			card:         284900,
+			current:      284900,
+			original:     329900,
+			installments: models.Installments{Count: 12, Value: money.BRL(24908)},
+			availability: AvailabilityInStock,
+			source:       "__NEXT_DATA__",
+		},
+		{
+			fixture:      "magalu_jsonld.html",
+			name:         "Geladeira Frost Free 375L",
+			cash:         279990,
+			current:      279990,
+			availability: AvailabilityInStock,
+			source:       "json-ld",
+		},
+		{
+			fixture:      "magalu_selectors.html",
+			name:         "Air Fryer 4L Preta",
+			cash:         37905,
+			card:         39900,
+			current:      37905,
+			original:     49990,
+			installments: models.Installments{Count: 10, Value: money.BRL(3990), InterestFree: true},
+			availability: AvailabilityInStock,
+			source:       "[data-testid='price-value']",
+		},
+		{
+			fixture:      "magalu_out_of_stock.html",
+			name:         "Ventilador de Coluna 40cm",
+			availability: AvailabilityOutOfStock,
+		},
+	})
+}
@@ -1,76 +1,167 @@
 package scraper
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+
 	"github.com/PuerkitoBio/goquery"
 )
 
+var (
+	mlOffersPriceRe   = regexp.MustCompile(`"offers"[^}]*"price"\s*:\s*"?([0-9.]+)"?`)
+	mlAnyPriceRe      = regexp.MustCompile(`"price"\s*:\s*"?([0-9.]+)"?`)
+	mlOriginalPriceRe = regexp.MustCompile(`"(listPrice|highPrice|originalPrice)"\s*:\s*"?([0-9.]+)"?`)
+	mlDiscountRe      = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	mlNameRe          = regexp.MustCompile(`"name"\s*:\s*"([^"]+)"`)
+	mlStockRe         = regexp.MustCompile(`(\+)?\s*(\d+)\s+disponíve`)
+	mlItemIDRe        = regexp.MustCompile(`(?i)\b(MLB)-?(\d{6,})`)
+	mlInstallmentsRe  = regexp.MustCompile(`(\d{1,2})\s*x`)
+)
+
 // MercadoLivreScraper implementa o scraper para Mercado Livre
+// Os dados vêm primeiro da API pública (anúncios e produtos de catálogo) e, se ela falhar, do HTML da página
 type MercadoLivreScraper struct {
-	client *http.Client
+	client     *http.Client
+	apiBaseURL string         // Endereço da API; vazio usa apenas o HTML
+	sellers    *mlSellerCache // Vendedores já consultados em /users
 }
 
 // NewMercadoLivreScraper cria uma nova instância do scraper do Mercado Livre
-func NewMercadoLivreScraper() *MercadoLivreScraper {
-	return &MercadoLivreScraper{}
-}
-
-func (m *MercadoLivreScraper) getClient() *http.Client {
-	if m.client == nil {
-		m.client = &http.Client{
-			Timeout: 30 * time.Second,
-		}
-	}
-	return m.client
+// apiBaseURL é o endereço da API (ex: https://api.mercadolibre.com ou um servidor local nos testes); vazio desativa a API
+func NewMercadoLivreScraper(apiBaseURL string, limiter *HostLimiter) *MercadoLivreScraper {
+	return &MercadoLivreScraper{apiBaseURL: apiBaseURL, client: newHTTPClient(limiter), sellers: newMLSellerCache()}
 }
 
 // CanHandle verifica se o scraper pode lidar com a URL fornecida
-// URLs do Mercado Livre sempre começam com "https://mercadolivre.com.br/"
+// Aceita o domínio e os subdomínios do Mercado Livre (www., produto., lista...), comparando o host da URL
 func (m *MercadoLivreScraper) CanHandle(url string) bool {
-	return strings.HasPrefix(url, "https://mercadolivre.com.br/") || 
-		   strings.HasPrefix(url, "http://mercadolivre.com.br/") ||
-		   strings.Contains(url, "mercadolivre.com.br")
+	return matchesHost(url, "mercadolivre.com.br")
 }
 
-// GetPrice extrai o preço de um produto do Mercado Livre
-func (m *MercadoLivreScraper) GetPrice(url string) (float64, error) {
+// Scrape consulta a API pública do anúncio e, se ela falhar, baixa a página do produto
+// e extrai nome, preços e desconto do HTML
+func (m *MercadoLivreScraper) Scrape(ctx context.Context, url string) (ProductSnapshot, error) {
+	start := time.Now()
 	cleanURL := m.cleanURL(url)
 
-	req, err := http.NewRequest("GET", cleanURL, nil)
+	newSnapshot := func() ProductSnapshot {
+		return ProductSnapshot{
+			URL:       cleanURL,
+			Currency:  "BRL",
+			FetchedAt: start,
+			Extraction: Extraction{
+				Scraper: "mercadolivre",
+			},
+		}
+	}
+
+	snapshot := newSnapshot()
+	if m.apiBaseURL != "" {
+		pageOffer, err := m.scrapeAPI(ctx, cleanURL, &snapshot)
+		if err == nil {
+			snapshot.Extraction.StatusCode = http.StatusOK
+			// Quando a oferta mais barata é de outro vendedor, o parcelamento e os cupons da página não se aplicam a ela
+			if pageOffer {
+				m.enrichFromPage(ctx, cleanURL, &snapshot)
+			}
+			snapshot.Extraction.Duration = time.Since(start)
+			return snapshot, nil
+		}
+		snapshot = newSnapshot()
+		snapshot.Extraction.Fallback = fmt.Sprintf("API do Mercado Livre: %v", err)
+	}
+
+	doc, status, err := fetchDocument(ctx, m.client, cleanURL)
+	snapshot.Extraction.StatusCode = status
 	if err != nil {
-		return 0, err
+		return snapshot, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
+	snapshot.Name = m.extractName(doc)
+	snapshot.Availability, snapshot.StockQuantity = m.extractAvailability(doc)
+	m.extractPageExtras(doc, &snapshot)
 
-	resp, err := m.getClient().Do(req)
+	price, source, err := m.extractPrice(doc)
 	if err != nil {
-		return 0, err
+		// Anúncios esgotados ou pausados não exibem preço; a indisponibilidade é registrada sem erro
+		if snapshot.Availability == AvailabilityOutOfStock {
+			snapshot.Extraction.Duration = time.Since(start)
+			return snapshot, nil
+		}
+		return snapshot, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	snapshot.CurrentPrice = price
+	snapshot.OriginalPrice = m.extractOriginalPrice(doc)
+	snapshot.Discount = m.extractDiscount(doc)
+	snapshot.Installments = m.extractInstallments(doc)
+	snapshot.Extraction.PriceSource = source
+	snapshot.Extraction.Duration = time.Since(start)
+
+	return snapshot, nil
+}
+
+// extractPageExtras lê da página o frete, os cupons e o selo promocional
+func (m *MercadoLivreScraper) extractPageExtras(doc *goquery.Document, snapshot *ProductSnapshot) {
+	if shipping := shippingFromText(doc.Find("#shipping_summary, .ui-pdp-shipping, .ui-pdp-media--shipping").Text()); shipping.Known() {
+		snapshot.Shipping = shipping
 	}
+	// Exemplo: "Cupom de R$ 50" ou "Aplicar cupom 10% OFF. Limite de R$ 100"
+	snapshot.Coupons = couponsFromSelection(doc.Find(".ui-pdp-coupons__label, .ui-vpp-coupons-awareness__checkbox-label, .ui-vpp-coupons"))
+	// Exemplo: "OFERTA RELÂMPAGO" com "Termina em 02:15:30"
+	snapshot.Deal = dealFromText(doc.Find(".ui-pdp-promotions-pill-label, .ui-pdp-price__tags, .ui-pdp-highlights").Text(), snapshot.FetchedAt)
+}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+// enrichFromPage completa um snapshot lido da API com o que só a página exibe (parcelamento, frete, cupons e selos)
+// Falhas ao baixar a página são ignoradas: preço e estoque já vieram da API
+func (m *MercadoLivreScraper) enrichFromPage(ctx context.Context, pageURL string, snapshot *ProductSnapshot) {
+	doc, _, err := fetchDocument(ctx, m.client, pageURL)
 	if err != nil {
-		return 0, err
+		return
+	}
+	m.extractPageExtras(doc, snapshot)
+	snapshot.Installments = m.extractInstallments(doc)
+}
+
+// mlAmount lê um preço a partir de um elemento andes-money-amount ou da sua parte inteira
+// Os centavos ficam em um span separado (andes-money-amount__cents), fora do texto da fração
+func mlAmount(s *goquery.Selection) (money.Money, bool) {
+	container := s
+	if s.HasClass("andes-money-amount__fraction") {
+		container = s.Parent()
 	}
 
+	fraction := strings.TrimSpace(container.Find(".andes-money-amount__fraction").First().Text())
+	if fraction == "" {
+		price, err := parsePrice(strings.TrimSpace(s.Text()))
+		return price, err == nil && price.IsPositive()
+	}
+
+	cents := strings.TrimSpace(container.Find(".andes-money-amount__cents").First().Text())
+	value, err := money.ParseSplit(fraction, cents)
+	if err != nil || value <= 0 {
+		return money.Money{}, false
+	}
+	return money.BRL(value), true
+}
+
+// extractPrice extrai o preço atual do produto, retornando também o seletor que o encontrou
+func (m *MercadoLivreScraper) extractPrice(doc *goquery.Document) (money.Money, string, error) {
 	// Primeiro, tentar buscar especificamente o preço promocional
 	// O Mercado Livre geralmente mostra o preço promocional em elementos específicos
-	var priceText string
-	var promotionalPrice string
-	
+	var price money.Money
+	var source string
+
 	// Buscar em elementos que geralmente contêm o preço promocional
 	promotionalSelectors := []string{
 		".ui-pdp-price__second-line .andes-money-amount__fraction",
@@ -78,26 +169,23 @@ func (m *MercadoLivreScraper) GetPrice(url string) (float64, error) {
 		".ui-pdp-price--size-large .andes-money-amount__fraction",
 		".andes-money-amount--cents-superscript + .andes-money-amount__fraction",
 	}
-	
+
 	for _, selector := range promotionalSelectors {
-		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-			if promotionalPrice == "" {
-				text := strings.TrimSpace(s.Text())
-				if text != "" {
-					promotionalPrice = text
-				}
+		doc.Find(selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+			if value, ok := mlAmount(s); ok {
+				price = value
+				return false
 			}
+			return true
 		})
-		if promotionalPrice != "" {
+		if price.IsPositive() {
+			source = selector
 			break
 		}
 	}
-	
-	// Se encontrou preço promocional, usar ele
-	if promotionalPrice != "" {
-		priceText = promotionalPrice
-	} else {
-		// Caso contrário, buscar em todos os seletores possíveis
+
+	// Caso não tenha encontrado preço promocional, buscar em todos os seletores possíveis
+	if !price.IsPositive() {
 		priceSelectors := []string{
 			"[data-testid='price'] .andes-money-amount__fraction",
 			".ui-pdp-price__first-line .andes-money-amount__fraction",
@@ -106,134 +194,70 @@ func (m *MercadoLivreScraper) GetPrice(url string) (float64, error) {
 			"[data-testid='price']",
 		}
 
-		var prices []string // Coletar todos os preços encontrados
-		
+		// Se houver múltiplos preços, pegar o menor (que geralmente é o promocional)
 		for _, selector := range priceSelectors {
 			doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-				text := strings.TrimSpace(s.Text())
-				if text != "" {
-					prices = append(prices, text)
+				if value, ok := mlAmount(s); ok && (!price.IsPositive() || value.Less(price)) {
+					price = value
+					source = selector
 				}
 			})
 		}
-		
-		// Se houver múltiplos preços, pegar o menor (que geralmente é o promocional)
-		if len(prices) > 0 {
-			if len(prices) > 1 {
-				// Se houver múltiplos preços, tentar pegar o menor
-				// Isso geralmente indica que o menor é o promocional
-				var minPrice float64 = -1
-				for _, p := range prices {
-					// Limpar e converter temporariamente para comparar
-					cleanP := strings.ReplaceAll(p, ".", "")
-					cleanP = strings.ReplaceAll(cleanP, ",", ".")
-					re := regexp.MustCompile(`[^0-9.]`)
-					cleanP = re.ReplaceAllString(cleanP, "")
-					if val, err := strconv.ParseFloat(cleanP, 64); err == nil {
-						if minPrice < 0 || val < minPrice {
-							minPrice = val
-							priceText = p
-						}
-					}
-				}
-			} else {
-				priceText = prices[0]
-			}
-		}
 	}
 
 	// Se não encontrou, tentar buscar em atributos data e meta tags
-	if priceText == "" {
-		// Buscar em data-testid='price' com conteúdo
-		doc.Find("[data-testid='price']").Each(func(i int, s *goquery.Selection) {
-			if priceText == "" {
-				// Tentar content primeiro
-				priceText = s.AttrOr("content", "")
-				// Se não tiver content, pegar o texto
-				if priceText == "" {
-					priceText = strings.TrimSpace(s.Text())
-				}
+	if !price.IsPositive() {
+		attrSources := []struct {
+			selector string
+			source   string
+		}{
+			{"[data-testid='price'][content]", "[data-testid='price'][content]"},
+			{"meta[property='product:price:amount']", "meta[property='product:price:amount']"},
+		}
+		for _, attr := range attrSources {
+			content := doc.Find(attr.selector).First().AttrOr("content", "")
+			if value, err := parsePrice(content); err == nil && value.IsPositive() {
+				price = value
+				source = attr.source
+				break
 			}
-		})
-		
-		// Buscar em meta tags
-		if priceText == "" {
-			doc.Find("meta[property='product:price:amount']").Each(func(i int, s *goquery.Selection) {
-				if priceText == "" {
-					priceText = s.AttrOr("content", "")
-				}
-			})
 		}
 	}
 
 	// Tentar buscar no JSON-LD (priorizar preço em "offers" que geralmente tem o preço atual/promocional)
-	if priceText == "" {
-		doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
+	if !price.IsPositive() {
+		doc.Find("script[type='application/ld+json']").EachWithBreak(func(i int, s *goquery.Selection) bool {
 			jsonText := s.Text()
-			
+
 			// Primeiro tentar buscar em "offers" que geralmente tem o preço promocional
-			offersRe := regexp.MustCompile(`"offers"[^}]*"price"\s*:\s*"?([0-9.]+)"?`)
-			offersMatches := offersRe.FindStringSubmatch(jsonText)
-			if len(offersMatches) > 1 {
-				priceText = offersMatches[1]
-				return
+			if matches := mlOffersPriceRe.FindStringSubmatch(jsonText); len(matches) > 1 {
+				if value, err := parsePrice(matches[1]); err == nil && value.IsPositive() {
+					price = value
+					source = "json-ld offers"
+					return false
+				}
 			}
-			
+
 			// Fallback: buscar qualquer "price"
-			re := regexp.MustCompile(`"price"\s*:\s*"?([0-9.]+)"?`)
-			matches := re.FindStringSubmatch(jsonText)
-			if len(matches) > 1 {
-				priceText = matches[1]
+			if matches := mlAnyPriceRe.FindStringSubmatch(jsonText); len(matches) > 1 {
+				if value, err := parsePrice(matches[1]); err == nil && value.IsPositive() {
+					price = value
+					source = "json-ld"
+				}
 			}
+			return true
 		})
 	}
 
-	if priceText == "" {
-		return 0, fmt.Errorf("preço não encontrado na página")
+	if !price.IsPositive() {
+		return money.Money{}, "", fmt.Errorf("preço não encontrado na página")
 	}
 
-	// Limpar o texto do preço
-	priceText = strings.ReplaceAll(priceText, ".", "")
-	priceText = strings.ReplaceAll(priceText, ",", ".")
-	re := regexp.MustCompile(`[^0-9.]`)
-	priceText = re.ReplaceAllString(priceText, "")
-
-	price, err := strconv.ParseFloat(priceText, 64)
-	if err != nil {
-		return 0, fmt.Errorf("erro ao parsear preço '%s': %v", priceText, err)
-	}
-
-	return price, nil
+	return price, source, nil
 }
 
-// GetOriginalPrice extrai o preço original (antes do desconto) de um produto do Mercado Livre
-func (m *MercadoLivreScraper) GetOriginalPrice(url string) (float64, error) {
-	cleanURL := m.cleanURL(url)
-
-	req, err := http.NewRequest("GET", cleanURL, nil)
-	if err != nil {
-		return 0, err
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
-
-	resp, err := m.getClient().Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-
+// extractOriginalPrice extrai o preço original (antes do desconto), retornando zero se não houver
+func (m *MercadoLivreScraper) extractOriginalPrice(doc *goquery.Document) money.Money {
 	// Buscar preço original (geralmente aparece riscado na primeira linha quando há promoção)
 	// O preço original geralmente está em elementos com classe relacionada a "previous" ou "original"
 	originalPriceSelectors := []string{
@@ -247,103 +271,78 @@ func (m *MercadoLivreScraper) GetOriginalPrice(url string) (float64, error) {
 		".ui-pdp-price__first-line .andes-money-amount__fraction",
 	}
 
-	var originalPriceText string
-	var allPrices []string
-	
+	var originalPrice money.Money
+	var allPrices []money.Money
+
 	// Coletar todos os preços encontrados
 	for _, selector := range originalPriceSelectors {
 		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-			text := strings.TrimSpace(s.Text())
-			if text != "" {
-				allPrices = append(allPrices, text)
-				// Priorizar preços de elementos com "previous" ou "original"
-				if strings.Contains(selector, "previous") || strings.Contains(selector, "original") {
-					if originalPriceText == "" {
-						originalPriceText = text
-					}
+			value, ok := mlAmount(s)
+			if !ok {
+				return
+			}
+			allPrices = append(allPrices, value)
+			// Priorizar preços de elementos com "previous" ou "original"
+			if strings.Contains(selector, "previous") || strings.Contains(selector, "original") {
+				if !originalPrice.IsPositive() {
+					originalPrice = value
 				}
 			}
 		})
 	}
-	
+
 	// Se não encontrou em elementos específicos, mas encontrou múltiplos preços,
 	// pegar o maior (que geralmente é o original quando há promoção)
-	if originalPriceText == "" && len(allPrices) > 1 {
-		var maxPrice float64 = -1
-		for _, p := range allPrices {
-			cleanP := strings.ReplaceAll(p, ".", "")
-			cleanP = strings.ReplaceAll(cleanP, ",", ".")
-			re := regexp.MustCompile(`[^0-9.]`)
-			cleanP = re.ReplaceAllString(cleanP, "")
-			if val, err := strconv.ParseFloat(cleanP, 64); err == nil {
-				if maxPrice < 0 || val > maxPrice {
-					maxPrice = val
-					originalPriceText = p
-				}
+	if !originalPrice.IsPositive() && len(allPrices) > 1 {
+		for _, value := range allPrices {
+			if originalPrice.Less(value) {
+				originalPrice = value
 			}
 		}
 	}
 
 	// Se não encontrou, tentar buscar no JSON-LD
-	if originalPriceText == "" {
+	if !originalPrice.IsPositive() {
 		doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
-			jsonText := s.Text()
-			// Buscar por "priceCurrency" e "highPrice" ou "listPrice"
-			re := regexp.MustCompile(`"(listPrice|highPrice|originalPrice)"\s*:\s*"?([0-9.]+)"?`)
-			matches := re.FindStringSubmatch(jsonText)
-			if len(matches) > 2 {
-				originalPriceText = matches[2]
+			// Buscar por "listPrice", "highPrice" ou "originalPrice"
+			if matches := mlOriginalPriceRe.FindStringSubmatch(s.Text()); len(matches) > 2 {
+				if value, err := parsePrice(matches[2]); err == nil {
+					originalPrice = value
+				}
 			}
 		})
 	}
 
-	// Se não encontrou preço original, retornar 0 (não há desconto)
-	if originalPriceText == "" {
-		return 0, nil
-	}
-
-	// Limpar o texto do preço
-	originalPriceText = strings.ReplaceAll(originalPriceText, ".", "")
-	originalPriceText = strings.ReplaceAll(originalPriceText, ",", ".")
-	re := regexp.MustCompile(`[^0-9.]`)
-	originalPriceText = re.ReplaceAllString(originalPriceText, "")
-
-	originalPrice, err := strconv.ParseFloat(originalPriceText, 64)
-	if err != nil {
-		return 0, nil // Se não conseguir parsear, retornar 0 (sem preço original)
-	}
-
-	return originalPrice, nil
+	// Se não encontrou preço original, retorna zero (não há desconto)
+	return originalPrice
 }
 
-// GetDiscount extrai o percentual de desconto de um produto do Mercado Livre
-func (m *MercadoLivreScraper) GetDiscount(url string) (float64, error) {
-	cleanURL := m.cleanURL(url)
-
-	req, err := http.NewRequest("GET", cleanURL, nil)
-	if err != nil {
-		return 0, err
+// extractInstallments lê o parcelamento exibido abaixo do preço (ex: "em 10x R$ 129,90 sem juros")
+// O valor da parcela é um andes-money-amount, com os centavos em um span separado
+func (m *MercadoLivreScraper) extractInstallments(doc *goquery.Document) models.Installments {
+	subtitle := doc.Find("#pricing_price_subtitle, .ui-pdp-price__subtitles").First()
+	text := subtitle.Text()
+	matches := mlInstallmentsRe.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return models.Installments{}
 	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
-
-	resp, err := m.getClient().Do(req)
-	if err != nil {
-		return 0, err
+	count, err := strconv.Atoi(matches[1])
+	if err != nil || count <= 0 {
+		return models.Installments{}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	value, ok := mlAmount(subtitle.Find(".andes-money-amount").First())
+	if !ok {
+		return models.Installments{}
 	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return 0, err
+	return models.Installments{
+		Count:        count,
+		Value:        value,
+		InterestFree: strings.Contains(strings.ToLower(text), "sem juros"),
 	}
+}
 
+// extractDiscount extrai o percentual de desconto, retornando 0 se não houver
+func (m *MercadoLivreScraper) extractDiscount(doc *goquery.Document) float64 {
 	// Buscar o campo de desconto diretamente
 	// Exemplo: <span class="andes-money-amount__discount ...">17% OFF</span>
 	// O desconto está dentro de ui-pdp-price__second-line
@@ -371,53 +370,57 @@ func (m *MercadoLivreScraper) GetDiscount(url string) (float64, error) {
 		}
 	}
 
-	// Se não encontrou, retornar 0 (sem desconto)
-	if discountText == "" {
-		return 0, nil
-	}
-
 	// Extrair o número do texto (ex: "17% OFF" -> 17)
-	re := regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
-	matches := re.FindStringSubmatch(discountText)
+	matches := mlDiscountRe.FindStringSubmatch(discountText)
 	if len(matches) > 1 {
 		discount, err := strconv.ParseFloat(matches[1], 64)
 		if err != nil {
-			return 0, nil
+			return 0
 		}
-		return discount, nil
+		return discount
 	}
 
-	return 0, nil
+	return 0
 }
 
-// GetName extrai o nome de um produto do Mercado Livre
-func (m *MercadoLivreScraper) GetName(url string) (string, error) {
-	cleanURL := m.cleanURL(url)
-
-	req, err := http.NewRequest("GET", cleanURL, nil)
-	if err != nil {
-		return "", err
+// extractAvailability interpreta os avisos de estoque do anúncio
+// Exemplos: "Estoque disponível", "(+50 disponíveis)", "(3 disponíveis)", "Último disponível!", "Publicação pausada"
+func (m *MercadoLivreScraper) extractAvailability(doc *goquery.Document) (Availability, int) {
+	notice := strings.ToLower(doc.Find(".ui-pdp-message, .ui-vpp-message, .ui-pdp-stock-information, .ui-pdp-buybox .andes-message").Text())
+	for _, soldOut := range []string{"esgotad", "sem estoque", "pausad", "finalizad", "indisponível"} {
+		if strings.Contains(notice, soldOut) {
+			return AvailabilityOutOfStock, 0
+		}
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
-
-	resp, err := m.getClient().Do(req)
-	if err != nil {
-		return "", err
+	quantityText := doc.Find(".ui-pdp-buybox__quantity__available, .ui-pdp-stock-information__subtitle").Text()
+	if matches := mlStockRe.FindStringSubmatch(quantityText); len(matches) > 2 {
+		if quantity, err := strconv.Atoi(matches[2]); err == nil {
+			// "+50 disponíveis" é só um piso; o estoque real é maior
+			if matches[1] == "+" {
+				return AvailabilityInStock, quantity
+			}
+			return stockAvailability(quantity), quantity
+		}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	if strings.Contains(notice, "último disponível") {
+		return AvailabilityLimited, 1
+	}
+	if strings.Contains(notice, "estoque disponível") {
+		return AvailabilityInStock, 0
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return "", err
+	if _, soldOut := jsonLDOutOfStock(doc); soldOut {
+		return AvailabilityOutOfStock, 0
 	}
+	if product, ok := extractJSONLDProduct(doc); ok {
+		return product.Availability, 0
+	}
+	return AvailabilityUnknown, 0
+}
 
+// extractName extrai o nome do produto
+func (m *MercadoLivreScraper) extractName(doc *goquery.Document) string {
 	// Tentar encontrar o nome do produto
 	nameSelectors := []string{
 		"h1.ui-pdp-title",
@@ -428,11 +431,7 @@ func (m *MercadoLivreScraper) GetName(url string) (string, error) {
 
 	var name string
 	for _, selector := range nameSelectors {
-		doc.Find(selector).First().Each(func(i int, s *goquery.Selection) {
-			if name == "" {
-				name = strings.TrimSpace(s.Text())
-			}
-		})
+		name = strings.TrimSpace(doc.Find(selector).First().Text())
 		if name != "" {
 			break
 		}
@@ -441,10 +440,7 @@ func (m *MercadoLivreScraper) GetName(url string) (string, error) {
 	if name == "" {
 		// Tentar buscar no JSON-LD
 		doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
-			jsonText := s.Text()
-			re := regexp.MustCompile(`"name"\s*:\s*"([^"]+)"`)
-			matches := re.FindStringSubmatch(jsonText)
-			if len(matches) > 1 {
+			if matches := mlNameRe.FindStringSubmatch(s.Text()); len(matches) > 1 {
 				name = matches[1]
 			}
 		})
@@ -454,11 +450,93 @@ func (m *MercadoLivreScraper) GetName(url string) (string, error) {
 		name = "Produto sem nome"
 	}
 
-	return name, nil
+	return name
+}
+
+// itemID extrai o código do anúncio (ex: MLB1234567890) de URLs como https://produto.mercadolivre.com.br/MLB-1234567890-...
+// Páginas de catálogo (/p/MLB...) identificam o produto, não um anúncio, e não são aceitas
+func (m *MercadoLivreScraper) itemID(rawURL string) (string, bool) {
+	if strings.Contains(rawURL, "/p/") {
+		return "", false
+	}
+	matches := mlItemIDRe.FindStringSubmatch(rawURL)
+	if len(matches) < 3 {
+		return "", false
+	}
+	return "MLB" + matches[2], true
+}
+
+// ProductKey identifica o produto pelo código MLB da URL (ex: ML:MLB50097091)
+// O código do caminho (/p/MLB50097091 ou /MLB-1234567890-...) tem prioridade sobre o dos parâmetros;
+// a variação escolhida de um anúncio faz parte da chave (ex: ML:MLB1234567890:V123)
+func (m *MercadoLivreScraper) ProductKey(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	suffix := ""
+	if variation := mlVariationID(rawURL); variation != "" {
+		suffix = ":V" + variation
+	}
+	for _, text := range []string{u.Path, u.RawQuery} {
+		if matches := mlItemIDRe.FindStringSubmatch(text); len(matches) > 2 {
+			return "ML:MLB" + matches[2] + suffix, true
+		}
+	}
+	return "", false
+}
+
+// QuoteShipping cota o frete para o CEP na API de opções de envio do anúncio, usando a opção mais barata
+func (m *MercadoLivreScraper) QuoteShipping(ctx context.Context, rawURL, cep string) (models.Shipping, error) {
+	id, ok := m.itemID(rawURL)
+	if !ok {
+		return models.Shipping{}, fmt.Errorf("%w: o link não identifica um anúncio (MLB-...)", ErrShippingUnsupported)
+	}
+
+	if m.apiBaseURL == "" {
+		return models.Shipping{}, fmt.Errorf("%w: a API do Mercado Livre está desativada", ErrShippingUnsupported)
+	}
+
+	endpoint := fmt.Sprintf("%s/items/%s/shipping_options?zip_code=%s", strings.TrimSuffix(m.apiBaseURL, "/"), id, url.QueryEscape(cep))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return models.Shipping{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return models.Shipping{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Shipping{}, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Options []struct {
+			Cost float64 `json:"cost"`
+		} `json:"options"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return models.Shipping{}, fmt.Errorf("resposta inválida da API de frete: %v", err)
+	}
+	if len(body.Options) == 0 {
+		return models.Shipping{}, fmt.Errorf("nenhuma opção de frete para o CEP %s", cep)
+	}
+
+	cheapest := body.Options[0].Cost
+	for _, option := range body.Options[1:] {
+		if option.Cost < cheapest {
+			cheapest = option.Cost
+		}
+	}
+	cost := money.FromFloat(cheapest, money.DefaultCurrency)
+	return models.Shipping{Cost: cost, Free: cost.IsZero(), CEP: cep}, nil
 }
 
 func (m *MercadoLivreScraper) cleanURL(url string) string {
 	parts := strings.Split(url, "#")
 	return parts[0]
 }
-
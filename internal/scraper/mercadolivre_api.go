@@ -0,0 +1,416 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// mlItem é a resposta de GET /items/{id}: um anúncio de um vendedor
+type mlItem struct {
+	ID                string        `json:"id"`
+	Title             string        `json:"title"`
+	Price             float64       `json:"price"`
+	OriginalPrice     float64       `json:"original_price"` // null (zero) quando o anúncio não está em promoção
+	CurrencyID        string        `json:"currency_id"`
+	AvailableQuantity int           `json:"available_quantity"`
+	Status            string        `json:"status"` // active, paused, closed, under_review, inactive
+	SellerID          int64         `json:"seller_id"`
+	OfficialStoreID   int64         `json:"official_store_id"` // null (zero) quando o vendedor não é loja oficial
+	Permalink         string        `json:"permalink"`
+	Shipping          mlShipping    `json:"shipping"`
+	Variations        []mlVariation `json:"variations"`
+}
+
+// mlVariation é uma variação do anúncio (ex: cor ou voltagem), com preço e estoque próprios
+type mlVariation struct {
+	ID                    int64                  `json:"id"`
+	Price                 float64                `json:"price"`
+	AvailableQuantity     int                    `json:"available_quantity"`
+	AttributeCombinations []mlVariationAttribute `json:"attribute_combinations"`
+}
+
+// mlVariationAttribute é um atributo de uma variação (ex: Cor: Preto)
+type mlVariationAttribute struct {
+	Name      string `json:"name"`
+	ValueName string `json:"value_name"`
+}
+
+// label descreve os atributos da variação (ex: "Cor: Preto, Voltagem: 127V")
+func (v mlVariation) label() string {
+	var parts []string
+	for _, attribute := range v.AttributeCombinations {
+		parts = append(parts, attribute.Name+": "+attribute.ValueName)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mlShipping é o resumo de frete de um anúncio
+type mlShipping struct {
+	FreeShipping bool `json:"free_shipping"`
+}
+
+// mlProduct é a resposta de GET /products/{id}: uma página de catálogo (/p/MLB...)
+// O anúncio vencedor da buy box é o que a página exibe como oferta principal
+type mlProduct struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Status       string          `json:"status"`
+	BuyBoxWinner *mlBuyBoxWinner `json:"buy_box_winner"`
+	Pickers      []mlPicker      `json:"pickers"`
+}
+
+// mlPicker é um seletor de variantes da página de catálogo (ex: Voltagem com 127V e 220V)
+// Cada opção é outro produto de catálogo, com a opção escolhida e os demais atributos do produto atual
+type mlPicker struct {
+	Name     string `json:"picker_name"`
+	Products []struct {
+		ProductID string `json:"product_id"` // Vazio quando a combinação não existe
+		Label     string `json:"picker_label"`
+	} `json:"products"`
+}
+
+// variants lista as opções dos seletores do produto e a variante do próprio produto
+func (p mlProduct) variants() (models.Variant, []models.Variant) {
+	current := models.Variant{ID: p.ID, URL: mlCatalogURL(p.ID)}
+	var labels []string
+	var variants []models.Variant
+	for _, picker := range p.Pickers {
+		for _, option := range picker.Products {
+			if option.ProductID == "" {
+				continue
+			}
+			label := picker.Name + ": " + option.Label
+			if option.ProductID == p.ID {
+				labels = append(labels, label)
+			}
+			variants = append(variants, models.Variant{ID: option.ProductID, Label: label, URL: mlCatalogURL(option.ProductID)})
+		}
+	}
+	if len(labels) == 0 {
+		return models.Variant{}, variants
+	}
+	current.Label = strings.Join(labels, ", ")
+	return current, variants
+}
+
+// mlCatalogURL monta a URL da página de catálogo de um produto
+func mlCatalogURL(productID string) string {
+	return "https://www.mercadolivre.com.br/p/" + productID
+}
+
+// mlBuyBoxWinner identifica o anúncio que vence a buy box de um produto de catálogo
+type mlBuyBoxWinner struct {
+	ItemID string `json:"item_id"`
+}
+
+// maxOffers limita quantas ofertas de um produto de catálogo são guardadas (as mais baratas),
+// já que cada vendedor exige uma consulta a /users
+const maxOffers = 10
+
+// mlProductItems é a resposta de GET /products/{id}/items: os anúncios que disputam a página de catálogo
+type mlProductItems struct {
+	Results []mlProductItem `json:"results"`
+}
+
+// mlProductItem é a oferta de um vendedor em uma página de catálogo
+type mlProductItem struct {
+	ItemID          string     `json:"item_id"`
+	Price           float64    `json:"price"`
+	OriginalPrice   float64    `json:"original_price"`
+	CurrencyID      string     `json:"currency_id"`
+	SellerID        int64      `json:"seller_id"`
+	OfficialStoreID int64      `json:"official_store_id"`
+	Shipping        mlShipping `json:"shipping"`
+}
+
+// mlUser é a resposta de GET /users/{id}, usada para o nome e a reputação do vendedor
+type mlUser struct {
+	ID               int64  `json:"id"`
+	Nickname         string `json:"nickname"`
+	SellerReputation struct {
+		LevelID string `json:"level_id"` // Ex: "5_green"; null (vazio) para vendedores sem vendas suficientes
+	} `json:"seller_reputation"`
+}
+
+// reputation converte o nível do termômetro ("1_red" a "5_green") em 1 a 5, 0 se desconhecido
+func (u mlUser) reputation() int {
+	level, _, _ := strings.Cut(u.SellerReputation.LevelID, "_")
+	reputation, err := strconv.Atoi(level)
+	if err != nil || reputation < 1 || reputation > models.MaxReputation {
+		return 0
+	}
+	return reputation
+}
+
+// mlSellerCacheTTL é por quanto tempo o nome e a reputação de um vendedor são reaproveitados entre verificações
+const mlSellerCacheTTL = 24 * time.Hour
+
+// mlSellerCache guarda as respostas de /users, que mudam pouco e se repetem entre produtos e verificações
+type mlSellerCache struct {
+	mu    sync.Mutex
+	users map[int64]mlCachedUser
+}
+
+type mlCachedUser struct {
+	user      mlUser
+	fetchedAt time.Time
+}
+
+func newMLSellerCache() *mlSellerCache {
+	return &mlSellerCache{users: make(map[int64]mlCachedUser)}
+}
+
+func (c *mlSellerCache) get(id int64) (mlUser, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.users[id]
+	if !ok || time.Since(cached.fetchedAt) > mlSellerCacheTTL {
+		return mlUser{}, false
+	}
+	return cached.user, true
+}
+
+func (c *mlSellerCache) put(user mlUser) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cached := range c.users {
+		if time.Since(cached.fetchedAt) > mlSellerCacheTTL {
+			delete(c.users, id)
+		}
+	}
+	c.users[user.ID] = mlCachedUser{user: user, fetchedAt: time.Now()}
+}
+
+// seller retorna o vendedor com nome e reputação; se a consulta falhar, apenas o código é conhecido
+func (m *MercadoLivreScraper) seller(ctx context.Context, id int64, officialStore bool) models.Seller {
+	seller := models.Seller{ID: id, OfficialStore: officialStore}
+	if id == 0 {
+		return seller
+	}
+	user, ok := m.sellers.get(id)
+	if !ok {
+		if m.getJSON(ctx, fmt.Sprintf("/users/%d", id), &user) != nil {
+			return seller
+		}
+		user.ID = id
+		m.sellers.put(user)
+	}
+	seller.Name = user.Nickname
+	seller.Reputation = user.reputation()
+	return seller
+}
+
+// catalogOffers lista as ofertas dos vendedores de um produto de catálogo, da mais barata para a mais cara
+func (m *MercadoLivreScraper) catalogOffers(ctx context.Context, productID string) ([]models.Offer, error) {
+	var items mlProductItems
+	if err := m.getJSON(ctx, "/products/"+productID+"/items", &items); err != nil {
+		return nil, err
+	}
+
+	results := items.Results
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Price < results[j].Price })
+	var offers []models.Offer
+	for _, item := range results {
+		if item.ItemID == "" || item.Price <= 0 {
+			continue
+		}
+		if len(offers) == maxOffers {
+			break
+		}
+		currency := item.CurrencyID
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		offer := models.Offer{
+			ItemID:   item.ItemID,
+			Seller:   m.seller(ctx, item.SellerID, item.OfficialStoreID != 0),
+			Price:    money.FromFloat(item.Price, currency),
+			Shipping: models.Shipping{Free: item.Shipping.FreeShipping},
+		}
+		if item.OriginalPrice > item.Price {
+			offer.OriginalPrice = money.FromFloat(item.OriginalPrice, currency)
+		}
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+// getJSON consulta um endpoint da API do Mercado Livre e decodifica a resposta em out
+func (m *MercadoLivreScraper) getJSON(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(m.apiBaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status code: %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: resposta inválida: %v", path, err)
+	}
+	return nil
+}
+
+// apiIDs extrai da URL o código MLB e indica se ele é de um produto de catálogo (/p/MLB...) ou de um anúncio
+func (m *MercadoLivreScraper) apiIDs(rawURL string) (id string, catalog bool, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false, false
+	}
+	matches := mlItemIDRe.FindStringSubmatch(u.Path)
+	if len(matches) < 3 {
+		return "", false, false
+	}
+	return "MLB" + matches[2], strings.Contains(u.Path, "/p/"), true
+}
+
+// mlVariationID retorna a variação do anúncio escolhida na URL (?variation=123), vazio se não houver
+func mlVariationID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	if id := query.Get("variation"); id != "" {
+		return id
+	}
+	return query.Get("variation_id")
+}
+
+// mlVariationURL monta a URL do anúncio com a variação escolhida
+func mlVariationURL(rawURL string, variationID int64) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Del("variation_id")
+	query.Set("variation", strconv.FormatInt(variationID, 10))
+	u.RawQuery = query.Encode()
+	u.Fragment = ""
+	return u.String()
+}
+
+// scrapeAPI preenche o snapshot a partir da API pública: /products/{id} para páginas de catálogo
+// (seguindo a oferta mais barata entre os vendedores, ou o anúncio da buy box) e /items/{id} para anúncios
+// Parcelamento, cupons e selos não são publicados pela API e ficam a cargo de enrichFromPage;
+// pageOffer indica se o anúncio lido é o exibido pela página, a quem esses dados se referem
+func (m *MercadoLivreScraper) scrapeAPI(ctx context.Context, rawURL string, snapshot *ProductSnapshot) (pageOffer bool, err error) {
+	id, catalog, ok := m.apiIDs(rawURL)
+	if !ok {
+		return false, fmt.Errorf("o link não tem um código MLB")
+	}
+
+	var name string
+	pageOffer = true
+	if catalog {
+		var product mlProduct
+		if err := m.getJSON(ctx, "/products/"+id, &product); err != nil {
+			return false, err
+		}
+		var buyBoxItem string
+		if product.BuyBoxWinner != nil {
+			buyBoxItem = product.BuyBoxWinner.ItemID
+		}
+		// Vários vendedores disputam a página; o produto acompanha a oferta mais barata entre eles
+		// Sem a lista de ofertas, vale o anúncio da buy box
+		offers, _ := m.catalogOffers(ctx, id)
+		snapshot.Offers = offers
+		itemID := buyBoxItem
+		if best, ok := models.BestOffer(offers, models.SellerFilter{}); ok {
+			itemID = best.ItemID
+		}
+		if itemID == "" {
+			return false, fmt.Errorf("/products/%s: produto sem anúncio vencedor", id)
+		}
+		name = product.Name
+		id = itemID
+		pageOffer = itemID == buyBoxItem
+		snapshot.Variant, snapshot.Variants = product.variants()
+	}
+
+	var item mlItem
+	if err := m.getJSON(ctx, "/items/"+id, &item); err != nil {
+		return false, err
+	}
+	if name == "" {
+		name = item.Title
+	}
+
+	currency := item.CurrencyID
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if !catalog && len(item.Variations) > 0 {
+		// Anúncios com variações: cada uma tem preço e estoque próprios, e a URL pode escolher uma delas
+		selected := mlVariationID(rawURL)
+		for _, variation := range item.Variations {
+			variant := models.Variant{
+				ID:    strconv.FormatInt(variation.ID, 10),
+				Label: variation.label(),
+				URL:   mlVariationURL(rawURL, variation.ID),
+				Price: money.FromFloat(variation.Price, currency),
+			}
+			snapshot.Variants = append(snapshot.Variants, variant)
+			if variant.ID == selected {
+				snapshot.Variant = variant
+				item.Price = variation.Price
+				item.AvailableQuantity = variation.AvailableQuantity
+			}
+		}
+		if selected != "" && snapshot.Variant.ID == "" {
+			return false, fmt.Errorf("/items/%s: variação %s não encontrada", id, selected)
+		}
+	}
+	snapshot.Name = name
+	snapshot.Currency = currency
+	snapshot.Availability, snapshot.StockQuantity = item.availability()
+	snapshot.Seller = m.seller(ctx, item.SellerID, item.OfficialStoreID != 0)
+	if item.Shipping.FreeShipping {
+		snapshot.Shipping = models.Shipping{Free: true}
+	}
+
+	if item.Price <= 0 {
+		// Anúncios pausados ou encerrados podem voltar sem preço; a indisponibilidade é registrada sem erro
+		if snapshot.Availability == AvailabilityOutOfStock {
+			return pageOffer, nil
+		}
+		return false, fmt.Errorf("/items/%s: anúncio sem preço", id)
+	}
+	snapshot.CurrentPrice = money.FromFloat(item.Price, currency)
+	if item.OriginalPrice > item.Price {
+		snapshot.OriginalPrice = money.FromFloat(item.OriginalPrice, currency)
+		snapshot.Discount = snapshot.CurrentPrice.DiscountFrom(snapshot.OriginalPrice)
+	}
+	snapshot.Extraction.PriceSource = "api /items/" + id
+	return pageOffer, nil
+}
+
+// availability interpreta o status e a quantidade disponível do anúncio
+// A API arredonda quantidades grandes em faixas (50, 100, 250...), então só as pequenas são exatas
+func (item mlItem) availability() (Availability, int) {
+	if item.Status != "active" {
+		return AvailabilityOutOfStock, 0
+	}
+	return stockAvailability(item.AvailableQuantity), item.AvailableQuantity
+}
@@ -0,0 +1,246 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+const mlAPIBaseURL = "https://api.mercadolibre.com"
+
+// mlStandIn simula a API pública e as páginas do Mercado Livre, contando as requisições por caminho
+type mlStandIn struct {
+	api      map[string]string // Resposta JSON por caminho da API
+	status   map[string]int    // Status de erro forçado por caminho da API (ex: 403)
+	page     http.HandlerFunc  // Página do produto
+	mu       sync.Mutex
+	requests map[string]int // Requisições por host + caminho
+}
+
+func (s *mlStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.Host+r.URL.Path]++
+	s.mu.Unlock()
+
+	if r.Host != "api.mercadolibre.com" {
+		s.page(w, r)
+		return
+	}
+	if status, ok := s.status[r.URL.Path]; ok {
+		w.WriteHeader(status)
+		return
+	}
+	body, ok := s.api[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+func (s *mlStandIn) count(host, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[host+path]
+}
+
+// newMLScraper cria o scraper apontando para a API e as páginas simuladas
+func newMLScraper(t *testing.T, fixture string, api map[string]string, status map[string]int) (*MercadoLivreScraper, *mlStandIn) {
+	t.Helper()
+
+	standIn := &mlStandIn{api: api, status: status, page: serveFixture(t, fixture), requests: make(map[string]int)}
+	m := NewMercadoLivreScraper(mlAPIBaseURL, nil)
+	m.client = newStoreServer(t, standIn)
+	return m, standIn
+}
+
+var mlItemAPI = map[string]string{
+	"/items/MLB1234567890": `{"id":"MLB1234567890","title":"Fone De Ouvido Bluetooth (API)","price":199.9,"original_price":249.9,` +
+		`"currency_id":"BRL","available_quantity":3,"status":"active","seller_id":111,"official_store_id":2045,` +
+		`"shipping":{"free_shipping":true}}`,
+	"/users/111": `{"id":111,"nickname":"LOJA_OFICIAL_AUDIO","seller_reputation":{"level_id":"5_green"}}`,
+}
+
+func TestMercadoLivreScrapeItemAPI(t *testing.T) {
+	const itemURL = "https://produto.mercadolivre.com.br/MLB-1234567890-fone-de-ouvido-bluetooth-_JM"
+	m, standIn := newMLScraper(t, "mercadolivre_item.html", mlItemAPI, nil)
+
+	snapshot, err := m.Scrape(context.Background(), itemURL)
+	if err != nil {
+		t.Fatalf("Scrape retornou erro: %v", err)
+	}
+
+	if snapshot.Extraction.Fallback != "" {
+		t.Errorf("Fallback = %q, esperado vazio", snapshot.Extraction.Fallback)
+	}
+	if snapshot.Extraction.PriceSource != "api /items/MLB1234567890" {
+		t.Errorf("PriceSource = %q, esperado a API", snapshot.Extraction.PriceSource)
+	}
+	if snapshot.Name != "Fone De Ouvido Bluetooth (API)" {
+		t.Errorf("Name = %q, esperado o título da API", snapshot.Name)
+	}
+	// Preços e estoque vêm da API, mesmo que a página exiba outros valores
+	if snapshot.CurrentPrice.Cents != 19990 || snapshot.OriginalPrice.Cents != 24990 {
+		t.Errorf("preços = %d/%d, esperado 19990/24990", snapshot.CurrentPrice.Cents, snapshot.OriginalPrice.Cents)
+	}
+	if int(snapshot.Discount) != 20 {
+		t.Errorf("Discount = %.1f, esperado 20", snapshot.Discount)
+	}
+	if snapshot.Availability != AvailabilityLimited || snapshot.StockQuantity != 3 {
+		t.Errorf("estoque = %q/%d, esperado limitado com 3", snapshot.Availability, snapshot.StockQuantity)
+	}
+	wantSeller := models.Seller{ID: 111, Name: "LOJA_OFICIAL_AUDIO", OfficialStore: true, Reputation: 5}
+	if snapshot.Seller != wantSeller {
+		t.Errorf("Seller = %+v, esperado %+v", snapshot.Seller, wantSeller)
+	}
+	if !snapshot.Shipping.Free {
+		t.Errorf("Shipping = %+v, esperado frete grátis", snapshot.Shipping)
+	}
+	// Parcelamento e cupons só existem na página
+	wantInstallments := models.Installments{Count: 10, Value: money.BRL(1899), InterestFree: true}
+	if snapshot.Installments != wantInstallments {
+		t.Errorf("Installments = %+v, esperado %+v", snapshot.Installments, wantInstallments)
+	}
+	if len(snapshot.Coupons) != 1 {
+		t.Errorf("Coupons = %+v, esperado o cupom da página", snapshot.Coupons)
+	}
+	if n := standIn.count("produto.mercadolivre.com.br", "/MLB-1234567890-fone-de-ouvido-bluetooth-_JM"); n != 1 {
+		t.Errorf("página baixada %d vezes, esperado 1", n)
+	}
+}
+
+func TestMercadoLivreScrapeCatalogAPI(t *testing.T) {
+	api := map[string]string{
+		"/products/MLB50097091": `{"id":"MLB50097091","name":"Lava E Seca 11kg Inverter","status":"active",` +
+			`"buy_box_winner":{"item_id":"MLB2000000001"},` +
+			`"pickers":[{"picker_name":"Voltagem","products":[` +
+			`{"product_id":"MLB50097091","picker_label":"127V"},{"product_id":"MLB50097092","picker_label":"220V"},` +
+			`{"product_id":"","picker_label":"Bivolt"}]}]}`,
+		"/products/MLB50097091/items": `{"results":[` +
+			`{"item_id":"MLB2000000001","price":2999.9,"currency_id":"BRL","seller_id":222,"official_store_id":77,"shipping":{"free_shipping":true}},` +
+			`{"item_id":"MLB2000000002","price":2899.9,"original_price":3199.9,"currency_id":"BRL","seller_id":333,"shipping":{"free_shipping":false}},` +
+			`{"item_id":"MLB2000000003","price":0,"currency_id":"BRL","seller_id":444}]}`,
+		"/items/MLB2000000002": `{"id":"MLB2000000002","title":"Lava e Seca 11kg Inverter 127V","price":2899.9,"original_price":3199.9,` +
+			`"currency_id":"BRL","available_quantity":50,"status":"active","seller_id":333,"shipping":{"free_shipping":false}}`,
+		"/users/222": `{"id":222,"nickname":"LOJA_ELETRO","seller_reputation":{"level_id":"5_green"}}`,
+		"/users/333": `{"id":333,"nickname":"VENDEDOR_CASA","seller_reputation":{"level_id":"4_light_green"}}`,
+	}
+	m, standIn := newMLScraper(t, "mercadolivre_catalog.html", api, nil)
+
+	// A segunda leitura usa os vendedores em cache
+	for i := 0; i < 2; i++ {
+		snapshot, err := m.Scrape(context.Background(), "https://www.mercadolivre.com.br/p/MLB50097091")
+		if err != nil {
+			t.Fatalf("Scrape retornou erro: %v", err)
+		}
+
+		if snapshot.Name != "Lava E Seca 11kg Inverter" {
+			t.Errorf("Name = %q, esperado o nome do produto de catálogo", snapshot.Name)
+		}
+		// O produto acompanha a oferta mais barata, não a da buy box
+		if snapshot.CurrentPrice.Cents != 289990 || snapshot.OriginalPrice.Cents != 319990 {
+			t.Errorf("preços = %d/%d, esperado 289990/319990", snapshot.CurrentPrice.Cents, snapshot.OriginalPrice.Cents)
+		}
+		if snapshot.Extraction.PriceSource != "api /items/MLB2000000002" {
+			t.Errorf("PriceSource = %q, esperado o anúncio mais barato", snapshot.Extraction.PriceSource)
+		}
+		if snapshot.Availability != AvailabilityInStock || snapshot.StockQuantity != 50 {
+			t.Errorf("estoque = %q/%d, esperado em estoque com 50", snapshot.Availability, snapshot.StockQuantity)
+		}
+
+		wantOffers := []models.Offer{
+			{
+				ItemID:        "MLB2000000002",
+				Seller:        models.Seller{ID: 333, Name: "VENDEDOR_CASA", Reputation: 4},
+				Price:         money.BRL(289990),
+				OriginalPrice: money.BRL(319990),
+			},
+			{
+				ItemID:   "MLB2000000001",
+				Seller:   models.Seller{ID: 222, Name: "LOJA_ELETRO", OfficialStore: true, Reputation: 5},
+				Price:    money.BRL(299990),
+				Shipping: models.Shipping{Free: true},
+			},
+		}
+		if len(snapshot.Offers) != len(wantOffers) {
+			t.Fatalf("Offers = %+v, esperado %+v", snapshot.Offers, wantOffers)
+		}
+		for j, offer := range snapshot.Offers {
+			if offer != wantOffers[j] {
+				t.Errorf("Offers[%d] = %+v, esperado %+v", j, offer, wantOffers[j])
+			}
+		}
+		if snapshot.Seller != wantOffers[0].Seller {
+			t.Errorf("Seller = %+v, esperado %+v", snapshot.Seller, wantOffers[0].Seller)
+		}
+
+		wantVariant := models.Variant{ID: "MLB50097091", Label: "Voltagem: 127V", URL: "https://www.mercadolivre.com.br/p/MLB50097091"}
+		if snapshot.Variant != wantVariant {
+			t.Errorf("Variant = %+v, esperado %+v", snapshot.Variant, wantVariant)
+		}
+		if len(snapshot.Variants) != 2 {
+			t.Errorf("Variants = %+v, esperado as 2 combinações existentes", snapshot.Variants)
+		}
+		// O parcelamento da página é da buy box, não da oferta mais barata
+		if snapshot.Installments != (models.Installments{}) {
+			t.Errorf("Installments = %+v, esperado vazio", snapshot.Installments)
+		}
+	}
+
+	for _, path := range []string{"/users/222", "/users/333"} {
+		if n := standIn.count("api.mercadolibre.com", path); n != 1 {
+			t.Errorf("%s consultado %d vezes, esperado 1", path, n)
+		}
+	}
+	if n := standIn.count("www.mercadolivre.com.br", "/p/MLB50097091"); n != 0 {
+		t.Errorf("página baixada %d vezes, esperado nenhuma", n)
+	}
+}
+
+func TestMercadoLivreScrapeFallback(t *testing.T) {
+	const itemURL = "https://produto.mercadolivre.com.br/MLB-1234567890-fone-de-ouvido-bluetooth-_JM"
+
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			m, _ := newMLScraper(t, "mercadolivre_item.html", mlItemAPI, map[string]int{"/items/MLB1234567890": status})
+
+			snapshot, err := m.Scrape(context.Background(), itemURL)
+			if err != nil {
+				t.Fatalf("Scrape retornou erro: %v", err)
+			}
+
+			if !strings.Contains(snapshot.Extraction.Fallback, "status code: "+strconv.Itoa(status)) {
+				t.Errorf("Fallback = %q, esperado o status %d da API", snapshot.Extraction.Fallback, status)
+			}
+			if snapshot.Extraction.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, esperado o status da página", snapshot.Extraction.StatusCode)
+			}
+			if snapshot.Name != "Fone De Ouvido Bluetooth Com Cancelamento De Ruído" {
+				t.Errorf("Name = %q, esperado o título da página", snapshot.Name)
+			}
+			if snapshot.CurrentPrice.Cents != 18990 || snapshot.OriginalPrice.Cents != 24990 {
+				t.Errorf("preços = %d/%d, esperado 18990/24990", snapshot.CurrentPrice.Cents, snapshot.OriginalPrice.Cents)
+			}
+			if int(snapshot.Discount) != 24 {
+				t.Errorf("Discount = %.1f, esperado 24", snapshot.Discount)
+			}
+			if snapshot.Availability != AvailabilityLimited || snapshot.StockQuantity != 3 {
+				t.Errorf("estoque = %q/%d, esperado limitado com 3", snapshot.Availability, snapshot.StockQuantity)
+			}
+			if strings.HasPrefix(snapshot.Extraction.PriceSource, "api ") {
+				t.Errorf("PriceSource = %q, esperado um seletor da página", snapshot.Extraction.PriceSource)
+			}
+			// Sem a API, o vendedor não é conhecido
+			if snapshot.Seller != (models.Seller{}) {
+				t.Errorf("Seller = %+v, esperado vazio", snapshot.Seller)
+			}
+		})
+	}
+}
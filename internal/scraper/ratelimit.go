@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter limita as requisições por host com um token bucket independente para cada loja
+// Os clientes HTTP dos scrapers esperam por ele a cada requisição, inclusive nas consultas a APIs
+type HostLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens repostos por segundo
+	burst   float64 // Capacidade máxima do bucket
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewHostLimiter cria um limitador com requestsPerMinute requisições por minuto e rajadas de até burst por host
+// Com requestsPerMinute igual a 0, nenhuma requisição espera
+func NewHostLimiter(requestsPerMinute float64, burst int) *HostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{
+		rate:    requestsPerMinute / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Wait bloqueia até haver um token disponível para o host ou o contexto ser cancelado
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	for {
+		delay := l.reserve(host)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve consome um token se houver e retorna 0, ou retorna quanto esperar pelo próximo
+func (l *HostLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[host] = bucket
+	}
+
+	// Repor os tokens acumulados desde a última requisição
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+	return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+}
+
+// hostOf retorna o host da URL sem o prefixo "www.", usado como chave do limitador
+func hostOf(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// limitedTransport espera pelo limitador do host antes de cada requisição
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *HostLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), hostOf(req.URL)); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// limitTransport aplica o limitador às requisições do transporte (nil deixa o transporte sem limite)
+func limitTransport(base http.RoundTripper, limiter *HostLimiter) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if limiter == nil {
+		return base
+	}
+	return &limitedTransport{base: base, limiter: limiter}
+}
@@ -0,0 +1,186 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxRedirects limita quantos redirecionamentos ResolveURL segue antes de desistir
+const maxRedirects = 10
+
+// shortLinkHosts são encurtadores e domínios de links de afiliado que só revelam o produto depois do redirecionamento
+var shortLinkHosts = map[string]bool{
+	"amzn.to":          true,
+	"a.co":             true,
+	"magalu.me":        true,
+	"mercadolivre.com": true, // mercadolivre.com/sec/...
+	"bit.ly":           true,
+	"tinyurl.com":      true,
+	"cutt.ly":          true,
+	"is.gd":            true,
+	"t.co":             true,
+}
+
+// trackingParams são parâmetros de rastreamento de anúncios e campanhas, removidos de qualquer loja
+// Famílias de parâmetros terminam em "_" e são comparadas pelo prefixo (ex: utm_source)
+var trackingParams = paramSet("fbclid", "gclid", "gbraid", "wbraid", "msclkid", "srsltid", "_gl", "mc_cid", "mc_eid", "utm_")
+
+// storeTrackingParams são os parâmetros de afiliados e de navegação de cada loja, por domínio
+// Nomes como tag e ref só são removidos nas lojas em que não mudam o produto exibido
+var storeTrackingParams = map[string]map[string]bool{
+	"amazon.com.br": paramSet("tag", "ascsubtag", "linkcode", "linkid", "creative", "creativeasin", "camp", "ref", "ref_", "pd_rd_", "pf_rd_"),
+	"mercadolivre.com.br": paramSet("tracking_id", "searchvariation", "search_layout", "polycard_client", "matt_",
+		"c_id", "c_uid", "c_element_order", "c_campaign", "c_label", "c_element_id", "reco_backend", "reco_client", "reco_item_pos", "reco_id"),
+	"magazineluiza.com.br": paramSet("partner_id"),
+}
+
+// paramSet monta o conjunto de nomes de parâmetros usado por isTrackingParam
+func paramSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// isShortLink indica se a URL é de um encurtador ou de um link de afiliado
+func isShortLink(u *url.URL) bool {
+	return shortLinkHosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]
+}
+
+// storeParams retorna os parâmetros de afiliados e de navegação da loja do host, ou nil se não houver
+func storeParams(host string) map[string]bool {
+	host = strings.ToLower(host)
+	for domain, params := range storeTrackingParams {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return params
+		}
+	}
+	return nil
+}
+
+// isTrackingParam indica se o parâmetro de query é de rastreamento ou, na loja de store, de afiliado
+func isTrackingParam(name string, store map[string]bool) bool {
+	name = strings.ToLower(name)
+	for _, set := range []map[string]bool{trackingParams, store} {
+		if set[name] {
+			return true
+		}
+		for family := range set {
+			if strings.HasSuffix(family, "_") && strings.HasPrefix(name, family) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stripTrackingParams remove da URL o fragmento e os parâmetros de rastreamento e de afiliados
+// Os demais parâmetros ficam na ordem e com a codificação originais, já que algumas lojas dependem deles
+func stripTrackingParams(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	store := storeParams(u.Hostname())
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !isTrackingParam(name, store) {
+			kept = append(kept, param)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// newRedirectClient cria o cliente usado por ResolveURL, que segue os redirecionamentos um a um
+func newRedirectClient(limiter *HostLimiter) *http.Client {
+	return &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: limitTransport(nil, limiter),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ResolveURL segue os redirecionamentos de links encurtados e de afiliados (até maxRedirects)
+// e retorna a URL da página do produto sem parâmetros de rastreamento
+// URLs que já são de uma página de produto conhecida não geram requisições
+func (r *Registry) ResolveURL(ctx context.Context, rawURL string) (string, error) {
+	current, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (current.Scheme != "http" && current.Scheme != "https") || current.Host == "" {
+		return "", fmt.Errorf("URL inválida: %s", rawURL)
+	}
+
+	for hops := 0; isShortLink(current) || (hops > 0 && !r.knownProductURL(current.String())); hops++ {
+		if hops == maxRedirects {
+			return "", fmt.Errorf("o link passou por mais de %d redirecionamentos", maxRedirects)
+		}
+
+		next, err := r.nextRedirect(ctx, current)
+		if err != nil {
+			return "", err
+		}
+		if next == nil {
+			break
+		}
+		current = next
+	}
+
+	return stripTrackingParams(current.String()), nil
+}
+
+// knownProductURL indica se a URL é tratada por um scraper dedicado que identifica o produto
+func (r *Registry) knownProductURL(rawURL string) bool {
+	keyer, ok := r.FindScraper(rawURL).(ProductKeyer)
+	if !ok {
+		return false
+	}
+	_, ok = keyer.ProductKey(rawURL)
+	return ok
+}
+
+// nextRedirect faz uma requisição à URL e retorna o destino do redirecionamento, ou nil se a resposta não redirecionar
+func (r *Registry) nextRedirect(ctx context.Context, u *url.URL) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
+
+	resp, err := r.resolver.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir %s: %v", u.Host, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return nil, nil
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, nil
+	}
+	next, err := u.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("redirecionamento inválido de %s: %v", u.Host, err)
+	}
+	return next, nil
+}
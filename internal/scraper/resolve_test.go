@@ -0,0 +1,190 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// redirectServer responde com os redirecionamentos informados por host+caminho e registra as URLs pedidas
+// URLs sem redirecionamento respondem 200
+type redirectServer struct {
+	redirects map[string]string
+
+	mu        sync.Mutex
+	requested []string
+}
+
+func (s *redirectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requested = append(s.requested, r.Host+r.URL.Path)
+	s.mu.Unlock()
+
+	if location, ok := s.redirects[r.Host+r.URL.Path]; ok {
+		http.Redirect(w, r, location, http.StatusFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *redirectServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+// newResolveRegistry cria um Registry cujo ResolveURL envia as requisições ao servidor de teste
+func newResolveRegistry(t *testing.T, server *redirectServer) *Registry {
+	t.Helper()
+
+	registry := NewRegistry(Options{})
+	registry.resolver = newStoreServer(t, server)
+	registry.resolver.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return registry
+}
+
+func TestResolveURLShortLinks(t *testing.T) {
+	const product = "https://www.amazon.com.br/dp/B0C1234567?tag=afiliado-20&th=1&linkCode=ll1"
+	const want = "https://www.amazon.com.br/dp/B0C1234567?th=1"
+
+	for host := range shortLinkHosts {
+		for _, prefix := range []string{"", "www."} {
+			t.Run(prefix+host, func(t *testing.T) {
+				server := &redirectServer{redirects: map[string]string{prefix + host + "/abc": product}}
+				registry := newResolveRegistry(t, server)
+
+				resolved, err := registry.ResolveURL(context.Background(), "https://"+prefix+host+"/abc")
+				if err != nil {
+					t.Fatalf("ResolveURL retornou erro: %v", err)
+				}
+				if resolved != want {
+					t.Errorf("ResolveURL = %q, esperado %q", resolved, want)
+				}
+				// A página da Amazon já é de um produto conhecido e não é aberta
+				if requests := server.requests(); len(requests) != 1 {
+					t.Errorf("requisições = %v, esperado só a do link encurtado", requests)
+				}
+			})
+		}
+	}
+}
+
+func TestResolveURLFollowsUnknownHops(t *testing.T) {
+	server := &redirectServer{redirects: map[string]string{
+		"bit.ly/abc":      "https://click.example/r?id=1",
+		"click.example/r": "https://loja.example/produto?id=1&utm_source=bitly&tag=azul",
+	}}
+	registry := newResolveRegistry(t, server)
+
+	resolved, err := registry.ResolveURL(context.Background(), "https://bit.ly/abc")
+	if err != nil {
+		t.Fatalf("ResolveURL retornou erro: %v", err)
+	}
+	// tag só é parâmetro de afiliado na Amazon; em outras lojas pode escolher o produto
+	if want := "https://loja.example/produto?id=1&tag=azul"; resolved != want {
+		t.Errorf("ResolveURL = %q, esperado %q", resolved, want)
+	}
+	if requests := server.requests(); len(requests) != 3 {
+		t.Errorf("requisições = %v, esperado as três páginas da cadeia", requests)
+	}
+}
+
+func TestResolveURLHopLimit(t *testing.T) {
+	// Cada página do encurtador redireciona para outra do mesmo encurtador
+	redirects := make(map[string]string)
+	for i := 0; i <= maxRedirects; i++ {
+		redirects["bit.ly/"+strings.Repeat("a", i+1)] = "https://bit.ly/" + strings.Repeat("a", i+2)
+	}
+	server := &redirectServer{redirects: redirects}
+	registry := newResolveRegistry(t, server)
+
+	_, err := registry.ResolveURL(context.Background(), "https://bit.ly/a")
+	if err == nil || !strings.Contains(err.Error(), "redirecionamentos") {
+		t.Fatalf("ResolveURL retornou erro %v, esperado o limite de redirecionamentos", err)
+	}
+	if requests := server.requests(); len(requests) != maxRedirects {
+		t.Errorf("%d requisições, esperado %d", len(requests), maxRedirects)
+	}
+}
+
+func TestResolveURLDirectLinks(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.amazon.com.br/dp/B0C1234567?tag=afiliado-20&ref_=abc", "https://www.amazon.com.br/dp/B0C1234567"},
+		{"https://loja.example/produto?tag=azul#avaliacoes", "https://loja.example/produto?tag=azul"},
+	}
+
+	for _, tt := range tests {
+		server := &redirectServer{}
+		registry := newResolveRegistry(t, server)
+
+		resolved, err := registry.ResolveURL(context.Background(), tt.url)
+		if err != nil {
+			t.Fatalf("ResolveURL(%q) retornou erro: %v", tt.url, err)
+		}
+		if resolved != tt.want {
+			t.Errorf("ResolveURL(%q) = %q, esperado %q", tt.url, resolved, tt.want)
+		}
+		// Links que não são de encurtadores não geram requisições
+		if requests := server.requests(); len(requests) != 0 {
+			t.Errorf("ResolveURL(%q) fez as requisições %v, esperado nenhuma", tt.url, requests)
+		}
+	}
+
+	if _, err := NewRegistry(Options{}).ResolveURL(context.Background(), "ftp://loja.example/produto"); err == nil {
+		t.Error("ResolveURL aceitou uma URL que não é http")
+	}
+}
+
+func TestStripTrackingParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			"afiliados da Amazon",
+			"https://www.amazon.com.br/dp/B0C1234567?ascsubtag=x&th=1&pd_rd_w=abc&psc=1&camp=1789&creative=9325",
+			"https://www.amazon.com.br/dp/B0C1234567?th=1&psc=1",
+		},
+		{
+			"rastreamento do Mercado Livre",
+			"https://produto.mercadolivre.com.br/MLB-50097091-fone?tracking_id=abc&matt_tool=123&c_id=x#polycard",
+			"https://produto.mercadolivre.com.br/MLB-50097091-fone",
+		},
+		{
+			"parâmetros de campanha em qualquer loja",
+			"https://www.kabum.com.br/produto/123?utm_source=google&gclid=abc&fbclid=def",
+			"https://www.kabum.com.br/produto/123",
+		},
+		{
+			"parâmetros de afiliados de uma loja ficam nas demais",
+			"https://loja.example/p?ref=home&sid=1&camp=verao&creative=2",
+			"https://loja.example/p?ref=home&sid=1&camp=verao&creative=2",
+		},
+		{
+			"ordem e codificação mantidas",
+			"https://loja.example/busca?z=1&q=fone%20bluetooth&utm_medium=cpc&a=b%2Bc&UTM_Campaign=x",
+			"https://loja.example/busca?z=1&q=fone%20bluetooth&a=b%2Bc",
+		},
+		{
+			"nome de parâmetro codificado",
+			"https://loja.example/p?id=7&utm%5Fsource=x",
+			"https://loja.example/p?id=7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTrackingParams(tt.url); got != tt.want {
+				t.Errorf("stripTrackingParams = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,35 +1,230 @@
 package scraper
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"bot-produtos/internal/models"
+	"bot-produtos/internal/money"
+)
+
+// ProductSnapshot contém todos os dados extraídos de uma página de produto
+// em uma única requisição
+type ProductSnapshot struct {
+	URL           string // URL efetivamente consultada
+	Name          string
+	CurrentPrice  money.Money         // Menor preço pago pelo cliente (à vista quando a loja diferencia)
+	CashPrice     money.Money         // Preço à vista no Pix/boleto, zero se a loja não diferenciar
+	CardPrice     money.Money         // Preço no cartão, zero se a loja não diferenciar
+	Installments  models.Installments // Parcelamento exibido pela loja, se houver
+	OriginalPrice money.Money         // Preço original (antes do desconto), zero se não houver
+	Discount      float64             // Percentual de desconto (0-100), 0 se não houver
+	Currency      string              // Código ISO 4217 da moeda (ex: BRL)
+	Availability  Availability
+	StockQuantity int              // Unidades disponíveis exibidas pela página, 0 se não informadas
+	Shipping      models.Shipping  // Frete exibido na página (sem CEP definido), se houver
+	Seller        models.Seller    // Vendedor do anúncio, quando a loja informa
+	Offers        []models.Offer   // Ofertas dos vendedores que disputam uma página de catálogo, da mais barata para a mais cara
+	Variant       models.Variant   // Variante exibida pela URL (ex: 127V), vazia se o produto não tiver variantes
+	Variants      []models.Variant // Variantes disponíveis para escolha, incluindo a exibida
+	Coupons       []models.Coupon  // Cupons de desconto ativos na página
+	Deal          models.Deal      // Selo promocional (ex: "Oferta relâmpago") e fim da oferta
+	FetchedAt     time.Time
+	Extraction    Extraction
+}
+
+// Availability indica se o produto pode ser comprado no momento
+type Availability string
+
+const (
+	AvailabilityUnknown    Availability = ""             // A página não informa a disponibilidade
+	AvailabilityInStock    Availability = "in_stock"     // Produto disponível para compra
+	AvailabilityLimited    Availability = "limited"      // Disponível, mas com poucas unidades
+	AvailabilityOutOfStock Availability = "out_of_stock" // Produto esgotado ou indisponível
+)
+
+// limitedStockThreshold é a maior quantidade exibida que ainda é tratada como estoque limitado
+const limitedStockThreshold = 10
+
+// Purchasable indica se o produto pode ser comprado (em estoque ou com estoque limitado)
+func (a Availability) Purchasable() bool {
+	return a == AvailabilityInStock || a == AvailabilityLimited
+}
+
+// stockAvailability classifica a quantidade exata exibida pela página
+func stockAvailability(quantity int) Availability {
+	switch {
+	case quantity <= 0:
+		return AvailabilityOutOfStock
+	case quantity <= limitedStockThreshold:
+		return AvailabilityLimited
+	}
+	return AvailabilityInStock
+}
+
+// Confidence indica o quanto a leitura do preço é confiável em páginas sem scraper dedicado
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"   // Dados estruturados do produto (JSON-LD)
+	ConfidenceMedium Confidence = "medium" // Meta tags ou atributos de microdata
+	ConfidenceLow    Confidence = "low"    // Texto visível de um elemento marcado como preço
+)
+
+// Extraction descreve como os dados do snapshot foram obtidos
+type Extraction struct {
+	Scraper     string        // Nome do scraper que gerou o snapshot
+	StatusCode  int           // Status HTTP da resposta
+	PriceSource string        // Seletor ou estratégia que encontrou o preço atual
+	Confidence  Confidence    // Confiança na leitura do preço (vazia em scrapers dedicados)
+	Duration    time.Duration // Tempo gasto com download e parse
+	Fallback    string        // Por que a fonte principal (ex: API da loja) falhou e a página foi lida, vazio se não falhou
+}
+
 // Scraper define a interface para scrapers de diferentes lojas
 type Scraper interface {
-	GetPrice(url string) (float64, error)
-	GetOriginalPrice(url string) (float64, error) // Preço original (antes do desconto)
-	GetDiscount(url string) (float64, error)      // Percentual de desconto (0-100)
-	GetName(url string) (string, error)
+	// Scrape baixa a página uma única vez e extrai todos os dados do produto
+	// Um produto esgotado cuja página não mostra preço não é erro: o snapshot volta sem preço
+	// e com Availability AvailabilityOutOfStock, para que a indisponibilidade seja registrada
+	Scrape(ctx context.Context, url string) (ProductSnapshot, error)
 	CanHandle(url string) bool
 }
 
+// ShippingQuoter é implementado por scrapers que conseguem cotar o frete para um CEP
+type ShippingQuoter interface {
+	QuoteShipping(ctx context.Context, url, cep string) (models.Shipping, error)
+}
+
+// ProductKeyer é implementado por scrapers que identificam o produto pelo código da loja,
+// para que formas diferentes da mesma URL (catálogo, anúncio, links com rastreamento) virem um único produto
+type ProductKeyer interface {
+	// ProductKey retorna a chave canônica do produto (ex: ML:MLB50097091) ou false se a URL não tiver o código
+	ProductKey(url string) (string, bool)
+}
+
+// ErrShippingUnsupported indica que a loja da URL não permite cotar o frete para um CEP
+var ErrShippingUnsupported = errors.New("cotação de frete por CEP não suportada para esta loja")
+
 // Registry mantém um registro de todos os scrapers disponíveis
 type Registry struct {
 	scrapers []Scraper
+	fallback Scraper      // Usado quando nenhum scraper dedicado aceita a URL
+	resolver *http.Client // Cliente de ResolveURL, que não segue redirecionamentos sozinho
+	limiter  *HostLimiter // Limite de requisições por host, compartilhado por todos os scrapers
+
+	// Scrapers carregados do arquivo de configuração; têm prioridade sobre os embutidos
+	// para que um seletor quebrado possa ser corrigido sem recompilar
+	mu         sync.RWMutex
+	configured []Scraper
+	configPath string
+}
+
+// Options configura os scrapers embutidos
+type Options struct {
+	MercadoLivreAPIURL string  // Endereço da API pública do Mercado Livre; vazio usa apenas o HTML
+	HostRatePerMinute  float64 // Requisições por minuto permitidas para cada host (0 desativa o limite)
+	HostBurst          int     // Requisições seguidas permitidas para um host antes de aplicar o limite
 }
 
 // NewRegistry cria um novo registro de scrapers
-func NewRegistry() *Registry {
+// Todas as requisições dos scrapers, inclusive às APIs das lojas, respeitam o limite por host das opções
+func NewRegistry(options Options) *Registry {
+	limiter := NewHostLimiter(options.HostRatePerMinute, options.HostBurst)
 	return &Registry{
 		scrapers: []Scraper{
-			NewMercadoLivreScraper(),
+			NewMercadoLivreScraper(options.MercadoLivreAPIURL, limiter),
+			NewAmazonScraper(limiter),
+			NewMagazineLuizaScraper(limiter),
+			NewKabumScraper(limiter),
+			NewCasasBahiaScraper(limiter),
 		},
+		fallback: NewGenericScraper(limiter),
+		resolver: newRedirectClient(limiter),
+		limiter:  limiter,
+	}
+}
+
+// LoadConfig carrega as lojas declaradas no arquivo JSON e retorna quantas foram registradas
+// O caminho é lembrado por Reload mesmo se a leitura falhar (ex: arquivo ainda não criado);
+// em caso de erro, os scrapers carregados anteriormente são mantidos
+func (r *Registry) LoadConfig(path string) (int, error) {
+	r.mu.Lock()
+	r.configPath = path
+	r.mu.Unlock()
+
+	stores, err := LoadStoreConfigs(path)
+	if err != nil {
+		return 0, err
 	}
+
+	configured := make([]Scraper, 0, len(stores))
+	for _, store := range stores {
+		configured = append(configured, NewDeclarativeScraper(store, r.limiter))
+	}
+
+	r.mu.Lock()
+	r.configured = configured
+	r.mu.Unlock()
+
+	return len(configured), nil
+}
+
+// Reload relê o arquivo carregado por LoadConfig
+func (r *Registry) Reload() (int, error) {
+	r.mu.RLock()
+	path := r.configPath
+	r.mu.RUnlock()
+
+	if path == "" {
+		return 0, fmt.Errorf("nenhum arquivo de scrapers configurado")
+	}
+	return r.LoadConfig(path)
+}
+
+// QuoteShipping cota o frete do produto para o CEP usando o scraper da URL
+// Retorna ErrShippingUnsupported quando o scraper não implementa ShippingQuoter
+func (r *Registry) QuoteShipping(ctx context.Context, url, cep string) (models.Shipping, error) {
+	quoter, ok := r.FindScraper(url).(ShippingQuoter)
+	if !ok {
+		return models.Shipping{}, ErrShippingUnsupported
+	}
+	return quoter.QuoteShipping(ctx, url, cep)
+}
+
+// ProductKey retorna a chave canônica do produto da URL, usada para não cadastrar o mesmo produto duas vezes
+// Sem código de produto conhecido, a chave é a URL normalizada (ver urlKey)
+func (r *Registry) ProductKey(url string) string {
+	if keyer, ok := r.FindScraper(url).(ProductKeyer); ok {
+		if key, ok := keyer.ProductKey(url); ok {
+			return key
+		}
+	}
+	return urlKey(url)
 }
 
 // FindScraper encontra o scraper apropriado para uma URL
+// Sem scraper dedicado, retorna o scraper genérico; nil apenas para URLs inválidas
 func (r *Registry) FindScraper(url string) Scraper {
+	r.mu.RLock()
+	configured := r.configured
+	r.mu.RUnlock()
+
+	for _, scraper := range configured {
+		if scraper.CanHandle(url) {
+			return scraper
+		}
+	}
 	for _, scraper := range r.scrapers {
 		if scraper.CanHandle(url) {
 			return scraper
 		}
 	}
+	if r.fallback != nil && r.fallback.CanHandle(url) {
+		return r.fallback
+	}
 	return nil
 }
-